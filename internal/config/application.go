@@ -15,6 +15,11 @@ type Application struct {
 	version string
 }
 
+// NewApplication returns a new Application configuration of the given semantic version.
+func NewApplication(version string) Application {
+	return Application{version: version}
+}
+
 // Version returns the semantic version of the application. Ref: https://semver.org/
 func (a Application) Version() string {
 	return a.version
@@ -33,9 +33,27 @@ func setDefaultConfig() {
 	viper.SetDefault("http.server.port", 8080)
 	viper.SetDefault("http.server.shutdown.timeout", time.Second*15)
 	viper.SetDefault("http.data_api.url", "https://thecocktaildb.com/api/json/v1/1/search.php?f=a")
+	viper.SetDefault("http.auth.keys_file", "")
+	viper.SetDefault("http.auth.keys", "")
+	viper.SetDefault("http.auth.insecure_open_admin", false)
+	viper.SetDefault("http.auth.jwt.hmac_secrets", "")
+	viper.SetDefault("http.auth.jwt.public_key_files", "")
+	viper.SetDefault("http.auth.jwt.jwks_file", "")
+	viper.SetDefault("http.auth.jwt.audience", "")
+	viper.SetDefault("http.auth.jwt.role_claim", "role")
+	viper.SetDefault("http.auth.jwt.role_mapping", "")
+	viper.SetDefault("http.auth.jwt.leeway", time.Minute)
+	viper.SetDefault("http.rate_limit.rate", 10)
+	viper.SetDefault("http.rate_limit.burst", 20)
+	viper.SetDefault("http.rate_limit.daily_quota", 0)
+	viper.SetDefault("http.rate_limit.groups", "")
 	viper.SetDefault("database.driver", "csv")
 	viper.SetDefault("database.csv.file_name", "cocktails.csv")
 	viper.SetDefault("database.csv.data_dir", "./data")
+	viper.SetDefault("database.csv.lock_timeout", 5*time.Second)
+	viper.SetDefault("database.csv.mode", "replace")
+	viper.SetDefault("database.csv.compact_threshold", 1000)
+	viper.SetDefault("database.sync.removed_policy", "ignore")
 }
 
 // newConfig creates a new Config instance of type singleton.
@@ -61,12 +79,38 @@ func newConfig() Config {
 				DataAPI: DataAPI{
 					url: viper.GetString("http.data_api.url"),
 				},
+				Auth: Auth{
+					keysFile:  viper.GetString("http.auth.keys_file"),
+					keys:      splitEntries(viper.GetString("http.auth.keys")),
+					openAdmin: viper.GetBool("http.auth.insecure_open_admin"),
+					JWT: JWT{
+						hmacSecrets:    splitEntries(viper.GetString("http.auth.jwt.hmac_secrets")),
+						publicKeyFiles: splitEntries(viper.GetString("http.auth.jwt.public_key_files")),
+						jwksFile:       viper.GetString("http.auth.jwt.jwks_file"),
+						audience:       splitEntries(viper.GetString("http.auth.jwt.audience")),
+						roleClaim:      viper.GetString("http.auth.jwt.role_claim"),
+						roleMapping:    splitEntries(viper.GetString("http.auth.jwt.role_mapping")),
+						leeway:         viper.GetDuration("http.auth.jwt.leeway"),
+					},
+				},
+				RateLimit: RateLimit{
+					rate:       viper.GetFloat64("http.rate_limit.rate"),
+					burst:      viper.GetInt("http.rate_limit.burst"),
+					dailyQuota: viper.GetInt("http.rate_limit.daily_quota"),
+					groups:     splitEntries(viper.GetString("http.rate_limit.groups")),
+				},
 			},
 			Database: Database{
 				driver: viper.GetString("database.driver"),
 				Csv: CsvDB{
-					fileName: viper.GetString("database.csv.file_name"),
-					dataDir:  viper.GetString("database.csv.data_dir"),
+					fileName:         viper.GetString("database.csv.file_name"),
+					dataDir:          viper.GetString("database.csv.data_dir"),
+					lockTimeout:      viper.GetDuration("database.csv.lock_timeout"),
+					mode:             viper.GetString("database.csv.mode"),
+					compactThreshold: viper.GetInt("database.csv.compact_threshold"),
+				},
+				Sync: Sync{
+					removedPolicy: viper.GetString("database.sync.removed_policy"),
 				},
 			},
 		}
@@ -77,3 +121,14 @@ func newConfig() Config {
 	})
 	return cfg
 }
+
+// splitEntries returns the non-empty trimmed entries of a comma separated list.
+func splitEntries(list string) []string {
+	entries := make([]string, 0)
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
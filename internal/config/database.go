@@ -1,11 +1,15 @@
 package config
 
-import "path/filepath"
+import (
+	"path/filepath"
+	"time"
+)
 
 // Database holds the configurations of the supported databases
 type Database struct {
 	driver string
 	Csv    CsvDB
+	Sync   Sync
 }
 
 // Driver returns the configured database driver.
@@ -15,8 +19,11 @@ func (db Database) Driver() string {
 
 // CsvDB holds and retrieves the config properties of the CSV database.
 type CsvDB struct {
-	fileName string
-	dataDir  string
+	fileName         string
+	dataDir          string
+	lockTimeout      time.Duration
+	mode             string
+	compactThreshold int
 }
 
 // NewCsv returns a new CsvDB configuration implementation.
@@ -37,8 +44,57 @@ func (c CsvDB) DataDir() string {
 	return c.dataDir
 }
 
+// LockTimeout returns how long the accesses to the CSV database file wait for its lock.
+// Zero or less means the repository default.
+func (c CsvDB) LockTimeout() time.Duration {
+	return c.lockTimeout
+}
+
+// WithLockTimeout returns a copy of the configuration waiting for the CSV database file lock up to the given timeout.
+func (c CsvDB) WithLockTimeout(timeout time.Duration) CsvDB {
+	c.lockTimeout = timeout
+	return c
+}
+
+// Mode returns how the CSV database file is written: "replace" rewrites it on every write, while "journal" appends
+// the single-record writes to a journal, folded into the file by the compactions. Empty means "replace".
+func (c CsvDB) Mode() string {
+	return c.mode
+}
+
+// CompactThreshold returns the number of journal entries past which the journal is compacted automatically.
+// Zero or less means the repository default.
+func (c CsvDB) CompactThreshold() int {
+	return c.compactThreshold
+}
+
+// WithMode returns a copy of the configuration writing the CSV database file in the given mode, compacting its
+// journal past the given number of entries. See Mode.
+func (c CsvDB) WithMode(mode string, compactThreshold int) CsvDB {
+	c.mode = mode
+	c.compactThreshold = compactThreshold
+	return c
+}
+
 // FilePath returns the full path of the CSV database file.
 // The file path is built by prefixing the data directory to the file name.
 func (c CsvDB) FilePath() string {
 	return filepath.Join(c.dataDir, c.fileName)
 }
+
+// Sync holds and retrieves the config properties of the database updates from the data API.
+type Sync struct {
+	removedPolicy string
+}
+
+// NewSync returns a new Sync configuration implementation.
+func NewSync(removedPolicy string) Sync {
+	return Sync{
+		removedPolicy: removedPolicy,
+	}
+}
+
+// RemovedPolicy returns the handling of the records removed from the data API: "ignore", "flag" or "delete".
+func (s Sync) RemovedPolicy() string {
+	return s.removedPolicy
+}
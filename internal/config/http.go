@@ -7,8 +7,10 @@ import (
 
 // HTTP holds the configurations regarding HTTP items.
 type HTTP struct {
-	Server  HttpServer
-	DataAPI DataAPI
+	Server    HttpServer
+	DataAPI   DataAPI
+	Auth      Auth
+	RateLimit RateLimit
 }
 
 // HttpServer is the config used to set the http server instance.
@@ -44,3 +46,151 @@ func NewDataAPI(url string) DataAPI {
 func (a DataAPI) URL() string {
 	return a.url
 }
+
+// Auth holds the API keys authentication configuration.
+// The keys are given as "name:role:sha256" entries, where sha256 is the hex encoded SHA-256 hash of the key,
+// either inline or in a CSV keys file holding "name,role,sha256" records.
+// The JWT bearer tokens are verified by the JWT configuration.
+// With no keys configured, the unauthenticated requests are only granted the reader role, unless the open admin
+// access is explicitly enabled, for local development.
+type Auth struct {
+	keysFile  string
+	keys      []string
+	openAdmin bool
+	JWT       JWT
+}
+
+// NewAuth returns a new Auth configuration implementation.
+func NewAuth(keysFile string, keys []string, jwt JWT) Auth {
+	return Auth{
+		keysFile: keysFile,
+		keys:     keys,
+		JWT:      jwt,
+	}
+}
+
+// KeysFile returns the path of the CSV keys file. Empty if it is not set.
+func (a Auth) KeysFile() string {
+	return a.keysFile
+}
+
+// Keys returns the inline "name:role:sha256" key entries.
+func (a Auth) Keys() []string {
+	return a.keys
+}
+
+// InsecureOpenAdmin reports whether the unauthenticated requests are granted the admin role when no keys are
+// configured. It is meant for local development only.
+func (a Auth) InsecureOpenAdmin() bool {
+	return a.openAdmin
+}
+
+// WithInsecureOpenAdmin returns a copy of the configuration granting the admin role to the unauthenticated
+// requests when no keys are configured, if open is true. See InsecureOpenAdmin.
+func (a Auth) WithInsecureOpenAdmin(open bool) Auth {
+	a.openAdmin = open
+	return a
+}
+
+// JWT holds the JWT bearer tokens verification configuration.
+// The tokens are verified with local keys only: HMAC secrets, PEM encoded RSA or ECDSA public key files,
+// or a JWKS file; so no network is needed.
+type JWT struct {
+	hmacSecrets    []string
+	publicKeyFiles []string
+	jwksFile       string
+	audience       []string
+	roleClaim      string
+	roleMapping    []string
+	leeway         time.Duration
+}
+
+// NewJWT returns a new JWT configuration implementation.
+func NewJWT(hmacSecrets, publicKeyFiles []string, jwksFile string, audience []string, roleClaim string,
+	roleMapping []string, leeway time.Duration) JWT {
+	return JWT{
+		hmacSecrets:    hmacSecrets,
+		publicKeyFiles: publicKeyFiles,
+		jwksFile:       jwksFile,
+		audience:       audience,
+		roleClaim:      roleClaim,
+		roleMapping:    roleMapping,
+		leeway:         leeway,
+	}
+}
+
+// HMACSecrets returns the secrets verifying the HMAC signed tokens.
+func (j JWT) HMACSecrets() []string {
+	return j.hmacSecrets
+}
+
+// PublicKeyFiles returns the paths of the PEM encoded RSA or ECDSA public keys verifying the signed tokens.
+func (j JWT) PublicKeyFiles() []string {
+	return j.publicKeyFiles
+}
+
+// JWKSFile returns the path of the JSON Web Key Set file verifying the signed tokens. Empty if it is not set.
+func (j JWT) JWKSFile() string {
+	return j.jwksFile
+}
+
+// Audience returns the accepted "aud" claim values. Empty accepts any audience.
+func (j JWT) Audience() []string {
+	return j.audience
+}
+
+// RoleClaim returns the name of the claim holding the caller roles. e.g. "role"
+func (j JWT) RoleClaim() string {
+	return j.roleClaim
+}
+
+// RoleMapping returns the "claim:role" entries mapping the role claim values to the API roles.
+// e.g. "bar-manager:admin"
+func (j JWT) RoleMapping() []string {
+	return j.roleMapping
+}
+
+// Leeway returns the clock skew tolerated validating the "exp" and "nbf" claims.
+func (j JWT) Leeway() time.Duration {
+	return j.leeway
+}
+
+// RateLimit holds the per-client rate limits configuration.
+// The default limits apply to every route group, unless overridden by a "name:rate:burst:quota" group entry,
+// where name is the controller name. e.g. "Webhook:1:5:100"
+type RateLimit struct {
+	rate       float64
+	burst      int
+	dailyQuota int
+	groups     []string
+}
+
+// NewRateLimit returns a new RateLimit configuration implementation.
+func NewRateLimit(rate float64, burst, dailyQuota int, groups []string) RateLimit {
+	return RateLimit{
+		rate:       rate,
+		burst:      burst,
+		dailyQuota: dailyQuota,
+		groups:     groups,
+	}
+}
+
+// Rate returns the default number of requests per second a client is allowed. Zero disables the rate limit.
+func (r RateLimit) Rate() float64 {
+	return r.rate
+}
+
+// Burst returns the default number of requests a client is allowed at once.
+func (r RateLimit) Burst() int {
+	return r.burst
+}
+
+// DailyQuota returns the default number of requests a client is allowed per day. Zero disables the quota.
+func (r RateLimit) DailyQuota() int {
+	return r.dailyQuota
+}
+
+// Groups returns the "name:rate:burst:quota" entries overriding the default limits of the route groups.
+func (r RateLimit) Groups() []string {
+	return r.groups
+}
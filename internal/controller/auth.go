@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+)
+
+// RenderError responds the given error in JSON format, along with its HTTP status code.
+// It lets the middlewares out of the controllers respond errors the same way the handlers do.
+func RenderError(w http.ResponseWriter, r *http.Request, err error) {
+	errJSON(w, r, err)
+}
+
+// requireRole returns a middleware allowing only the requests whose principal is granted the given role.
+// The principal is set in the request context by the authentication middleware. See authorize.
+func requireRole(role ct.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := authorize(r, role); err != nil {
+				errJSON(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authorize returns an error unless the principal of the request is granted the given role.
+// The requests with no principal, or an anonymous one not granted the role, are unauthenticated, so they get an
+// AuthErr; the authenticated principals not granted the role get a ForbiddenErr.
+func authorize(r *http.Request, role ct.Role) error {
+	principal, ok := ct.PrincipalFrom(r.Context())
+	if !ok || (principal.Method == ct.AnonymousAuth && !principal.Role.Allows(role)) {
+		return &AuthErr{ErrMissingCredentials}
+	}
+	if !principal.Role.Allows(role) {
+		return &ForbiddenErr{fmt.Errorf("%w: %q requires the %s role", ErrRoleForbidden, principal.Name, role)}
+	}
+	return nil
+}
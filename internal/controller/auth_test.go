@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller/mocks"
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *ct.Principal
+		code      int
+		err       errHTTP
+		wantErr   bool
+	}{
+		{
+			name:    "Unauthenticated",
+			code:    http.StatusUnauthorized,
+			err:     newTestProblem(AuthErrCode, "authentication: missing credentials"),
+			wantErr: true,
+		},
+		{
+			name:      "Anonymous",
+			principal: &ct.Principal{Name: "anonymous", Role: ct.ReaderRole, Method: ct.AnonymousAuth},
+			code:      http.StatusUnauthorized,
+			err:       newTestProblem(AuthErrCode, "authentication: missing credentials"),
+			wantErr:   true,
+		},
+		{
+			name:      "Anonymous admin",
+			principal: &ct.Principal{Name: "anonymous", Role: ct.AdminRole, Method: ct.AnonymousAuth},
+			code:      http.StatusOK,
+		},
+		{
+			name:      "Insufficient role",
+			principal: &ct.Principal{Name: "ci", Role: ct.EditorRole},
+			code:      http.StatusForbidden,
+			err:       newTestProblem(ForbiddenErrCode, `authorization: forbidden: "ci" requires the admin role`),
+			wantErr:   true,
+		},
+		{
+			name:      "Granted",
+			principal: &ct.Principal{Name: "ops", Role: ct.AdminRole},
+			code:      http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewWebhookSvc()
+			mSvc.On("GetAll").Return([]entity.Webhook{{ID: "a1", URL: "https://foo.com/hook"}}, nil)
+			ctrl := NewWebhook(mSvc)
+
+			// Request
+			r, err := http.NewRequest("GET", "/webhooks", nil)
+			require.Nil(t, err)
+
+			// Server instance
+			rr := httptest.NewRecorder()
+			srv := newTestRouterAs(ctrl, tt.principal)
+			srv.ServeHTTP(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.wantErr {
+				var errResp errHTTP
+				require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &errResp))
+				assert.Equal(t, tt.err, errResp)
+				mSvc.AssertNotCalled(t, "GetAll")
+			}
+		})
+	}
+}
@@ -1,10 +1,16 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -19,10 +25,28 @@ type Cocktail struct {
 
 // CocktailSvc is the abstraction of the Cocktail service dependency.
 type CocktailSvc interface {
-	GetFiltered(filter, value string) ([]entity.Cocktail, error)
+	GetFilteredWithSuggestions(filter, value string) ([]entity.Cocktail, []ct.Suggestion, error)
 	GetAll() ([]entity.Cocktail, error)
+	Query(q string) ([]entity.Cocktail, error)
+	StreamAll(ctx context.Context, fn func(entity.Cocktail) error) error
+	GetStats(q string, top int) (ct.CocktailStats, error)
+	GetChanges(cursor string, limit int) (ct.ChangeFeed, error)
 	GetCC(nType, jobs, jWorker string) ([]entity.Cocktail, error)
-	UpdateDB() (ct.DBOpsSummary, error)
+	UpdateDB(ctx context.Context, restoreDeleted bool) (ct.DBOpsSummary, error)
+	Checksum() (string, error)
+	LastModified() (time.Time, error)
+	Get(id int) (entity.Cocktail, error)
+	Update(ctx context.Context, id, version int, c entity.Cocktail) (entity.Cocktail, error)
+	Delete(ctx context.Context, id, version int) error
+	History(id int) ([]entity.Revision, error)
+	GetAllAsOf(t time.Time) ([]entity.Cocktail, error)
+	GetAsOf(id int, t time.Time) (entity.Cocktail, error)
+	Rollback(ctx context.Context, txID string) (ct.DBOpsSummary, error)
+	GetAllWithDeleted() ([]entity.Cocktail, error)
+	GetWithDeleted(id int) (entity.Cocktail, error)
+	Restore(ctx context.Context, id int) (entity.Cocktail, error)
+	Purge(ctx context.Context, id int) error
+	Compact(ctx context.Context) (ct.CompactSummary, error)
 }
 
 // NewCocktail returns a new Cocktail controller implementation.
@@ -33,53 +57,466 @@ func NewCocktail(svc CocktailSvc) Cocktail {
 }
 
 // SetRoutes sets a fresh middleware stack for the handle functions and mounts the routes in the provided sub router.
+// Reading the recipes requires the reader role, editing and restoring them the editor role, and purging them,
+// updating or rolling back the database the admin role.
 func (c Cocktail) SetRoutes(r chi.Router) {
-	r.Get("/cocktail/{filter}/{value}", c.getFiltered)
-	r.Get("/cocktails", c.getAll)
-	r.Get("/cocktails/{type}/{items}/{items-worker}", c.getCC)
-	r.Get("/cocktail/updatedb", c.updateDB)
+	r.Group(func(r chi.Router) {
+		r.Use(requireRole(ct.ReaderRole))
+		r.Get("/cocktail/{filter}/{value}", c.getFiltered)
+		r.Get("/cocktails", c.getAll)
+		r.Get("/cocktails/{id:[0-9]+}", c.getOne)
+		r.Get("/cocktail/id/{id:[0-9]+}/history", c.getHistory)
+		r.Get("/cocktails/stream", c.streamAll)
+		r.Get("/cocktails/stats", c.getStats)
+		r.Get("/cocktails/{type}/{items}/{items-worker}", c.getCC)
+		r.Get("/changes", c.getChanges)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(requireRole(ct.EditorRole))
+		r.Put("/cocktails/{id:[0-9]+}", c.update)
+		r.Delete("/cocktails/{id:[0-9]+}", c.delete)
+		r.Post("/cocktails/{id:[0-9]+}/restore", c.restore)
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(requireRole(ct.AdminRole))
+		r.Get("/cocktail/updatedb", c.updateDB)
+		r.Post("/cocktail/rollback/{tx_id}", c.rollback)
+		r.Post("/cocktail/compact", c.compact)
+		r.Post("/cocktails/{id:[0-9]+}/purge", c.purge)
+	})
 }
 
-// getFiltered is a handler function that retrieve a list of filtered cocktails in the database in JSON format.
+// Docs returns the description of the Cocktail routes.
+func (c Cocktail) Docs() []RouteDoc {
+	listTypes := formatMediaTypes(listFormats)
+	return []RouteDoc{
+		{
+			Method:  http.MethodGet,
+			Pattern: "/cocktail/{filter}/{value}",
+			Summary: "Filter the recipes",
+			Desc:    "The recipes whose filter property matches the value. The JSON response is an object holding the recipes, along with the \"did you mean\" suggestions ranked from the most similar value if none matches; the rest of the formats hold the recipes alone.",
+			Role:    ct.ReaderRole,
+			Params: append([]ParamDoc{
+				{Name: "filter", In: "path", Required: true, Desc: "The filtered property.", Enum: []string{"id", "name", "alcoholic", "category", "ingredient", "glass", "tag"}},
+				{Name: "value", In: "path", Required: true, Desc: "The filter value, case insensitive."},
+			}, append(listParamDocs, conditionalParamDocs...)...),
+			Response:     []entity.Cocktail{},
+			JSONResponse: filteredResult[entity.Cocktail]{},
+			MediaTypes:   listTypes,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/cocktails",
+			Summary: "List the recipes",
+			Role:    ct.ReaderRole,
+			Params: append([]ParamDoc{
+				{Name: "q", In: "query", Desc: "A filter query the recipes must satisfy. e.g. category:cocktail AND glass:highball"},
+				asOfParamDoc,
+				includeDeletedParamDoc,
+			}, append(listParamDocs, conditionalParamDocs...)...),
+			Response:   []entity.Cocktail{},
+			MediaTypes: listTypes,
+		},
+		{
+			Method:     http.MethodGet,
+			Pattern:    "/cocktails/{id}",
+			Summary:    "Get a recipe",
+			Desc:       "The recipe in the negotiated format. The ETag of the response is the version of the recipe, as required by the If-Match header of its edits, followed by the hash of the response body.",
+			Role:       ct.ReaderRole,
+			Params:     append([]ParamDoc{idParamDoc, asOfParamDoc, includeDeletedParamDoc, recordFormatParamDoc}, conditionalParamDocs...),
+			Response:   entity.Cocktail{},
+			MediaTypes: formatMediaTypes(recordFormats),
+		},
+		{
+			Method:   http.MethodGet,
+			Pattern:  "/cocktail/id/{id}/history",
+			Summary:  "Recipe revision history",
+			Desc:     "The revisions of the recipe, from its creation on, made by the database updates and the edits. Every revision holds its author, the changed fields and the resulting recipe.",
+			Role:     ct.ReaderRole,
+			Params:   []ParamDoc{idParamDoc},
+			Response: []entity.Revision{},
+		},
+		{
+			Method:   http.MethodPut,
+			Pattern:  "/cocktails/{id}",
+			Summary:  "Edit a recipe",
+			Desc:     "Replaces the recipe values, provided it was not edited since its version in If-Match was read; 412 Precondition Failed is responded otherwise.",
+			Role:     ct.EditorRole,
+			Params:   []ParamDoc{idParamDoc, ifMatchParamDoc},
+			Body:     entity.Cocktail{},
+			Response: entity.Cocktail{},
+		},
+		{
+			Method:  http.MethodDelete,
+			Pattern: "/cocktails/{id}",
+			Summary: "Delete a recipe",
+			Desc:    "Deletes the recipe, provided it was not edited since its version in If-Match was read; 412 Precondition Failed is responded otherwise. The recipe is kept as a tombstone until purged, so it can be restored.",
+			Role:    ct.EditorRole,
+			Params:  []ParamDoc{idParamDoc, ifMatchParamDoc},
+			Status:  http.StatusNoContent,
+		},
+		{
+			Method:   http.MethodPost,
+			Pattern:  "/cocktails/{id}/restore",
+			Summary:  "Restore a deleted recipe",
+			Desc:     "Restores the deleted recipe, at its next version. 409 Conflict is responded if the recipe is not deleted.",
+			Role:     ct.EditorRole,
+			Params:   []ParamDoc{idParamDoc},
+			Response: entity.Cocktail{},
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/cocktails/{id}/purge",
+			Summary: "Purge a deleted recipe",
+			Desc:    "Removes the deleted recipe for good, so it can no longer be restored. 409 Conflict is responded if the recipe is not deleted.",
+			Role:    ct.AdminRole,
+			Params:  []ParamDoc{idParamDoc},
+			Status:  http.StatusNoContent,
+		},
+		{
+			Method:     http.MethodGet,
+			Pattern:    "/cocktails/stream",
+			Summary:    "Stream the recipes",
+			Desc:       "The recipes streamed as they are read from the database.",
+			Role:       ct.ReaderRole,
+			Response:   []entity.Cocktail{},
+			MediaTypes: formatMediaTypes([]format{jsonFormat, ndjsonFormat, csvFormat}),
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/cocktails/stats",
+			Summary: "Recipes statistics",
+			Role:    ct.ReaderRole,
+			Params: []ParamDoc{
+				{Name: "q", In: "query", Desc: "A filter query narrowing the aggregated recipes."},
+				{Name: "top", In: "query", Type: "integer", Desc: "The number of most common ingredients to retrieve."},
+			},
+			Response: ct.CocktailStats{},
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/cocktails/{type}/{items}/{items-worker}",
+			Summary: "Read the odd or even recipes concurrently",
+			Role:    ct.ReaderRole,
+			Params: append([]ParamDoc{
+				{Name: "type", In: "path", Required: true, Desc: "The type of the record IDs.", Enum: []string{"odd", "even"}},
+				{Name: "items", In: "path", Required: true, Type: "integer", Desc: "The number of records to read."},
+				{Name: "items-worker", In: "path", Required: true, Type: "integer", Desc: "The number of records read by each worker."},
+			}, listParamDocs...),
+			Response:   []entity.Cocktail{},
+			MediaTypes: listTypes,
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/changes",
+			Summary: "Database changes feed",
+			Role:    ct.ReaderRole,
+			Params: []ParamDoc{
+				{Name: "since", In: "query", Desc: "The cursor of the last change read."},
+				{Name: limitParam, In: "query", Type: "integer", Desc: "The maximum number of changes to retrieve."},
+			},
+			Response: ct.ChangeFeed{},
+		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/cocktail/updatedb",
+			Summary: "Update the database",
+			Desc:    "Updates the database records from the public data API. The summary holds the ID of the sync transaction, to roll it back.",
+			Role:    ct.AdminRole,
+			Params: []ParamDoc{
+				{Name: restoreDeletedParam, In: "query", Type: "boolean", Desc: "Whether the fetched recipes restore the deleted ones, left deleted otherwise."},
+			},
+			Response: ct.DBOpsSummary{},
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/cocktail/rollback/{tx_id}",
+			Summary: "Roll back a sync",
+			Desc:    "Restores the database to its content before the sync transaction, undoing every write made since. The rollback is a sync transaction itself.",
+			Role:    ct.AdminRole,
+			Params: []ParamDoc{
+				{Name: "tx_id", In: "path", Required: true, Desc: "The ID of the sync transaction, from its summary. e.g. 20231001T123015Z-1a2b3c4d"},
+			},
+			Response: ct.DBOpsSummary{},
+		},
+		{
+			Method:   http.MethodPost,
+			Pattern:  "/cocktail/compact",
+			Summary:  "Compact the journal",
+			Desc:     "Folds the journaled writes into the database file, in journal mode. Nothing is folded otherwise.",
+			Role:     ct.AdminRole,
+			Response: ct.CompactSummary{},
+		},
+	}
+}
+
+// filteredResult is the JSON response of the filters: the page of the matching cocktails, or of their selected
+// fields, and, if none matches, the ranked "did you mean" suggestions. The response is the same object either way,
+// so its shape does not depend on the data.
+type filteredResult[T any] struct {
+	Cocktails   []T             `json:"cocktails"`
+	Suggestions []ct.Suggestion `json:"suggestions"`
+}
+
+// getFiltered is a handler function that retrieve a list of filtered cocktails in the database in the negotiated format.
+// Like getAll, it responds 304 Not Modified to the conditional requests of unchanged records.
+// The JSON response is a filteredResult, holding the ranked "did you mean" suggestions if no cocktail matches the
+// filter value; the rest of the formats hold the list of cocktails alone.
 func (c Cocktail) getFiltered(w http.ResponseWriter, r *http.Request) {
 	filter := chi.URLParam(r, "filter")
 	value := chi.URLParam(r, "value")
+	opts, err := newListRequestOpts(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	notModified, err := c.checkNotModified(w, r, opts.format)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	if notModified {
+		return
+	}
 
-	cocktails, err := c.svc.GetFiltered(filter, value)
+	cocktails, suggestions, err := c.svc.GetFilteredWithSuggestions(filter, value)
 	if err != nil {
 		errJSON(w, r, err)
 		return
 	}
-	render.JSON(w, r, cocktails)
+	if opts.format != jsonFormat {
+		renderList(w, r, opts, cocktails)
+		return
+	}
+	if suggestions == nil {
+		suggestions = []ct.Suggestion{}
+	}
+	page := setListHeaders(w, r, opts, cocktails)
+	varyAccept(w)
+	if len(opts.fields) == 0 {
+		render.JSON(w, r, filteredResult[entity.Cocktail]{Cocktails: page, Suggestions: suggestions})
+		return
+	}
+	render.JSON(w, r, filteredResult[map[string]any]{Cocktails: projectCocktails(page, opts.fields), Suggestions: suggestions})
 }
 
-// getAll is a handler function that retrieve all the cocktails in the database in JSON format.
+// getAll is a handler function that retrieve all the cocktails in the database in the negotiated format:
+// JSON, NDJSON, CSV, XML or YAML. See negotiateFormat.
+// Like the rest of the list handlers, it supports pagination, sorting and field selection. See newListOpts.
+// If the "q" query parameter is set, only the cocktails satisfying the filter query are retrieved.
+// If the "as_of" query parameter is set instead, the cocktails are retrieved as they were at that time.
+// The deleted cocktails are left out, unless the "include_deleted" query parameter is set. See parseIncludeDeleted.
+// The parameters and the role are checked first, then the conditional headers, before reading the records: requests
+// whose If-None-Match or If-Modified-Since header matches the database version get a 304 Not Modified response
+// right away. See checkNotModified.
 func (c Cocktail) getAll(w http.ResponseWriter, r *http.Request) {
-	cocktails, err := c.svc.GetAll()
+	opts, err := newListRequestOpts(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	asOf, past, err := parseAsOf(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	withDeleted, err := parseIncludeDeleted(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	q := r.URL.Query().Get("q")
+	switch {
+	case withDeleted && (past || q != ""):
+		errJSON(w, r, &ParamsErr{&ct.FieldErr{Field: includeDeletedParam, Err: ErrIncludeDeletedWith}})
+		return
+	case past && q != "":
+		errJSON(w, r, &ParamsErr{&ct.FieldErr{Field: asOfParam, Err: ErrAsOfWithQuery}})
+		return
+	}
+
+	notModified, err := c.checkNotModified(w, r, opts.format)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	if notModified {
+		return
+	}
+
+	var cocktails []entity.Cocktail
+	switch {
+	case withDeleted:
+		cocktails, err = c.svc.GetAllWithDeleted()
+	case past:
+		cocktails, err = c.svc.GetAllAsOf(asOf)
+	case q != "":
+		cocktails, err = c.svc.Query(q)
+	default:
+		cocktails, err = c.svc.GetAll()
+	}
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	renderList(w, r, opts, cocktails)
+}
+
+// checkNotModified sets the validators of the list response to the given request in the given format, the ETag
+// and Last-Modified headers, and reports whether the request conditions match them. If so, the response is written
+// as 304 Not Modified. Both validators are read before the records, so a write made in between changes them.
+// The Last-Modified header is the time of the last database write, so purges and removals change it too.
+// See checkETag and checkModifiedSince.
+func (c Cocktail) checkNotModified(w http.ResponseWriter, r *http.Request, f format) (bool, error) {
+	etag, err := c.etag(r, f)
+	if err != nil {
+		return false, err
+	}
+	lastModified, err := c.svc.LastModified()
+	if err != nil {
+		return false, err
+	}
+	return checkETag(w, r, etag) || checkModifiedSince(w, r, lastModified), nil
+}
+
+// etag returns the entity tag of the response to the given request in the given format, for the current
+// version of the database. See newETag.
+func (c Cocktail) etag(r *http.Request, f format) (string, error) {
+	checksum, err := c.svc.Checksum()
+	if err != nil {
+		return "", err
+	}
+	return newETag(checksum, r, f), nil
+}
+
+// streamAll is a handler function that streams all the cocktails in the database as they are read.
+// The records are written as a JSON array, as NDJSON or as CSV, as negotiated by the request.
+// The stream stops if the client disconnects. Errors occurring after the first record was written
+// abort the response, leaving it truncated.
+func (c Cocktail) streamAll(w http.ResponseWriter, r *http.Request) {
+	f, err := negotiateFormat(r, jsonFormat, ndjsonFormat, csvFormat)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	streamer := newRecordStreamer(w, f)
+	err = c.svc.StreamAll(r.Context(), streamer.write)
+	if err == nil {
+		err = streamer.close()
+	}
+	if err == nil {
+		return
+	}
+
+	if errors.Is(err, context.Canceled) {
+		logger.Log().Debug().Int("records", streamer.count).Msg("streamAll: client disconnected, stream stopped")
+		return
+	}
+	if !streamer.started {
+		errJSON(w, r, err)
+		return
+	}
+	logger.Log().Error().Err(err).Int("records", streamer.count).Msg("streamAll: stream aborted")
+	panic(http.ErrAbortHandler)
+}
+
+// getStats is a handler function that retrieve the aggregate statistics of the cocktails in JSON format.
+// The "q" query parameter narrows the statistics to the cocktails satisfying the filter query,
+// and "top" sets the number of most common ingredients to retrieve.
+func (c Cocktail) getStats(w http.ResponseWriter, r *http.Request) {
+	top := 0
+	if v := r.URL.Query().Get("top"); v != "" {
+		var err error
+		top, err = strconv.Atoi(v)
+		if err != nil || top <= 0 {
+			errJSON(w, r, &ParamsErr{&ct.FieldErr{Field: "top", Err: fmt.Errorf("%w: %q", ErrInvalidTop, v)}})
+			return
+		}
+	}
+
+	stats, err := c.svc.GetStats(r.URL.Query().Get("q"), top)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	render.JSON(w, r, stats)
+}
+
+// getChanges is a handler function that retrieve the database changes made after the "since" cursor in JSON format.
+// The "limit" query parameter sets the maximum number of changes retrieved.
+func (c Cocktail) getChanges(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r.URL.Query())
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+
+	feed, err := c.svc.GetChanges(r.URL.Query().Get("since"), limit)
 	if err != nil {
 		errJSON(w, r, err)
 		return
 	}
-	render.JSON(w, r, cocktails)
+	render.JSON(w, r, feed)
 }
 
-// getCC is a handler function that retrieve a list of cocktails from the database concurrently in JSON format.
+// getCC is a handler function that retrieve a list of cocktails from the database concurrently in the negotiated format.
 func (c Cocktail) getCC(w http.ResponseWriter, r *http.Request) {
 	nType := chi.URLParam(r, "type")
 	items := chi.URLParam(r, "items")
 	iWorker := chi.URLParam(r, "items-worker")
+	opts, err := newListRequestOpts(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	notModified, err := c.checkNotModified(w, r, opts.format)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	if notModified {
+		return
+	}
 
 	cocktails, err := c.svc.GetCC(nType, items, iWorker)
 	if err != nil {
 		errJSON(w, r, err)
 		return
 	}
-	render.JSON(w, r, cocktails)
+	renderList(w, r, opts, cocktails)
+}
+
+// rollback is a handler function that restores the database to its content before the sync transaction of the
+// "tx_id" path parameter, and responds the database operations summary in JSON format.
+func (c Cocktail) rollback(w http.ResponseWriter, r *http.Request) {
+	summary, err := c.svc.Rollback(r.Context(), chi.URLParam(r, "tx_id"))
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	render.JSON(w, r, summary)
+}
+
+// compact is a handler function that folds the journaled writes into the database, and responds the compaction
+// summary in JSON format.
+func (c Cocktail) compact(w http.ResponseWriter, r *http.Request) {
+	summary, err := c.svc.Compact(r.Context())
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	render.JSON(w, r, summary)
 }
 
 // updateDB is a handler function that updates the database records from a public API.
+// The deleted records are restored only if the "restore_deleted" query parameter is set.
 func (c Cocktail) updateDB(w http.ResponseWriter, r *http.Request) {
-	summary, err := c.svc.UpdateDB()
+	restoreDeleted, err := parseBoolParam(r, restoreDeletedParam)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	summary, err := c.svc.UpdateDB(r.Context(), restoreDeleted)
 	if err != nil {
 		errJSON(w, r, err)
 		return
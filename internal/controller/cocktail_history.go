@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+
+	"github.com/go-chi/render"
+)
+
+// asOfParam is the query parameter of the point in time the records are viewed at.
+const asOfParam = "as_of"
+
+// asOfParamDoc documents the asOfParam query parameter. See parseAsOf.
+var asOfParamDoc = ParamDoc{Name: asOfParam, In: "query",
+	Desc: "The past time to view the recipes at, in RFC 3339 format or as \"2006-01-02 15:04:05\" UTC. e.g. 2023-10-01T12:00:00Z"}
+
+// getHistory is a handler function that retrieve the revisions of the cocktail of the "id" path parameter in JSON
+// format, from its creation on. Every revision holds its author, the changed fields and the resulting record.
+func (c Cocktail) getHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+
+	revs, err := c.svc.History(id)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	render.JSON(w, r, revs)
+}
+
+// parseAsOf returns the time of the "as_of" query parameter, and whether it is set.
+// Times with no zone are in UTC. Returns a ParamsErr if the time is invalid.
+func parseAsOf(r *http.Request) (time.Time, bool, error) {
+	v := r.URL.Query().Get(asOfParam)
+	if v == "" {
+		return time.Time{}, false, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateTime} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true, nil
+		}
+	}
+	return time.Time{}, false, &ParamsErr{&ct.FieldErr{Field: asOfParam, Err: fmt.Errorf("%w: %q", ErrInvalidAsOf, v)}}
+}
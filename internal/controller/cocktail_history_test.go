@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller/mocks"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAsOf(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		exp   time.Time
+		set   bool
+		err   error
+	}{
+		{name: "Missing", value: ""},
+		{name: "RFC 3339", value: "2023-10-01T12:00:00-06:00", exp: time.Date(2023, 10, 1, 18, 0, 0, 0, time.UTC), set: true},
+		{name: "Date time", value: "2023-10-01 12:00:00", exp: time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC), set: true},
+		{name: "Invalid", value: "yesterday", err: ErrInvalidAsOf},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/cocktails", nil)
+			req.URL.RawQuery = "as_of=" + tt.value
+
+			out, set, err := parseAsOf(req)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.set, set)
+			assert.True(t, tt.exp.Equal(out), "expected %s, got %s", tt.exp, out)
+		})
+	}
+}
+
+func TestCocktail_GetHistory(t *testing.T) {
+	revs := []entity.Revision{
+		{Seq: 1, ID: 2, Version: 1, Type: entity.ChangeCreated, Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			Author: entity.Author{Source: entity.SourceImport}, Cocktail: &testRecord},
+		{Seq: 3, ID: 2, Version: 2, Type: entity.ChangeUpdated, Time: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+			Author: entity.Author{Actor: "test", Source: entity.SourceEdit},
+			Diff:   []entity.FieldDiff{{Field: "glass", Old: json.RawMessage(`""`), New: json.RawMessage(`"Shot glass"`)}}},
+	}
+	tests := []struct {
+		name    string
+		path    string
+		svcErr  error
+		code    int
+		errCode string
+	}{
+		{name: "History", path: "/cocktail/id/2/history", code: http.StatusOK},
+		{name: "Not found", path: "/cocktail/id/2/history", svcErr: &service.NotFoundErr{Err: service.ErrCocktailNotFound}, code: http.StatusNotFound, errCode: service.NotFoundErrCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("History", 2).Return(revs, tt.svcErr)
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			newTestRouter(Cocktail{svc: mSvc}).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.errCode != "" {
+				var errResp errHTTP
+				require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &errResp))
+				assert.Equal(t, tt.errCode, errResp.Code)
+				return
+			}
+			var out []entity.Revision
+			require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &out))
+			assert.Equal(t, revs, out)
+		})
+	}
+}
+
+func TestCocktail_AsOf(t *testing.T) {
+	asOf := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		path    string
+		code    int
+		errCode string
+		method  string
+	}{
+		{name: "Catalog", path: "/cocktails?as_of=2023-10-01T12:00:00Z", code: http.StatusOK, method: "GetAllAsOf"},
+		{name: "Record", path: "/cocktails/2?as_of=2023-10-01T12:00:00Z", code: http.StatusOK, method: "GetAsOf"},
+		{name: "Invalid time", path: "/cocktails?as_of=yesterday", code: http.StatusBadRequest, errCode: ParamsErrCode},
+		{name: "With a query", path: "/cocktails?as_of=2023-10-01T12:00:00Z&q=name:foo", code: http.StatusBadRequest, errCode: ParamsErrCode},
+		{name: "Filter of the id", path: "/cocktail/id/2", code: http.StatusOK, method: "GetFilteredWithSuggestions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Checksum").Return(testChecksum, nil)
+			mSvc.On("LastModified").Return(testLastModified, nil)
+			mSvc.On("GetAllAsOf", asOf).Return([]entity.Cocktail{testRecord}, nil)
+			mSvc.On("GetAsOf", 2, asOf).Return(testRecord, nil)
+			mSvc.On("GetFilteredWithSuggestions", "id", "2").Return([]entity.Cocktail{testRecord}, nil, nil)
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			newTestRouter(Cocktail{svc: mSvc}).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.errCode != "" {
+				var errResp errHTTP
+				require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &errResp))
+				assert.Equal(t, tt.errCode, errResp.Code)
+				mSvc.AssertNotCalled(t, "GetAllAsOf", mock.Anything)
+				return
+			}
+			mSvc.AssertNumberOfCalls(t, tt.method, 1)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// idParamDoc is the path parameter of the single record routes.
+var idParamDoc = ParamDoc{Name: "id", In: "path", Required: true, Type: "integer", Desc: "The ID of the recipe."}
+
+// getOne is a handler function that retrieve the cocktail of the "id" path parameter in the negotiated format:
+// JSON, CSV, XML or YAML. See negotiateFormat and encodeCocktail.
+// The response carries the record version and the hash of the body as ETag, whose version the edits of the record
+// require in If-Match, and its update time as Last-Modified. Both are honored by the conditional requests.
+// See recordETag.
+// If the "as_of" query parameter is set, the cocktail is retrieved as it was at that time.
+// A deleted cocktail is not found, unless the "include_deleted" query parameter is set. See parseIncludeDeleted.
+func (c Cocktail) getOne(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+
+	asOf, past, err := parseAsOf(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+
+	withDeleted, err := parseIncludeDeleted(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+
+	f, err := negotiateFormat(r, recordFormats...)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+
+	var cocktail entity.Cocktail
+	switch {
+	case withDeleted && past:
+		err = &ParamsErr{&ct.FieldErr{Field: includeDeletedParam, Err: ErrIncludeDeletedWith}}
+	case withDeleted:
+		cocktail, err = c.svc.GetWithDeleted(id)
+	case past:
+		cocktail, err = c.svc.GetAsOf(id, asOf)
+	default:
+		cocktail, err = c.svc.Get(id)
+	}
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	body, err := encodeCocktail(f, cocktail)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	// Both validators are set before either check may write the 304 Not Modified response.
+	etag := recordETag(cocktail, body)
+	w.Header().Set(etagHeader, etag)
+	if checkModifiedSince(w, r, cocktail.UpdatedAt) || checkETag(w, r, etag) {
+		return
+	}
+	writeFormatted(w, f, body)
+}
+
+// update is a handler function that replaces the cocktail of the "id" path parameter with the JSON request body.
+// The If-Match header must hold the ETag of the record version being replaced. See parseIfMatch.
+// The response is the updated record in JSON format, along with its new ETag.
+func (c Cocktail) update(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	version, err := parseIfMatch(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	var values entity.Cocktail
+	if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+		errJSON(w, r, &ParamsErr{fmt.Errorf("%w: %s", ErrInvalidBody, err)})
+		return
+	}
+
+	cocktail, err := c.svc.Update(r.Context(), id, version, values)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	renderCocktail(w, r, jsonFormat, cocktail)
+}
+
+// delete is a handler function that deletes the cocktail of the "id" path parameter.
+// The If-Match header must hold the ETag of the record version being deleted. See parseIfMatch.
+func (c Cocktail) delete(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	version, err := parseIfMatch(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+
+	if err := c.svc.Delete(r.Context(), id, version); err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseID returns the record ID of the "id" path parameter.
+// Returns a ParamsErr if it is not a valid number.
+func parseID(r *http.Request) (int, error) {
+	v := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, &ParamsErr{&ct.FieldErr{Field: "id", Err: fmt.Errorf("%w: %s", ErrInvalidID, v)}}
+	}
+	return id, nil
+}
@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller/mocks"
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testRecord = entity.Cocktail{
+	ID: 2, Name: "Bar", Ingredients: []entity.Ingredient{{Name: "water"}}, Instructions: "Stir",
+	UpdatedAt: time.Date(2023, 10, 1, 12, 30, 15, 0, time.UTC), Version: 3,
+}
+
+// testRecordETag is the ETag of testRecord in JSON format.
+var testRecordETag = func() string {
+	body, err := encodeCocktail(jsonFormat, testRecord)
+	if err != nil {
+		panic(err)
+	}
+	return recordETag(testRecord, body)
+}()
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		version int
+		err     error
+	}{
+		{name: "Missing", header: "", err: ErrMissingIfMatch},
+		{name: "Record ETag", header: `"v3-kX3v2c8aQn7tZb1s"`, version: 3},
+		{name: "Version ETag", header: `"v3"`, version: 3},
+		{name: "Unquoted version", header: "3", version: 3},
+		{name: "Any", header: "*", version: service.AnyVersion},
+		{name: "Weak ETag", header: `W/"v3"`, err: ErrInvalidIfMatch},
+		{name: "List ETag", header: `"v3", "v4"`, err: ErrInvalidIfMatch},
+		{name: "Negative", header: `"v-1"`, err: ErrInvalidIfMatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("PUT", "/cocktails/2", nil)
+			require.Nil(t, err)
+			req.Header.Set(ifMatchHeader, tt.header)
+
+			version, err := parseIfMatch(req)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+}
+
+func TestCocktail_GetOne(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		headers     map[string]string
+		svcErr      error
+		code        int
+		contentType string
+		body        string
+		errCode     string
+	}{
+		{name: "Found", path: "/cocktails/2", code: http.StatusOK},
+		{name: "Not found", path: "/cocktails/2", svcErr: &service.NotFoundErr{Err: service.ErrCocktailNotFound}, code: http.StatusNotFound, errCode: service.NotFoundErrCode},
+		{name: "ID overflow", path: "/cocktails/99999999999999999999", code: http.StatusBadRequest, errCode: ParamsErrCode},
+		{name: "Not modified", path: "/cocktails/2", headers: map[string]string{ifNoneMatchHeader: testRecordETag}, code: http.StatusNotModified},
+		{name: "Version modified", path: "/cocktails/2", headers: map[string]string{ifNoneMatchHeader: `"v2"`}, code: http.StatusOK},
+		{name: "Format modified", path: "/cocktails/2?format=yaml", headers: map[string]string{ifNoneMatchHeader: testRecordETag}, code: http.StatusOK, contentType: "application/yaml; charset=utf-8", body: "id: 2\n"},
+		{name: "Not modified since", path: "/cocktails/2", headers: map[string]string{ifModifiedSinceHeader: "Sun, 01 Oct 2023 12:30:15 GMT"}, code: http.StatusNotModified},
+		{name: "CSV", path: "/cocktails/2", headers: map[string]string{"Accept": "text/csv"}, code: http.StatusOK, contentType: "text/csv; charset=utf-8", body: "id,name,"},
+		{name: "XML parameter", path: "/cocktails/2?format=xml", code: http.StatusOK, contentType: "application/xml; charset=utf-8", body: "<cocktail>\n  <id>2</id>\n  <name>Bar</name>"},
+		{name: "YAML", path: "/cocktails/2", headers: map[string]string{"Accept": "application/yaml"}, code: http.StatusOK, contentType: "application/yaml; charset=utf-8", body: "id: 2\nname: Bar\n"},
+		{name: "Not acceptable", path: "/cocktails/2", headers: map[string]string{"Accept": "image/png"}, code: http.StatusNotAcceptable, errCode: NotAcceptableErrCode},
+		{name: "NDJSON parameter", path: "/cocktails/2?format=ndjson", code: http.StatusNotAcceptable, errCode: NotAcceptableErrCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Get", 2).Return(testRecord, tt.svcErr)
+			req, err := http.NewRequest("GET", tt.path, nil)
+			require.Nil(t, err)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			newTestRouter(Cocktail{svc: mSvc}).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.errCode != "" {
+				var errResp errHTTP
+				require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &errResp))
+				assert.Equal(t, tt.errCode, errResp.Code)
+				return
+			}
+			assert.Equal(t, "Sun, 01 Oct 2023 12:30:15 GMT", rr.Header().Get(lastModifiedHeader))
+			if tt.contentType != "" {
+				assert.Equal(t, recordETag(testRecord, rr.Body.Bytes()), rr.Header().Get(etagHeader))
+				assert.NotEqual(t, testRecordETag, rr.Header().Get(etagHeader))
+				assert.Equal(t, tt.contentType, rr.Header().Get("Content-Type"))
+				assert.Contains(t, rr.Body.String(), tt.body)
+				return
+			}
+			assert.Equal(t, testRecordETag, rr.Header().Get(etagHeader))
+			if tt.code == http.StatusOK {
+				var out entity.Cocktail
+				require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &out))
+				assert.Equal(t, testRecord, out)
+			}
+		})
+	}
+}
+
+func TestCocktail_Update(t *testing.T) {
+	values := entity.Cocktail{Name: "Bar", Ingredients: []entity.Ingredient{{Name: "water"}}, Instructions: "Stir"}
+	body, err := json.Marshal(values)
+	require.Nil(t, err)
+	updated := testRecord
+	updated.Version = 4
+
+	tests := []struct {
+		name      string
+		ifMatch   string
+		body      string
+		principal ct.Principal
+		version   int
+		svcErr    error
+		code      int
+		errCode   string
+	}{
+		{name: "Updated", ifMatch: `"v3"`, body: string(body), version: 3, code: http.StatusOK},
+		{name: "Updated any version", ifMatch: "*", body: string(body), version: service.AnyVersion, code: http.StatusOK},
+		{name: "Missing If-Match", body: string(body), code: http.StatusPreconditionRequired, errCode: PreconditionErrCode},
+		{name: "Invalid If-Match", ifMatch: `W/"v3"`, body: string(body), code: http.StatusBadRequest, errCode: ParamsErrCode},
+		{name: "Invalid body", ifMatch: `"v3"`, body: "{", code: http.StatusBadRequest, errCode: ParamsErrCode},
+		{
+			name: "Version mismatch", ifMatch: `"v2"`, body: string(body), version: 2,
+			svcErr: &service.VersionErr{Current: 3, Err: service.ErrVersionMismatch},
+			code:   http.StatusPreconditionFailed, errCode: service.VersionErrCode,
+		},
+		{name: "Reader role", ifMatch: `"v3"`, body: string(body), principal: ct.Principal{Name: "test", Role: ct.ReaderRole}, code: http.StatusForbidden, errCode: ForbiddenErrCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Update", mock.Anything, 2, tt.version, values).Return(updated, tt.svcErr)
+			req, err := http.NewRequest("PUT", "/cocktails/2", strings.NewReader(tt.body))
+			require.Nil(t, err)
+			if tt.ifMatch != "" {
+				req.Header.Set(ifMatchHeader, tt.ifMatch)
+			}
+			principal := testAdmin
+			if tt.principal.Role != "" {
+				principal = tt.principal
+			}
+			rr := httptest.NewRecorder()
+			newTestRouterAs(Cocktail{svc: mSvc}, &principal).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.errCode != "" {
+				var errResp errHTTP
+				require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &errResp))
+				assert.Equal(t, tt.errCode, errResp.Code)
+				if tt.svcErr == nil {
+					mSvc.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				}
+				return
+			}
+			assert.Equal(t, recordETag(updated, rr.Body.Bytes()), rr.Header().Get(etagHeader))
+			assert.True(t, strings.HasPrefix(rr.Header().Get(etagHeader), `"v4-`))
+			var out entity.Cocktail
+			require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &out))
+			assert.Equal(t, updated, out)
+		})
+	}
+}
+
+func TestCocktail_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		version int
+		svcErr  error
+		code    int
+		errCode string
+	}{
+		{name: "Deleted", ifMatch: `"v3"`, version: 3, code: http.StatusNoContent},
+		{name: "Missing If-Match", code: http.StatusPreconditionRequired, errCode: PreconditionErrCode},
+		{
+			name: "Version mismatch", ifMatch: `"v2"`, version: 2,
+			svcErr: &service.VersionErr{Current: 3, Err: service.ErrVersionMismatch},
+			code:   http.StatusPreconditionFailed, errCode: service.VersionErrCode,
+		},
+		{
+			name: "Not found", ifMatch: `"v3"`, version: 3,
+			svcErr: &service.NotFoundErr{Err: fmt.Errorf("%w: %d", service.ErrCocktailNotFound, 2)},
+			code:   http.StatusNotFound, errCode: service.NotFoundErrCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Delete", mock.Anything, 2, tt.version).Return(tt.svcErr)
+			req, err := http.NewRequest("DELETE", "/cocktails/2", nil)
+			require.Nil(t, err)
+			if tt.ifMatch != "" {
+				req.Header.Set(ifMatchHeader, tt.ifMatch)
+			}
+			rr := httptest.NewRecorder()
+			newTestRouter(Cocktail{svc: mSvc}).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.errCode != "" {
+				var errResp errHTTP
+				require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &errResp))
+				assert.Equal(t, tt.errCode, errResp.Code)
+				return
+			}
+			assert.Empty(t, rr.Body.String())
+		})
+	}
+}
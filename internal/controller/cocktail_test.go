@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
-	"reflect"
+	"net/url"
 	"testing"
 
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller/mocks"
@@ -15,6 +15,7 @@ import (
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/service"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,8 +34,9 @@ func TestCocktail_GetFiltered(t *testing.T) {
 		value  string
 	}
 	type svc struct {
-		resp []entity.Cocktail
-		err  error
+		resp        []entity.Cocktail
+		suggestions []ct.Suggestion
+		err         error
 	}
 	tests := []struct {
 		name    string
@@ -48,11 +50,7 @@ func TestCocktail_GetFiltered(t *testing.T) {
 			name:   "Repository CSV error",
 			params: params{filter: "id", value: "50"},
 			code:   http.StatusInternalServerError,
-			err: errHTTP{
-				Code:      http.StatusInternalServerError,
-				ErrorType: repoCsvErrType,
-				Message:   "csv: %!s(<nil>)",
-			},
+			err:    newTestProblem(repository.CsvErrCode, "Database file error"),
 			svc: svc{
 				resp: nil,
 				err:  &repository.CsvErr{},
@@ -63,11 +61,7 @@ func TestCocktail_GetFiltered(t *testing.T) {
 			name:   "Service error",
 			params: params{filter: "id", value: "50"},
 			code:   http.StatusBadRequest,
-			err: errHTTP{
-				Code:      http.StatusBadRequest,
-				ErrorType: errType(reflect.TypeOf(testSvcErr).String()),
-				Message:   testSvcErr.Error(),
-			},
+			err:    newTestProblem(badRequestErrCode, "test service error"),
 			svc: svc{
 				resp: nil,
 				err:  testSvcErr,
@@ -86,11 +80,7 @@ func TestCocktail_GetFiltered(t *testing.T) {
 			name:   "Arbitrary",
 			params: params{filter: "foo", value: "some-value"},
 			code:   http.StatusUnprocessableEntity,
-			err: errHTTP{
-				Code:      http.StatusUnprocessableEntity,
-				ErrorType: svcFilterErrType,
-				Message:   "service filter: invalid filter",
-			},
+			err:    newTestProblem(service.FilterErrCode, "service filter: invalid filter"),
 			svc: svc{
 				resp: nil,
 				err:  &service.FilterErr{Err: service.ErrFltrInvalid},
@@ -117,6 +107,17 @@ func TestCocktail_GetFiltered(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:   "Suggestions",
+			params: params{filter: "name", value: "margerita"},
+			code:   http.StatusOK,
+			svc: svc{
+				resp:        []entity.Cocktail{},
+				suggestions: []ct.Suggestion{{Value: "Margarita", Score: 0.89, Matches: 1}, {Value: "Blue Margarita", Score: 0.75, Matches: 2}},
+				err:         nil,
+			},
+			wantErr: false,
+		},
 		{
 			name:   "Valid",
 			params: params{filter: "id", value: "2"},
@@ -134,8 +135,10 @@ func TestCocktail_GetFiltered(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mSvc := mocks.NewCocktailSvc()
-			mSvc.On("GetFiltered", tt.params.filter, tt.params.value).
-				Return(tt.svc.resp, tt.svc.err)
+			mSvc.On("Checksum").Return(testChecksum, nil)
+			mSvc.On("LastModified").Return(testLastModified, nil)
+			mSvc.On("GetFilteredWithSuggestions", tt.params.filter, tt.params.value).
+				Return(tt.svc.resp, tt.svc.suggestions, tt.svc.err)
 			ctrl := Cocktail{svc: mSvc}
 
 			// Request
@@ -151,18 +154,21 @@ func TestCocktail_GetFiltered(t *testing.T) {
 			// Tests
 			assert.Equal(t, tt.code, rr.Code)
 			if tt.wantErr {
-				if tt.err != (errHTTP{}) {
+				if tt.err.Status != 0 {
 					var errMsg errHTTP
 					require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errMsg))
 					assert.Equal(t, tt.err, errMsg)
 				}
 				return
 			}
-
-			var resp []entity.Cocktail
+			suggestions := tt.svc.suggestions
+			if suggestions == nil {
+				suggestions = []ct.Suggestion{}
+			}
+			var resp filteredResult[entity.Cocktail]
 			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
-			assert.Len(t, tt.svc.resp, len(resp))
-			assert.Equal(t, tt.svc.resp, resp)
+			assert.Equal(t, tt.svc.resp, resp.Cocktails)
+			assert.Equal(t, suggestions, resp.Suggestions)
 		})
 	}
 }
@@ -183,11 +189,7 @@ func TestCocktail_GetAll(t *testing.T) {
 		{
 			name: "Repository CSV error",
 			code: http.StatusInternalServerError,
-			err: errHTTP{
-				Code:      http.StatusInternalServerError,
-				ErrorType: repoCsvErrType,
-				Message:   "csv: %!s(<nil>)",
-			},
+			err:  newTestProblem(repository.CsvErrCode, "Database file error"),
 			svc: svc{
 				resp: nil,
 				err:  &repository.CsvErr{},
@@ -197,11 +199,7 @@ func TestCocktail_GetAll(t *testing.T) {
 		{
 			name: "Service error",
 			code: http.StatusBadRequest,
-			err: errHTTP{
-				Code:      http.StatusBadRequest,
-				ErrorType: errType(reflect.TypeOf(testSvcErr).String()),
-				Message:   testSvcErr.Error(),
-			},
+			err:  newTestProblem(badRequestErrCode, "test service error"),
 			svc: svc{
 				resp: nil,
 				err:  testSvcErr,
@@ -235,6 +233,8 @@ func TestCocktail_GetAll(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Checksum").Return(testChecksum, nil)
+			mSvc.On("LastModified").Return(testLastModified, nil)
 			mSvc.On("GetAll").Return(tt.svc.resp, tt.svc.err)
 			ctrl := Cocktail{svc: mSvc}
 
@@ -264,6 +264,240 @@ func TestCocktail_GetAll(t *testing.T) {
 	}
 }
 
+func TestCocktail_Query(t *testing.T) {
+	type svc struct {
+		resp []entity.Cocktail
+		err  error
+	}
+	tests := []struct {
+		name    string
+		query   string
+		code    int
+		err     errHTTP
+		svc     svc
+		wantErr bool
+	}{
+		{
+			name:  "Syntax error",
+			query: "name:foo AND",
+			code:  http.StatusUnprocessableEntity,
+			err:   newTestProblem(service.QueryErrCode, "service filter: query: unexpected end of query at position 13"),
+			svc: svc{
+				resp: nil,
+				err:  &service.FilterErr{Err: &service.QueryErr{Pos: 13, Err: service.ErrQueryUnexpectedEnd}},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "Valid",
+			query: "glass:shot OR name:baz",
+			code:  http.StatusOK,
+			svc: svc{
+				resp: []entity.Cocktail{
+					{ID: 2, Name: "Bar", Alcoholic: "Non alcoholic", Category: "Some Category", Glass: "Shot glass", Ingredients: []entity.Ingredient{{Name: "water", Measure: "50ml"}}},
+				},
+				err: nil,
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Checksum").Return(testChecksum, nil)
+			mSvc.On("LastModified").Return(testLastModified, nil)
+			mSvc.On("Query", tt.query).Return(tt.svc.resp, tt.svc.err)
+			ctrl := Cocktail{svc: mSvc}
+
+			// Request
+			req, err := http.NewRequest("GET", "/cocktails?q="+url.QueryEscape(tt.query), nil)
+			require.Nil(t, err)
+
+			// Server instance
+			rr := httptest.NewRecorder()
+			srv := newTestRouter(ctrl)
+			srv.ServeHTTP(rr, req)
+
+			// Tests
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.wantErr {
+				var errMsg errHTTP
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errMsg))
+				assert.Equal(t, tt.err, errMsg)
+				return
+			}
+
+			var resp []entity.Cocktail
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.svc.resp, resp)
+		})
+	}
+}
+
+func TestCocktail_StreamAll(t *testing.T) {
+	type svc struct {
+		resp []entity.Cocktail
+		err  error
+	}
+	tests := []struct {
+		name        string
+		accept      string
+		code        int
+		contentType string
+		err         errHTTP
+		svc         svc
+		wantErr     bool
+	}{
+		{
+			name:    "Repository CSV error",
+			code:    http.StatusInternalServerError,
+			err:     newTestProblem(repository.CsvErrCode, "Database file error"),
+			svc:     svc{resp: []entity.Cocktail{}, err: &repository.CsvErr{}},
+			wantErr: true,
+		},
+		{
+			name:        "Not records",
+			code:        http.StatusOK,
+			contentType: "application/json",
+			svc:         svc{resp: []entity.Cocktail{}, err: nil},
+		},
+		{
+			name:        "JSON array",
+			code:        http.StatusOK,
+			contentType: "application/json",
+			svc:         svc{resp: []entity.Cocktail{{ID: 1, Name: "Foo"}, {ID: 2, Name: "Bar"}}, err: nil},
+		},
+		{
+			name:        "NDJSON",
+			accept:      ndjsonContentType,
+			code:        http.StatusOK,
+			contentType: ndjsonContentType,
+			svc:         svc{resp: []entity.Cocktail{{ID: 1, Name: "Foo"}, {ID: 2, Name: "Bar"}}, err: nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("StreamAll", mock.Anything).Return(tt.svc.resp, tt.svc.err)
+			ctrl := Cocktail{svc: mSvc}
+
+			// Request
+			req, err := http.NewRequest("GET", "/cocktails/stream", nil)
+			require.Nil(t, err)
+			req.Header.Set("Accept", tt.accept)
+
+			// Server instance
+			rr := httptest.NewRecorder()
+			srv := newTestRouter(ctrl)
+			srv.ServeHTTP(rr, req)
+
+			// Tests
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.wantErr {
+				var errMsg errHTTP
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errMsg))
+				assert.Equal(t, tt.err, errMsg)
+				return
+			}
+			assert.Equal(t, tt.contentType, rr.Header().Get("Content-Type"))
+			assert.True(t, rr.Flushed)
+
+			if tt.contentType == ndjsonContentType {
+				dec := json.NewDecoder(rr.Body)
+				for _, exp := range tt.svc.resp {
+					var rec entity.Cocktail
+					require.NoError(t, dec.Decode(&rec))
+					assert.Equal(t, exp, rec)
+				}
+				assert.False(t, dec.More())
+				return
+			}
+			var resp []entity.Cocktail
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.svc.resp, resp)
+		})
+	}
+}
+
+func TestCocktail_GetStats(t *testing.T) {
+	type svc struct {
+		resp ct.CocktailStats
+		err  error
+	}
+	tests := []struct {
+		name    string
+		query   string
+		top     int
+		code    int
+		err     errHTTP
+		svc     svc
+		wantErr bool
+	}{
+		{
+			name:  "Invalid top",
+			query: "top=-2",
+			code:  http.StatusBadRequest,
+			err: newTestProblem(ParamsErrCode, `request parameters: invalid top, must be a positive number: "-2"`,
+				fieldHTTP{Field: "top", Detail: `invalid top, must be a positive number: "-2"`}),
+			wantErr: true,
+		},
+		{
+			name:    "Service filter error",
+			query:   "q=foo:bar",
+			code:    http.StatusUnprocessableEntity,
+			err:     newTestProblem(service.QueryErrCode, "service filter: query: invalid filter at position 1"),
+			svc:     svc{err: &service.FilterErr{Err: &service.QueryErr{Pos: 1, Err: service.ErrFltrInvalid}}},
+			wantErr: true,
+		},
+		{
+			name:  "Valid",
+			query: "q=glass:shot&top=5",
+			top:   5,
+			code:  http.StatusOK,
+			svc: svc{
+				resp: ct.CocktailStats{
+					TotalRecs:      2,
+					Glasses:        []ct.FacetCount{{Value: "Shot glass", Count: 2}},
+					TopIngredients: []ct.FacetCount{{Value: "soda", Count: 1}, {Value: "water", Count: 1}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			q, _ := url.ParseQuery(tt.query)
+			mSvc.On("GetStats", q.Get("q"), tt.top).Return(tt.svc.resp, tt.svc.err)
+			ctrl := Cocktail{svc: mSvc}
+
+			// Request
+			req, err := http.NewRequest("GET", "/cocktails/stats?"+tt.query, nil)
+			require.Nil(t, err)
+
+			// Server instance
+			rr := httptest.NewRecorder()
+			srv := newTestRouter(ctrl)
+			srv.ServeHTTP(rr, req)
+
+			// Tests
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.wantErr {
+				var errMsg errHTTP
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errMsg))
+				assert.Equal(t, tt.err, errMsg)
+				return
+			}
+
+			var resp ct.CocktailStats
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.svc.resp, resp)
+		})
+	}
+}
+
 func TestCocktail_GetCC(t *testing.T) {
 	type svc struct {
 		resp []entity.Cocktail
@@ -288,11 +522,7 @@ func TestCocktail_GetCC(t *testing.T) {
 				nType: "even", items: "10", itemsWorker: "4",
 			},
 			code: http.StatusInternalServerError,
-			err: errHTTP{
-				Code:      http.StatusInternalServerError,
-				ErrorType: repoCsvErrType,
-				Message:   "csv: %!s(<nil>)",
-			},
+			err:  newTestProblem(repository.CsvErrCode, "Database file error"),
 			svc: svc{
 				resp: nil,
 				err:  &repository.CsvErr{},
@@ -303,11 +533,7 @@ func TestCocktail_GetCC(t *testing.T) {
 			name: "Service Args error",
 			args: args{nType: "foo", items: "10", itemsWorker: "4"},
 			code: http.StatusUnprocessableEntity,
-			err: errHTTP{
-				Code:      http.StatusUnprocessableEntity,
-				ErrorType: svcArgsErrType,
-				Message:   "service arguments: invalid number type",
-			},
+			err:  newTestProblem(service.ArgsErrCode, "service arguments: invalid number type"),
 			svc: svc{
 				resp: nil,
 				err:  &service.ArgsErr{Err: service.ErrInvalidNumType},
@@ -354,6 +580,8 @@ func TestCocktail_GetCC(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Checksum").Return(testChecksum, nil)
+			mSvc.On("LastModified").Return(testLastModified, nil)
 			mSvc.On("GetCC", tt.args.nType, tt.args.items, tt.args.itemsWorker).
 				Return(tt.svc.resp, tt.svc.err)
 			ctrl := Cocktail{svc: mSvc}
@@ -391,20 +619,18 @@ func TestCocktail_UpdateDB(t *testing.T) {
 		err     error
 	}
 	tests := []struct {
-		name    string
-		code    int
-		err     errHTTP
-		svc     svc
-		wantErr bool
+		name           string
+		query          string
+		restoreDeleted bool
+		code           int
+		err            errHTTP
+		svc            svc
+		wantErr        bool
 	}{
 		{
 			name: "CSV error",
 			code: http.StatusInternalServerError,
-			err: errHTTP{
-				Code:      http.StatusInternalServerError,
-				ErrorType: repoCsvErrType,
-				Message:   "csv: %!s(<nil>)",
-			},
+			err:  newTestProblem(repository.CsvErrCode, "Database file error"),
 			svc: svc{
 				summary: ct.DBOpsSummary{},
 				err:     &repository.CsvErr{},
@@ -414,11 +640,7 @@ func TestCocktail_UpdateDB(t *testing.T) {
 		{
 			name: "Data API error",
 			code: http.StatusBadGateway,
-			err: errHTTP{
-				Code:      http.StatusBadGateway,
-				ErrorType: repoDataApiErrType,
-				Message:   "data api: %!s(<nil>)",
-			},
+			err:  newTestProblem(repository.DataAPIErrCode, "Data API unavailable"),
 			svc: svc{
 				summary: ct.DBOpsSummary{},
 				err:     &repository.DataApiErr{},
@@ -428,11 +650,7 @@ func TestCocktail_UpdateDB(t *testing.T) {
 		{
 			name: "Service error",
 			code: http.StatusBadRequest,
-			err: errHTTP{
-				Code:      http.StatusBadRequest,
-				ErrorType: errType(reflect.TypeOf(testSvcErr).String()),
-				Message:   testSvcErr.Error(),
-			},
+			err:  newTestProblem(badRequestErrCode, "test service error"),
 			svc: svc{
 				summary: ct.DBOpsSummary{},
 				err:     testSvcErr,
@@ -454,16 +672,31 @@ func TestCocktail_UpdateDB(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:           "Restoring the deleted records",
+			query:          "?restore_deleted=true",
+			restoreDeleted: true,
+			code:           http.StatusOK,
+			svc:            svc{summary: ct.DBOpsSummary{Status: "some status", ModifiedRecs: 1, TotalOps: 1}},
+		},
+		{
+			name:  "Invalid restore_deleted",
+			query: "?restore_deleted=maybe",
+			code:  http.StatusBadRequest,
+			err: newTestProblem(ParamsErrCode, `request parameters: invalid boolean, must be true or false: "maybe"`,
+				fieldHTTP{Field: restoreDeletedParam, Detail: `invalid boolean, must be true or false: "maybe"`}),
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mSvc := mocks.NewCocktailSvc()
-			mSvc.On("UpdateDB").Return(tt.svc.summary, tt.svc.err)
+			mSvc.On("UpdateDB", mock.Anything, tt.restoreDeleted).Return(tt.svc.summary, tt.svc.err)
 			ctrl := Cocktail{mSvc}
 
 			// Request
-			req, err := http.NewRequest("GET", "/cocktail/updatedb", nil)
+			req, err := http.NewRequest("GET", "/cocktail/updatedb"+tt.query, nil)
 			require.Nil(t, err)
 
 			// Server instance
@@ -486,3 +719,164 @@ func TestCocktail_UpdateDB(t *testing.T) {
 		})
 	}
 }
+
+func TestCocktail_Rollback(t *testing.T) {
+	const txID = "20231001T123015Z-1a2b3c4d"
+	summary := ct.DBOpsSummary{TxID: "20231002T000000Z-5e6f7a8b", Status: "some status", ModifiedRecs: 2, DeletedRecs: 1, TotalOps: 3}
+	tests := []struct {
+		name      string
+		principal ct.Principal
+		svcErr    error
+		code      int
+		errCode   string
+	}{
+		{name: "Rolled back", principal: testAdmin, code: http.StatusOK},
+		{name: "Unknown transaction", principal: testAdmin, svcErr: &service.NotFoundErr{Err: service.ErrSyncTxNotFound},
+			code: http.StatusNotFound, errCode: service.NotFoundErrCode},
+		{name: "Snapshot error", principal: testAdmin, svcErr: &repository.SnapshotErr{Err: repository.ErrFileNameEmpty},
+			code: http.StatusInternalServerError, errCode: repository.SnapshotErrCode},
+		{name: "Editor role", principal: ct.Principal{Name: "test", Role: ct.EditorRole}, code: http.StatusForbidden, errCode: ForbiddenErrCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Rollback", mock.Anything, txID).Return(summary, tt.svcErr)
+			req, err := http.NewRequest("POST", "/cocktail/rollback/"+txID, nil)
+			require.Nil(t, err)
+			rr := httptest.NewRecorder()
+			newTestRouterAs(Cocktail{svc: mSvc}, &tt.principal).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.errCode != "" {
+				var errResp errHTTP
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errResp))
+				assert.Equal(t, tt.errCode, errResp.Code)
+				return
+			}
+			var resp ct.DBOpsSummary
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, summary, resp)
+		})
+	}
+}
+
+func TestCocktail_Compact(t *testing.T) {
+	summary := ct.CompactSummary{Status: "some status", FoldedEntries: 3}
+	tests := []struct {
+		name      string
+		principal ct.Principal
+		svcErr    error
+		code      int
+		errCode   string
+	}{
+		{name: "Compacted", principal: testAdmin, code: http.StatusOK},
+		{name: "Database error", principal: testAdmin, svcErr: &repository.CsvErr{Err: repository.ErrLockTimeout},
+			code: http.StatusInternalServerError, errCode: repository.CsvErrCode},
+		{name: "Editor role", principal: ct.Principal{Name: "test", Role: ct.EditorRole}, code: http.StatusForbidden, errCode: ForbiddenErrCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Compact", mock.Anything).Return(summary, tt.svcErr)
+			req, err := http.NewRequest("POST", "/cocktail/compact", nil)
+			require.Nil(t, err)
+			rr := httptest.NewRecorder()
+			newTestRouterAs(Cocktail{svc: mSvc}, &tt.principal).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.errCode != "" {
+				var errResp errHTTP
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errResp))
+				assert.Equal(t, tt.errCode, errResp.Code)
+				return
+			}
+			var resp ct.CompactSummary
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, summary, resp)
+		})
+	}
+}
+
+func TestCocktail_GetChanges(t *testing.T) {
+	type svc struct {
+		resp ct.ChangeFeed
+		err  error
+	}
+	tests := []struct {
+		name    string
+		query   string
+		since   string
+		limit   int
+		code    int
+		err     errHTTP
+		svc     svc
+		wantErr bool
+	}{
+		{
+			name:  "Invalid limit",
+			query: "limit=foo",
+			code:  http.StatusBadRequest,
+			err: newTestProblem(ParamsErrCode, `request parameters: invalid limit: "foo", must be between 0 and 1000`,
+				fieldHTTP{Field: "limit", Detail: `invalid limit: "foo", must be between 0 and 1000`}),
+			wantErr: true,
+		},
+		{
+			name:    "Service arguments error",
+			query:   "since=foo",
+			since:   "foo",
+			code:    http.StatusUnprocessableEntity,
+			err:     newTestProblem(service.ArgsErrCode, "service arguments: invalid change cursor"),
+			svc:     svc{err: &service.ArgsErr{Err: service.ErrInvalidChangeCursor}},
+			wantErr: true,
+		},
+		{
+			name:  "Valid",
+			query: "since=3&limit=2",
+			since: "3",
+			limit: 2,
+			code:  http.StatusOK,
+			svc: svc{
+				resp: ct.ChangeFeed{
+					Changes: []entity.Change{
+						{Seq: 4, Type: entity.ChangeCreated, ID: 1, Cocktail: &entity.Cocktail{ID: 1, Name: "Foo"}},
+						{Seq: 5, Type: entity.ChangeDeleted, ID: 2},
+					},
+					NextCursor: "5",
+					HasMore:    true,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("GetChanges", tt.since, tt.limit).Return(tt.svc.resp, tt.svc.err)
+			ctrl := Cocktail{svc: mSvc}
+
+			// Request
+			req, err := http.NewRequest("GET", "/changes?"+tt.query, nil)
+			require.Nil(t, err)
+
+			// Server instance
+			rr := httptest.NewRecorder()
+			srv := newTestRouter(ctrl)
+			srv.ServeHTTP(rr, req)
+
+			// Tests
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.wantErr {
+				var errMsg errHTTP
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errMsg))
+				assert.Equal(t, tt.err, errMsg)
+				return
+			}
+
+			var resp ct.ChangeFeed
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.svc.resp, resp)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+)
+
+const (
+	// includeDeletedParam is the query parameter retrieving the deleted records along with the rest.
+	includeDeletedParam = "include_deleted"
+
+	// restoreDeletedParam is the query parameter letting a database update restore the deleted records.
+	restoreDeletedParam = "restore_deleted"
+)
+
+// includeDeletedParamDoc documents the includeDeletedParam query parameter. See parseIncludeDeleted.
+var includeDeletedParamDoc = ParamDoc{Name: includeDeletedParam, In: "query", Type: "boolean",
+	Desc: "Whether to retrieve the deleted recipes too, marked by their deleted_at date. Requires the admin role."}
+
+// restore is a handler function that restores the deleted cocktail of the "id" path parameter.
+// The response is the restored record in JSON format, along with its new ETag.
+func (c Cocktail) restore(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+
+	cocktail, err := c.svc.Restore(r.Context(), id)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	renderCocktail(w, r, jsonFormat, cocktail)
+}
+
+// purge is a handler function that removes the deleted cocktail of the "id" path parameter for good.
+func (c Cocktail) purge(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+
+	if err := c.svc.Purge(r.Context(), id); err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseIncludeDeleted returns whether the "include_deleted" query parameter is set.
+// Returns a ParamsErr if it is not a boolean, and an AuthErr or a ForbiddenErr if it is set by a principal lacking
+// the admin role. See authorize.
+func parseIncludeDeleted(r *http.Request) (bool, error) {
+	include, err := parseBoolParam(r, includeDeletedParam)
+	if err != nil || !include {
+		return false, err
+	}
+	if err := authorize(r, ct.AdminRole); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// parseBoolParam returns the boolean value of the given query parameter, false if it is missing.
+// Returns a ParamsErr if it is not a boolean.
+func parseBoolParam(r *http.Request, name string) (bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, &ParamsErr{&ct.FieldErr{Field: name, Err: fmt.Errorf("%w: %q", ErrInvalidBool, v)}}
+	}
+	return b, nil
+}
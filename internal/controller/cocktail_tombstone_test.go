@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller/mocks"
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIncludeDeleted(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		role  ct.Role
+		exp   bool
+		err   error
+	}{
+		{name: "Missing", role: ct.ReaderRole},
+		{name: "False", value: "false", role: ct.ReaderRole},
+		{name: "True", value: "true", role: ct.AdminRole, exp: true},
+		{name: "Not an admin", value: "true", role: ct.EditorRole, err: ErrRoleForbidden},
+		{name: "Invalid", value: "maybe", role: ct.AdminRole, err: ErrInvalidBool},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/cocktails?include_deleted="+tt.value, nil)
+			req = req.WithContext(ct.WithPrincipal(req.Context(), ct.Principal{Name: "test", Role: tt.role}))
+
+			out, err := parseIncludeDeleted(req)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp, out)
+		})
+	}
+}
+
+func TestCocktail_IncludeDeleted(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		code    int
+		errCode string
+		method  string
+	}{
+		{name: "Catalog", path: "/cocktails?include_deleted=true", code: http.StatusOK, method: "GetAllWithDeleted"},
+		{name: "Record", path: "/cocktails/2?include_deleted=true", code: http.StatusOK, method: "GetWithDeleted"},
+		{name: "Catalog without", path: "/cocktails?include_deleted=false", code: http.StatusOK, method: "GetAll"},
+		{name: "With a query", path: "/cocktails?include_deleted=true&q=name:foo", code: http.StatusBadRequest, errCode: ParamsErrCode},
+		{name: "With as_of", path: "/cocktails/2?include_deleted=true&as_of=2023-10-01T12:00:00Z", code: http.StatusBadRequest, errCode: ParamsErrCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Checksum").Return(testChecksum, nil)
+			mSvc.On("LastModified").Return(testLastModified, nil)
+			mSvc.On("GetAll").Return([]entity.Cocktail{testRecord}, nil)
+			mSvc.On("GetAllWithDeleted").Return([]entity.Cocktail{testRecord}, nil)
+			mSvc.On("GetWithDeleted", 2).Return(testRecord, nil)
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rr := httptest.NewRecorder()
+			newTestRouter(Cocktail{svc: mSvc}).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.errCode != "" {
+				var errResp errHTTP
+				require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &errResp))
+				assert.Equal(t, tt.errCode, errResp.Code)
+				mSvc.AssertNotCalled(t, "GetAllWithDeleted")
+				return
+			}
+			mSvc.AssertNumberOfCalls(t, tt.method, 1)
+		})
+	}
+}
+
+func TestCocktail_Restore(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    ct.Role
+		svcErr  error
+		code    int
+		errCode string
+	}{
+		{name: "Restored", role: ct.EditorRole, code: http.StatusOK},
+		{name: "Reader", role: ct.ReaderRole, code: http.StatusForbidden, errCode: ForbiddenErrCode},
+		{
+			name: "Not deleted", role: ct.EditorRole,
+			svcErr: &service.StateErr{Err: fmt.Errorf("%w: %d", service.ErrCocktailNotDeleted, 2)},
+			code:   http.StatusConflict, errCode: service.StateErrCode,
+		},
+		{
+			name: "Not found", role: ct.EditorRole,
+			svcErr: &service.NotFoundErr{Err: fmt.Errorf("%w: %d", service.ErrCocktailNotFound, 2)},
+			code:   http.StatusNotFound, errCode: service.NotFoundErrCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Restore", mock.Anything, 2).Return(testRecord, tt.svcErr)
+			req := httptest.NewRequest("POST", "/cocktails/2/restore", nil)
+			rr := httptest.NewRecorder()
+			newTestRouterAs(Cocktail{svc: mSvc}, &ct.Principal{Name: "test", Role: tt.role}).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.errCode != "" {
+				var errResp errHTTP
+				require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &errResp))
+				assert.Equal(t, tt.errCode, errResp.Code)
+				return
+			}
+			assert.Equal(t, recordETag(testRecord, rr.Body.Bytes()), rr.Header().Get(etagHeader))
+			var out entity.Cocktail
+			require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &out))
+			assert.Equal(t, testRecord.ID, out.ID)
+		})
+	}
+}
+
+func TestCocktail_Purge(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    ct.Role
+		svcErr  error
+		code    int
+		errCode string
+	}{
+		{name: "Purged", role: ct.AdminRole, code: http.StatusNoContent},
+		{name: "Editor", role: ct.EditorRole, code: http.StatusForbidden, errCode: ForbiddenErrCode},
+		{
+			name: "Not deleted", role: ct.AdminRole,
+			svcErr: &service.StateErr{Err: fmt.Errorf("%w: %d", service.ErrCocktailNotDeleted, 2)},
+			code:   http.StatusConflict, errCode: service.StateErrCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Purge", mock.Anything, 2).Return(tt.svcErr)
+			req := httptest.NewRequest("POST", "/cocktails/2/purge", nil)
+			rr := httptest.NewRecorder()
+			newTestRouterAs(Cocktail{svc: mSvc}, &ct.Principal{Name: "test", Role: tt.role}).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.errCode != "" {
+				var errResp errHTTP
+				require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &errResp))
+				assert.Equal(t, tt.errCode, errResp.Code)
+				return
+			}
+			assert.Empty(t, rr.Body.String())
+		})
+	}
+}
@@ -2,6 +2,10 @@ package controller
 
 import (
 	"errors"
+	"net/http"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -10,10 +14,49 @@ import (
 // testSvcErr represents a non-custom error of the service
 var testSvcErr = errors.New("test service error")
 
+// testChecksum is the database checksum returned by the service mocks.
+const testChecksum = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// testLastModified is the time of the last database write of the test requests.
+var testLastModified = time.Date(2023, 10, 1, 12, 30, 15, 0, time.UTC)
+
+// testAdmin is the principal of the test requests, granted all the roles.
+var testAdmin = ct.Principal{Name: "test", Role: ct.AdminRole}
+
 // newTestRouter returns a new pre-allocated chi.Mux instance with the registered routes provided by the given controller.
+// The requests are authenticated as the testAdmin principal.
 func newTestRouter(ctrl HTTP) *chi.Mux {
+	return newTestRouterAs(ctrl, &testAdmin)
+}
+
+// newTestRouterAs returns a new pre-allocated chi.Mux instance with the registered routes provided by the given controller.
+// The requests are authenticated as the given principal; nil leaves them unauthenticated.
+func newTestRouterAs(ctrl HTTP, principal *ct.Principal) *chi.Mux {
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
+	if principal != nil {
+		r.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(ct.WithPrincipal(r.Context(), *principal)))
+			})
+		})
+	}
 	ctrl.SetRoutes(r)
 	return r
 }
+
+// newTestProblem returns the expected problem details of the given error code, detail and field errors.
+func newTestProblem(code, detail string, fields ...fieldHTTP) errHTTP {
+	problem, ok := problems.Get(code)
+	if !ok {
+		problem = ct.ProblemType{Title: http.StatusText(http.StatusBadRequest), Status: http.StatusBadRequest}
+	}
+	return errHTTP{
+		Type:   problemTypePrefix + code,
+		Title:  problem.Title,
+		Status: problem.Status,
+		Detail: detail,
+		Code:   code,
+		Errors: fields,
+	}
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service"
+)
+
+// The conditional request headers, and the validators of the responses. Ref: https://www.rfc-editor.org/rfc/rfc9110#section-13
+const (
+	etagHeader            = "ETag"
+	lastModifiedHeader    = "Last-Modified"
+	ifNoneMatchHeader     = "If-None-Match"
+	ifModifiedSinceHeader = "If-Modified-Since"
+	ifMatchHeader         = "If-Match"
+)
+
+// newETag returns the strong entity tag of the response to the given request, made from the database checksum.
+// The response only depends on the database content, the request path and query, and the negotiated format,
+// so the tag is known before the records are read and serialized.
+func newETag(checksum string, r *http.Request, f format) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\n%s\n%s\n%s", checksum, r.URL.Path, r.URL.Query().Encode(), f)
+	return `"` + base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:18]) + `"`
+}
+
+// recordETag returns the strong entity tag of a single record encoded as the given body: the record version, which the
+// edits of the record require in If-Match, followed by the hash of the body, so every format and content of the
+// record gets its own tag. e.g. "v3-kX3v2c8aQn7tZb1s"
+func recordETag(c entity.Cocktail, body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"v` + strconv.Itoa(c.Version) + "-" + base64.RawURLEncoding.EncodeToString(sum[:12]) + `"`
+}
+
+// parseIfMatch returns the record version required by the If-Match header of the request, or service.AnyVersion
+// if the header is "*". The header holds a single strong entity tag, as set by recordETag, whose version alone is
+// compared, so the tag of any format of the record version matches it; the version tag, e.g. "v3", and the
+// unquoted version, e.g. 3, are accepted too.
+// Returns a PreconditionErr if the header is missing, and a ParamsErr if it is not a record entity tag.
+func parseIfMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get(ifMatchHeader))
+	if header == "" {
+		return 0, &PreconditionErr{ErrMissingIfMatch}
+	}
+	if header == "*" {
+		return service.AnyVersion, nil
+	}
+
+	tag := header
+	if len(tag) >= 2 && strings.HasPrefix(tag, `"`) && strings.HasSuffix(tag, `"`) {
+		tag = tag[1 : len(tag)-1]
+	}
+	tag, _, _ = strings.Cut(strings.TrimPrefix(tag, "v"), "-")
+	version, err := strconv.Atoi(tag)
+	if err != nil || version < 0 {
+		return 0, &ParamsErr{&ct.FieldErr{Field: ifMatchHeader, Err: fmt.Errorf("%w: %s", ErrInvalidIfMatch, header)}}
+	}
+	return version, nil
+}
+
+// checkETag sets the ETag header of the response, and reports whether the If-None-Match header of the request
+// matches it. If it does, the response is written as 304 Not Modified.
+func checkETag(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set(etagHeader, etag)
+	varyAccept(w)
+	if !etagMatch(r.Header.Get(ifNoneMatchHeader), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// checkModifiedSince sets the Last-Modified header of the response to the given time, if any, and reports
+// whether the records were not modified after the If-Modified-Since header of the request.
+// If so, the response is written as 304 Not Modified. The header is ignored if the request sets If-None-Match.
+func checkModifiedSince(w http.ResponseWriter, r *http.Request, lastModified time.Time) bool {
+	if lastModified.IsZero() {
+		return false
+	}
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	w.Header().Set(lastModifiedHeader, lastModified.Format(http.TimeFormat))
+	if r.Header.Get(ifNoneMatchHeader) != "" {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get(ifModifiedSinceHeader))
+	if err != nil || lastModified.After(since) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatch reports whether the given If-None-Match header value matches the entity tag, by weak comparison.
+// e.g. `"xyzzy", W/"r2d2xxxx"` or `*`
+func etagMatch(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// varyAccept adds the Accept header to the Vary header of the response, unless it is already there.
+func varyAccept(w http.ResponseWriter) {
+	for _, v := range w.Header().Values("Vary") {
+		if strings.EqualFold(v, "Accept") {
+			return
+		}
+	}
+	w.Header().Add("Vary", "Accept")
+}
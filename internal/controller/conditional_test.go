@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller/mocks"
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEtagMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "Missing", header: "", want: false},
+		{name: "Match", header: `"abc"`, want: true},
+		{name: "Weak match", header: `W/"abc"`, want: true},
+		{name: "List", header: `"foo", "abc"`, want: true},
+		{name: "Any", header: `*`, want: true},
+		{name: "No match", header: `"foo", W/"bar"`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, etagMatch(tt.header, `"abc"`))
+		})
+	}
+}
+
+func TestCocktail_ConditionalGet(t *testing.T) {
+	// The records were updated before the last database write, such as a purge
+	updated := testLastModified.Add(-time.Hour)
+	cocktails := []entity.Cocktail{
+		{ID: 1, Name: "Foo", UpdatedAt: updated.Add(-time.Hour)},
+		{ID: 2, Name: "Bar", UpdatedAt: updated},
+	}
+	request := func(path string) *http.Request {
+		req, err := http.NewRequest("GET", path, nil)
+		require.Nil(t, err)
+		return req
+	}
+	etag := newETag(testChecksum, request("/cocktails?limit=2"), jsonFormat)
+	lastModified := "Sun, 01 Oct 2023 12:30:15 GMT"
+
+	tests := []struct {
+		name            string
+		path            string
+		accept          string
+		headers         map[string]string
+		principal       *ct.Principal
+		checksumErr     error
+		lastModifiedErr error
+		read            bool
+		code            int
+		etag            string
+	}{
+		{
+			name: "Unconditional",
+			path: "/cocktails?limit=2",
+			read: true,
+			code: http.StatusOK,
+			etag: etag,
+		},
+		{
+			name:    "If-None-Match matches",
+			path:    "/cocktails?limit=2",
+			headers: map[string]string{ifNoneMatchHeader: `"foo", ` + etag},
+			code:    http.StatusNotModified,
+			etag:    etag,
+		},
+		{
+			name:    "If-None-Match of another format",
+			path:    "/cocktails?limit=2",
+			accept:  "text/csv",
+			headers: map[string]string{ifNoneMatchHeader: etag},
+			read:    true,
+			code:    http.StatusOK,
+			etag:    newETag(testChecksum, request("/cocktails?limit=2"), csvFormat),
+		},
+		{
+			name:    "If-None-Match of another page",
+			path:    "/cocktails?limit=1",
+			headers: map[string]string{ifNoneMatchHeader: etag},
+			read:    true,
+			code:    http.StatusOK,
+			etag:    newETag(testChecksum, request("/cocktails?limit=1"), jsonFormat),
+		},
+		{
+			name:    "Not modified since",
+			path:    "/cocktails?limit=2",
+			headers: map[string]string{ifModifiedSinceHeader: lastModified},
+			code:    http.StatusNotModified,
+			etag:    etag,
+		},
+		{
+			name:    "Modified since the last update of the records",
+			path:    "/cocktails?limit=2",
+			headers: map[string]string{ifModifiedSinceHeader: updated.Format(http.TimeFormat)},
+			read:    true,
+			code:    http.StatusOK,
+			etag:    etag,
+		},
+		{
+			name:    "If-None-Match takes precedence",
+			path:    "/cocktails?limit=2",
+			headers: map[string]string{ifNoneMatchHeader: `"foo"`, ifModifiedSinceHeader: lastModified},
+			read:    true,
+			code:    http.StatusOK,
+			etag:    etag,
+		},
+		{
+			name:      "Forbidden parameter before the conditions",
+			path:      "/cocktails?limit=2&include_deleted=true",
+			headers:   map[string]string{ifNoneMatchHeader: "*"},
+			principal: &ct.Principal{Name: "dashboard", Role: ct.ReaderRole},
+			code:      http.StatusForbidden,
+		},
+		{
+			name:    "Invalid parameters before the conditions",
+			path:    "/cocktails?limit=2&as_of=2023-10-01T00:00:00Z&q=name:foo",
+			headers: map[string]string{ifNoneMatchHeader: "*"},
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:        "Checksum error",
+			path:        "/cocktails?limit=2",
+			checksumErr: &repository.CsvErr{Err: repository.ErrFileNameEmpty},
+			code:        http.StatusInternalServerError,
+		},
+		{
+			name:            "Last modified error",
+			path:            "/cocktails?limit=2",
+			lastModifiedErr: &repository.CsvErr{Err: repository.ErrFileNameEmpty},
+			code:            http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Checksum").Return(testChecksum, tt.checksumErr)
+			mSvc.On("LastModified").Return(testLastModified, tt.lastModifiedErr)
+			mSvc.On("GetAll").Return(cocktails, nil)
+			ctrl := Cocktail{svc: mSvc}
+
+			req := request(tt.path)
+			req.Header.Set("Accept", tt.accept)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			principal := &testAdmin
+			if tt.principal != nil {
+				principal = tt.principal
+			}
+			rr := httptest.NewRecorder()
+			newTestRouterAs(ctrl, principal).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.etag, rr.Header().Get(etagHeader))
+			if tt.read {
+				mSvc.AssertCalled(t, "GetAll")
+			} else {
+				mSvc.AssertNotCalled(t, "GetAll")
+			}
+			if tt.code == http.StatusNotModified {
+				assert.Empty(t, rr.Body.String())
+			}
+			if tt.code == http.StatusOK {
+				assert.NotEmpty(t, rr.Body.String())
+				assert.Equal(t, lastModified, rr.Header().Get(lastModifiedHeader))
+			}
+		})
+	}
+}
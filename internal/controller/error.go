@@ -1,105 +1,238 @@
 package controller
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
-	"reflect"
 
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/repository"
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/service"
 
-	"github.com/go-chi/render"
+	"github.com/go-chi/chi/v5/middleware"
 )
 
+// The stable codes of the controller errors, responded by the API.
 const (
-	repoCsvErrType     errType = "RepositoryCSVError"
-	repoDataApiErrType errType = "RepositoryDataAPIError"
-	repoWPErrType      errType = "RepositoryWorkerPoolError"
-	svcFilterErrType   errType = "ServiceFilterError"
-	svcArgsErrType     errType = "ServiceArgumentsError"
+	ParamsErrCode        = "controller.parameters"
+	AuthErrCode          = "controller.authentication"
+	ForbiddenErrCode     = "controller.authorization"
+	RateLimitErrCode     = "controller.rate_limit"
+	NotAcceptableErrCode = "controller.not_acceptable"
+	PreconditionErrCode  = "controller.precondition_required"
+
+	// badRequestErrCode is the code of the errors missing in the problems catalog.
+	badRequestErrCode = "bad_request"
+
+	// problemTypePrefix prefixes the error codes to build the problem type URIs.
+	problemTypePrefix = "urn:capstone:problem:"
+	// problemContentType is the media type of the error responses.
+	problemContentType = "application/problem+json"
 )
 
-var _ fmt.Stringer = errType("")
+// problems is the catalog of the errors responded by the API, contributed by every layer.
+var problems = newProblemRegistry()
+
+// newProblemRegistry returns the problems catalog of the repository, service and controller errors.
+func newProblemRegistry() *ct.ProblemRegistry {
+	reg := ct.NewProblemRegistry()
+	repository.RegisterProblems(reg)
+	service.RegisterProblems(reg)
+	reg.Register(
+		ct.ProblemType{Code: ParamsErrCode, Title: "Invalid request parameters", Status: http.StatusBadRequest, Match: ct.MatchAs[*ParamsErr]()},
+		ct.ProblemType{Code: AuthErrCode, Title: "Authentication required", Status: http.StatusUnauthorized, Match: ct.MatchAs[*AuthErr]()},
+		ct.ProblemType{Code: ForbiddenErrCode, Title: "Permission denied", Status: http.StatusForbidden, Match: ct.MatchAs[*ForbiddenErr]()},
+		ct.ProblemType{Code: RateLimitErrCode, Title: "Too many requests", Status: http.StatusTooManyRequests, Match: ct.MatchAs[*RateLimitErr]()},
+		ct.ProblemType{Code: NotAcceptableErrCode, Title: "Format not acceptable", Status: http.StatusNotAcceptable, Match: ct.MatchAs[*NotAcceptableErr]()},
+		ct.ProblemType{Code: PreconditionErrCode, Title: "Precondition required", Status: http.StatusPreconditionRequired, Match: ct.MatchAs[*PreconditionErr]()},
+	)
+	return reg
+}
 
-// errHTTP represents the message in the http error responses.
-type errHTTP struct {
-	Code      int     `json:"code"`
-	ErrorType errType `json:"status"`
-	Message   string  `json:"message"`
+// Problems returns the catalog of the errors responded by the API.
+func Problems() []ct.ProblemType {
+	return problems.Types()
 }
 
-// errType represents the error classification or status.
-type errType string
+var (
+	ErrInvalidLimit     = errors.New("invalid limit")
+	ErrInvalidOffset    = errors.New("invalid offset")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrCursorWithOffset = errors.New("cursor can not be combined with an offset, it holds the offset of the next page")
+	ErrInvalidSortField = errors.New("invalid sort field")
+	ErrInvalidField     = errors.New("invalid field")
+	ErrInvalidTop       = errors.New("invalid top, must be a positive number")
+
+	ErrInvalidLastEventID = errors.New("invalid last event id")
+	ErrInvalidBody        = errors.New("invalid request body")
+
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrRoleForbidden      = errors.New("forbidden")
+
+	ErrNotAcceptable = errors.New("not acceptable")
+
+	ErrMissingIfMatch = errors.New("missing If-Match header, the ETag of the edited record is required")
+	ErrInvalidIfMatch = errors.New("invalid If-Match header, must be a strong record ETag or *")
+	ErrInvalidID      = errors.New("invalid id")
+
+	ErrInvalidAsOf   = errors.New("invalid as_of time, must be in RFC 3339 format")
+	ErrAsOfWithQuery = errors.New("as_of can not be combined with a filter query")
+
+	ErrInvalidBool        = errors.New("invalid boolean, must be true or false")
+	ErrIncludeDeletedWith = errors.New("include_deleted can not be combined with a filter query or as_of")
 
-func (e errType) String() string {
-	return string(e)
+	ErrRateLimited   = errors.New("too many requests")
+	ErrQuotaExceeded = errors.New("daily quota exceeded")
+)
+
+// errHTTP represents the RFC 7807 problem details of the http error responses.
+// Code is the stable error code, and Instance the request id.
+type errHTTP struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail"`
+	Instance string      `json:"instance,omitempty"`
+	Code     string      `json:"code"`
+	Errors   []fieldHTTP `json:"errors,omitempty"`
+}
+
+// fieldHTTP represents the error of a single input field in the http error responses.
+type fieldHTTP struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
 }
 
-// errJSON returns an error JSON response.
+// errJSON returns an error response in the application/problem+json format.
 func errJSON(w http.ResponseWriter, r *http.Request, err error) {
 	errHttp := newErrHTTP(err)
-	render.Status(r, errHttp.Code)
-	render.JSON(w, r, errHttp)
+	errHttp.Instance = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(errHttp.Status)
+	if err := json.NewEncoder(w).Encode(errHttp); err != nil {
+		logger.Log().Error().Err(err).Msg("errJSON: write error response failed")
+	}
 }
 
 // newErrHTTP returns a new errHTTP instance.
-// It sets the Code, ErrorType, and Message values based on the error type.
+// It sets the type, title, status and code from the problems catalog entry matching the error, and the
+// detail from the error message. The errors missing in the catalog are responded as bad requests.
+// The server errors, of the 5xx problem types, are detailed by their title alone, so no implementation details,
+// such as file paths or upstream URLs, are leaked; the cause is logged instead.
 func newErrHTTP(err error) errHTTP {
-	var (
-		repoCsvErr     *repository.CsvErr
-		repoDataApiErr *repository.DataApiErr
-		svcFilterErr   *service.FilterErr
-		svcArgsErr     *service.ArgsErr
-	)
-
-	switch {
-
-	// ###########  REPOSITORY ERRORS ###########
-
-	case errors.As(err, &repoCsvErr):
-		return errHTTP{
-			Code:      http.StatusInternalServerError,
-			ErrorType: repoCsvErrType,
-			Message:   err.Error(),
-		}
-	case errors.As(err, &repoDataApiErr):
+	problem, ok := problems.Match(err)
+	if !ok {
+		logger.Log().Warn().Err(err).Msg("newErrHTTP: error missing in the problems catalog")
 		return errHTTP{
-			Code:      http.StatusBadGateway,
-			ErrorType: repoDataApiErrType,
-			Message:   err.Error(),
+			Type:   problemTypePrefix + badRequestErrCode,
+			Title:  http.StatusText(http.StatusBadRequest),
+			Status: http.StatusBadRequest,
+			Detail: err.Error(),
+			Code:   badRequestErrCode,
 		}
-	case errors.Is(err, repository.ErrWPInvalidArgs):
+	}
+	if problem.Status >= http.StatusInternalServerError {
+		logger.Log().Error().Err(err).Str("code", problem.Code).Msg("newErrHTTP: server error")
 		return errHTTP{
-			Code:      http.StatusInternalServerError,
-			ErrorType: repoWPErrType,
-			Message:   err.Error(),
+			Type:   problemTypePrefix + problem.Code,
+			Title:  problem.Title,
+			Status: problem.Status,
+			Detail: problem.Title,
+			Code:   problem.Code,
 		}
+	}
 
-	// ########### SERVICE ERRORS ###########
+	errHttp := errHTTP{
+		Type:   problemTypePrefix + problem.Code,
+		Title:  problem.Title,
+		Status: problem.Status,
+		Detail: err.Error(),
+		Code:   problem.Code,
+	}
+	for _, field := range ct.FieldErrors(err) {
+		errHttp.Errors = append(errHttp.Errors, fieldHTTP{Field: field.Field, Detail: field.Error()})
+	}
+	return errHttp
+}
 
-	case errors.As(err, &svcFilterErr):
-		return errHTTP{
-			Code:      http.StatusUnprocessableEntity,
-			ErrorType: svcFilterErrType,
-			Message:   err.Error(),
-		}
-	case errors.As(err, &svcArgsErr):
-		return errHTTP{
-			Code:      http.StatusUnprocessableEntity,
-			ErrorType: svcArgsErrType,
-			Message:   err.Error(),
-		}
+// ParamsErr covers all errors related to the request parameters and wraps the error that caused it.
+type ParamsErr struct {
+	Err error
+}
 
-	// ########### CONTROLLER ERRORS ###########
+func (e ParamsErr) Error() string {
+	return fmt.Sprintf("request parameters: %s", e.Err)
+}
 
-	// ########### DEFAULT ERRORS ###########
+func (e ParamsErr) Unwrap() error {
+	return e.Err
+}
 
-	default:
-		return errHTTP{
-			Code:      http.StatusBadRequest,
-			ErrorType: errType(reflect.TypeOf(err).String()),
-			Message:   err.Error(),
-		}
-	}
+// AuthErr covers all errors related to the request authentication and wraps the error that caused it.
+type AuthErr struct {
+	Err error
+}
+
+func (e AuthErr) Error() string {
+	return fmt.Sprintf("authentication: %s", e.Err)
+}
+
+func (e AuthErr) Unwrap() error {
+	return e.Err
+}
+
+// ForbiddenErr covers all errors related to the request authorization and wraps the error that caused it.
+type ForbiddenErr struct {
+	Err error
+}
+
+func (e ForbiddenErr) Error() string {
+	return fmt.Sprintf("authorization: %s", e.Err)
+}
+
+func (e ForbiddenErr) Unwrap() error {
+	return e.Err
+}
+
+// RateLimitErr covers all errors related to the client rate limits and quotas, and wraps the error that caused it.
+type RateLimitErr struct {
+	Err error
+}
+
+func (e RateLimitErr) Error() string {
+	return fmt.Sprintf("rate limit: %s", e.Err)
+}
+
+func (e RateLimitErr) Unwrap() error {
+	return e.Err
+}
+
+// NotAcceptableErr covers all errors related to the response format negotiation, and wraps the error that caused it.
+type NotAcceptableErr struct {
+	Err error
+}
+
+func (e NotAcceptableErr) Error() string {
+	return fmt.Sprintf("content negotiation: %s", e.Err)
+}
+
+func (e NotAcceptableErr) Unwrap() error {
+	return e.Err
+}
+
+// PreconditionErr covers the writes missing their required precondition headers, and wraps the error that caused it.
+type PreconditionErr struct {
+	Err error
+}
+
+func (e PreconditionErr) Error() string {
+	return fmt.Sprintf("precondition: %s", e.Err)
+}
+
+func (e PreconditionErr) Unwrap() error {
+	return e.Err
 }
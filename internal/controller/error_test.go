@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/repository"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewErrHTTP(t *testing.T) {
+	_, numErr := strconv.Atoi("foo")
+	tests := []struct {
+		name string
+		err  error
+		want errHTTP
+	}{
+		{
+			name: "Repository error",
+			err:  &repository.CsvErr{Err: repository.ErrFileNameEmpty},
+			want: newTestProblem(repository.CsvErrCode, "Database file error"),
+		},
+		{
+			name: "Repository error holding an upstream URL",
+			err:  &repository.DataApiErr{Err: &url.Error{Op: "Get", URL: "https://foo.com/api?key=secret", Err: errors.New("timeout")}},
+			want: newTestProblem(repository.DataAPIErrCode, "Data API unavailable"),
+		},
+		{
+			name: "Repository sentinel error",
+			err:  fmt.Errorf("%w: jobs", repository.ErrWPInvalidArgs),
+			want: newTestProblem(repository.WorkerPoolErrCode, "Worker pool error"),
+		},
+		{
+			name: "Service error wrapping a more specific one",
+			err:  &service.FilterErr{Err: &service.QueryErr{Pos: 1, Err: service.ErrQueryEmpty}},
+			want: newTestProblem(service.QueryErrCode, "service filter: query: query empty at position 1"),
+		},
+		{
+			name: "Field errors",
+			err: &ParamsErr{errors.Join(
+				&ct.FieldErr{Field: limitParam, Err: ErrInvalidLimit},
+				&ct.FieldErr{Field: offsetParam, Err: ErrInvalidOffset},
+			)},
+			want: newTestProblem(ParamsErrCode, "request parameters: invalid limit\ninvalid offset",
+				fieldHTTP{Field: "limit", Detail: "invalid limit"},
+				fieldHTTP{Field: "offset", Detail: "invalid offset"},
+			),
+		},
+		{
+			name: "Unknown error",
+			err:  numErr,
+			want: newTestProblem(badRequestErrCode, numErr.Error()),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newErrHTTP(tt.err))
+		})
+	}
+}
+
+func TestErrJSON(t *testing.T) {
+	r := httptest.NewRequest("GET", "/cocktails", nil)
+	r = r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, "host/abc-000001"))
+	rr := httptest.NewRecorder()
+	errJSON(rr, r, &AuthErr{ErrMissingCredentials})
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, problemContentType, rr.Header().Get("Content-Type"))
+	var got errHTTP
+	require.Nil(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	want := newTestProblem(AuthErrCode, "authentication: missing credentials")
+	want.Instance = "host/abc-000001"
+	assert.Equal(t, want, got)
+}
+
+func TestProblems(t *testing.T) {
+	codes := make(map[string]bool)
+	for _, problem := range Problems() {
+		assert.False(t, codes[problem.Code], "duplicated code %q", problem.Code)
+		assert.NotEmpty(t, problem.Title)
+		assert.NotEmpty(t, http.StatusText(problem.Status))
+		codes[problem.Code] = true
+	}
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// eventStreamContentType is the media type of the Server-Sent Events. Ref: https://html.spec.whatwg.org/multipage/server-sent-events.html
+	eventStreamContentType = "text/event-stream"
+	// lastEventIDHeader is the header sent by the clients reconnecting to the event stream.
+	lastEventIDHeader = "Last-Event-ID"
+	// eventsHeartbeat is the time between the comments sent to keep the idle event streams alive.
+	eventsHeartbeat = 15 * time.Second
+	// eventsRetry is the reconnection time, in milliseconds, advised to the clients.
+	eventsRetry = 3000
+)
+
+var _ HTTP = Events{}
+
+// Events configures the routes and handler functions streaming the database events.
+type Events struct {
+	svc EventsSvc
+}
+
+// EventsSvc is the abstraction of the events subscription dependency.
+type EventsSvc interface {
+	Subscribe(lastID int64) (replay []ct.Event, events <-chan ct.Event, cancel func())
+}
+
+// NewEvents returns a new Events controller implementation.
+func NewEvents(svc EventsSvc) Events {
+	return Events{
+		svc: svc,
+	}
+}
+
+// SetRoutes sets a fresh middleware stack for the handle functions and mounts the routes in the provided sub router.
+// Streaming the events requires the reader role.
+func (e Events) SetRoutes(r chi.Router) {
+	r.With(requireRole(ct.ReaderRole)).Get("/events", e.stream)
+}
+
+// Docs returns the description of the Events routes.
+func (e Events) Docs() []RouteDoc {
+	return []RouteDoc{
+		{
+			Method:  http.MethodGet,
+			Pattern: "/events",
+			Summary: "Database events stream",
+			Desc:    "The database events pushed as Server-Sent Events.",
+			Role:    ct.ReaderRole,
+			Params: []ParamDoc{
+				{Name: lastEventIDHeader, In: "header", Type: "integer", Desc: "The ID of the last event received, to resume the stream from."},
+			},
+			Response:   "",
+			MediaTypes: []string{eventStreamContentType},
+		},
+	}
+}
+
+// stream is a handler function that pushes the database events as Server-Sent Events, until the client leaves.
+// Clients sending the Last-Event-ID header get the events they missed first.
+// A heartbeat comment is sent every 15 seconds of inactivity to keep the connection alive.
+// If the client falls behind, the stream is closed, so it reconnects and resumes from its last event.
+func (e Events) stream(w http.ResponseWriter, r *http.Request) {
+	var lastID int64
+	if v := r.Header.Get(lastEventIDHeader); v != "" {
+		var err error
+		lastID, err = strconv.ParseInt(v, 10, 64)
+		if err != nil || lastID < 0 {
+			errJSON(w, r, &ParamsErr{&ct.FieldErr{Field: "Last-Event-ID", Err: fmt.Errorf("%w: %q", ErrInvalidLastEventID, v)}})
+			return
+		}
+	}
+
+	replay, events, cancel := e.svc.Subscribe(lastID)
+	defer cancel()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", eventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(write func() error) bool {
+		if err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			logger.Log().Warn().Err(err).Msg("stream: set write deadline failed")
+		}
+		if err := write(); err != nil {
+			logger.Log().Debug().Err(err).Msg("stream: writing event failed, stream closed")
+			return false
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			logger.Log().Debug().Err(err).Msg("stream: flush failed, stream closed")
+			return false
+		}
+		return true
+	}
+
+	if !send(func() error { return writeRetry(w) }) {
+		return
+	}
+	for _, event := range replay {
+		event := event
+		if !send(func() error { return writeEvent(w, event) }) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				logger.Log().Debug().Msg("stream: subscription closed")
+				return
+			}
+			if !send(func() error { return writeEvent(w, event) }) {
+				return
+			}
+			heartbeat.Reset(eventsHeartbeat)
+		case <-heartbeat.C:
+			if !send(func() error { return writeHeartbeat(w) }) {
+				return
+			}
+		}
+	}
+}
+
+// writeRetry writes the advised reconnection time of the event stream.
+func writeRetry(w http.ResponseWriter) error {
+	_, err := fmt.Fprintf(w, "retry: %d\n\n", eventsRetry)
+	return err
+}
+
+// writeEvent writes the given event in the Server-Sent Events format, holding its data in JSON format.
+func writeEvent(w http.ResponseWriter, event ct.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// writeHeartbeat writes a comment to keep the event stream alive.
+func writeHeartbeat(w http.ResponseWriter) error {
+	_, err := fmt.Fprint(w, ": heartbeat\n\n")
+	return err
+}
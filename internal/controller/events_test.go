@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller/mocks"
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ EventsSvc = &mocks.EventsSvc{}
+
+func TestEvents_Stream(t *testing.T) {
+	type svc struct {
+		replay []ct.Event
+		events []ct.Event
+	}
+	tests := []struct {
+		name        string
+		lastEventID string
+		lastID      int64
+		code        int
+		err         errHTTP
+		svc         svc
+		exp         string
+		wantErr     bool
+	}{
+		{
+			name:        "Invalid last event id",
+			lastEventID: "foo",
+			code:        http.StatusBadRequest,
+			err: newTestProblem(ParamsErrCode, `request parameters: invalid last event id: "foo"`,
+				fieldHTTP{Field: "Last-Event-ID", Detail: `invalid last event id: "foo"`}),
+			wantErr: true,
+		},
+		{
+			name: "New subscription",
+			code: http.StatusOK,
+			svc: svc{
+				replay: []ct.Event{},
+				events: []ct.Event{{ID: 3, Type: "sync.completed", Data: ct.DBOpsSummary{Status: "no changes"}}},
+			},
+			exp: "retry: 3000\n\n" +
+				"id: 3\nevent: sync.completed\ndata: " + `{"status":"no changes","start_time":"0001-01-01T00:00:00Z","end_time":"0001-01-01T00:00:00Z","duration":"","new_records":0,"modified_records":0,"deleted_records":0,"total_operations":0,"total_records":0,"removed_records":0}` + "\n\n",
+		},
+		{
+			name:        "Resumed subscription",
+			lastEventID: "1",
+			lastID:      1,
+			code:        http.StatusOK,
+			svc: svc{
+				replay: []ct.Event{{ID: 2, Type: "cocktail.created", Data: map[string]any{"id": 1}}},
+				events: []ct.Event{{ID: 3, Type: "cocktail.updated", Data: map[string]any{"id": 2}}},
+			},
+			exp: "retry: 3000\n\n" +
+				"id: 2\nevent: cocktail.created\ndata: {\"id\":1}\n\n" +
+				"id: 3\nevent: cocktail.updated\ndata: {\"id\":2}\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewEventsSvc()
+			mSvc.On("Subscribe", tt.lastID).Return(tt.svc.replay, tt.svc.events)
+			mSvc.On("Cancel").Return()
+			ctrl := NewEvents(mSvc)
+
+			// Request
+			req, err := http.NewRequest("GET", "/events", nil)
+			require.Nil(t, err)
+			if tt.lastEventID != "" {
+				req.Header.Set(lastEventIDHeader, tt.lastEventID)
+			}
+
+			// Server instance
+			rr := httptest.NewRecorder()
+			srv := newTestRouter(ctrl)
+			srv.ServeHTTP(rr, req)
+
+			// Tests
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.wantErr {
+				var errMsg errHTTP
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errMsg))
+				assert.Equal(t, tt.err, errMsg)
+				mSvc.AssertNotCalled(t, "Subscribe", tt.lastID)
+				return
+			}
+			assert.Equal(t, eventStreamContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.exp, rr.Body.String())
+			mSvc.AssertCalled(t, "Cancel")
+		})
+	}
+}
@@ -0,0 +1,411 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/repository"
+
+	"github.com/go-chi/render"
+	"gopkg.in/yaml.v3"
+)
+
+// The response formats of the cocktail records.
+const (
+	jsonFormat   format = "json"
+	ndjsonFormat format = "ndjson"
+	csvFormat    format = "csv"
+	xmlFormat    format = "xml"
+	yamlFormat   format = "yaml"
+
+	// formatParam is the query parameter overriding the Accept header. e.g. "?format=csv"
+	formatParam = "format"
+)
+
+// listFormats are the formats of the cocktail lists.
+var listFormats = []format{jsonFormat, ndjsonFormat, csvFormat, xmlFormat, yamlFormat}
+
+// recordFormats are the formats of the single cocktail records.
+var recordFormats = []format{jsonFormat, csvFormat, xmlFormat, yamlFormat}
+
+// formatContentTypes are the media types responded for each format.
+var formatContentTypes = map[format]string{
+	jsonFormat:   "application/json",
+	ndjsonFormat: ndjsonContentType,
+	csvFormat:    "text/csv; charset=utf-8",
+	xmlFormat:    "application/xml; charset=utf-8",
+	yamlFormat:   "application/yaml; charset=utf-8",
+}
+
+// mediaTypeFormats are the formats of the accepted media types.
+var mediaTypeFormats = map[string]format{
+	"application/json":     jsonFormat,
+	"application/x-ndjson": ndjsonFormat,
+	"application/ndjson":   ndjsonFormat,
+	"text/csv":             csvFormat,
+	"application/xml":      xmlFormat,
+	"text/xml":             xmlFormat,
+	"application/yaml":     yamlFormat,
+	"application/x-yaml":   yamlFormat,
+	"text/yaml":            yamlFormat,
+}
+
+// format represents a response format. e.g. json, csv
+type format string
+
+// negotiateFormat returns the response format of the request, among the supported ones.
+// The "format" query parameter takes precedence over the Accept header; if both are missing, the first
+// supported format is selected. The accepted media types are ranked by their quality value.
+// Returns a NotAcceptableErr if no supported format is acceptable.
+func negotiateFormat(r *http.Request, supported ...format) (format, error) {
+	if v := r.URL.Query().Get(formatParam); v != "" {
+		f := format(strings.ToLower(v))
+		if !hasFormat(supported, f) {
+			return "", &NotAcceptableErr{fmt.Errorf("%w: format %q", ErrNotAcceptable, v)}
+		}
+		return f, nil
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return supported[0], nil
+	}
+	type mediaRange struct {
+		format format
+		q      float64
+	}
+	ranges := make([]mediaRange, 0)
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if f, ok := acceptedFormat(mediaType, supported); ok && q > 0 {
+			ranges = append(ranges, mediaRange{format: f, q: q})
+		}
+	}
+	if len(ranges) == 0 {
+		return "", &NotAcceptableErr{fmt.Errorf("%w: %q", ErrNotAcceptable, accept)}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	return ranges[0].format, nil
+}
+
+// acceptedFormat returns the first supported format of the given media type, which can be a wildcard.
+// e.g. "text/*"
+func acceptedFormat(mediaType string, supported []format) (format, bool) {
+	if mediaType == "*/*" {
+		return supported[0], true
+	}
+	if prefix, found := strings.CutSuffix(mediaType, "*"); found {
+		for _, f := range supported {
+			if strings.HasPrefix(formatContentTypes[f], prefix) {
+				return f, true
+			}
+		}
+		return "", false
+	}
+	f, ok := mediaTypeFormats[mediaType]
+	return f, ok && hasFormat(supported, f)
+}
+
+// hasFormat reports whether the given format is in the formats list.
+func hasFormat(formats []format, f format) bool {
+	for _, v := range formats {
+		if v == f {
+			return true
+		}
+	}
+	return false
+}
+
+// renderCocktails renders the given records in the given format.
+// If fields are given, only those fields of the records are rendered, in order.
+func renderCocktails(w http.ResponseWriter, r *http.Request, f format, cocktails []entity.Cocktail, fields []string) {
+	varyAccept(w)
+	if f == jsonFormat {
+		if len(fields) == 0 {
+			render.JSON(w, r, cocktails)
+			return
+		}
+		render.JSON(w, r, projectCocktails(cocktails, fields))
+		return
+	}
+
+	if len(fields) == 0 {
+		fields = cocktailFieldNames
+	}
+	buf := &bytes.Buffer{}
+	var err error
+	switch f {
+	case ndjsonFormat:
+		err = writeNDJSON(buf, cocktails, fields)
+	case csvFormat:
+		err = writeCSV(buf, cocktails, fields)
+	case xmlFormat:
+		err = writeXML(buf, cocktails, fields)
+	case yamlFormat:
+		err = writeYAML(buf, cocktails, fields)
+	}
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	writeFormatted(w, f, buf.Bytes())
+}
+
+// renderCocktail renders the given record in the given format, along with its ETag. See encodeCocktail and recordETag.
+func renderCocktail(w http.ResponseWriter, r *http.Request, f format, cocktail entity.Cocktail) {
+	body, err := encodeCocktail(f, cocktail)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	w.Header().Set(etagHeader, recordETag(cocktail, body))
+	writeFormatted(w, f, body)
+}
+
+// encodeCocktail returns the given record encoded in the given format: a JSON object, a CSV row along with a header,
+// a <cocktail> XML document or a YAML mapping, holding all the record fields.
+func encodeCocktail(f format, cocktail entity.Cocktail) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	var err error
+	switch f {
+	case csvFormat:
+		err = writeCSV(buf, []entity.Cocktail{cocktail}, cocktailFieldNames)
+	case xmlFormat:
+		err = writeXMLRecord(buf, cocktail, cocktailFieldNames)
+	case yamlFormat:
+		err = encodeYAML(buf, orderedFields(cocktail, cocktailFieldNames))
+	default:
+		// As render.JSON encodes it.
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(true)
+		err = enc.Encode(cocktail)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFormatted writes the given body in the given format, with the Accept header added to the Vary header.
+func writeFormatted(w http.ResponseWriter, f format, body []byte) {
+	varyAccept(w)
+	w.Header().Set("Content-Type", formatContentTypes[f])
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		logger.Log().Error().Err(err).Str("format", string(f)).Msg("writeFormatted: write response failed")
+	}
+}
+
+// writeNDJSON writes the given fields of the records as NDJSON lines.
+func writeNDJSON(buf *bytes.Buffer, cocktails []entity.Cocktail, fields []string) error {
+	enc := json.NewEncoder(buf)
+	for _, cocktail := range cocktails {
+		if err := enc.Encode(orderedFields(cocktail, fields)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCSV writes the given fields of the records in the column layout of the CSV database, along with a header.
+func writeCSV(buf *bytes.Buffer, cocktails []entity.Cocktail, fields []string) error {
+	columns := make(map[string]int)
+	for i, name := range repository.CsvHeaders() {
+		columns[name] = i
+	}
+
+	w := csv.NewWriter(buf)
+	if err := w.Write(fields); err != nil {
+		return err
+	}
+	row := make([]string, len(fields))
+	for _, cocktail := range cocktails {
+		rec, err := repository.CsvRecord(cocktail)
+		if err != nil {
+			return err
+		}
+		for i, field := range fields {
+			row[i] = rec[columns[field]]
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeXML writes the given fields of the records as the <cocktail> elements of a <cocktails> document.
+func writeXML(buf *bytes.Buffer, cocktails []entity.Cocktail, fields []string) error {
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", "  ")
+	root := xml.StartElement{Name: xml.Name{Local: "cocktails"}}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+	for _, cocktail := range cocktails {
+		if err := enc.EncodeElement(orderedFields(cocktail, fields), xml.StartElement{Name: xml.Name{Local: "cocktail"}}); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// writeXMLRecord writes the given fields of the record as a <cocktail> document.
+func writeXMLRecord(buf *bytes.Buffer, cocktail entity.Cocktail, fields []string) error {
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", "  ")
+	if err := enc.EncodeElement(orderedFields(cocktail, fields), xml.StartElement{Name: xml.Name{Local: "cocktail"}}); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// writeYAML writes the given fields of the records as a YAML sequence, keeping the fields order.
+func writeYAML(buf *bytes.Buffer, cocktails []entity.Cocktail, fields []string) error {
+	records := make([]fieldList, 0, len(cocktails))
+	for _, cocktail := range cocktails {
+		records = append(records, orderedFields(cocktail, fields))
+	}
+	return encodeYAML(buf, records)
+}
+
+// encodeYAML writes the given value in YAML format, as it would be written in JSON format.
+// The value is encoded through JSON, so the JSON field names and formats are kept.
+func encodeYAML(buf *bytes.Buffer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle clears the JSON styles of the given YAML node and its children, so they are written in block style.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// field is a named value of a record.
+type field struct {
+	name  string
+	value any
+}
+
+// fieldList is a list of record fields, encoded in order.
+type fieldList []field
+
+// orderedFields returns the given fields of the entity.Cocktail, in order.
+func orderedFields(c entity.Cocktail, fields []string) fieldList {
+	projected := projectCocktail(c, fields)
+	list := make(fieldList, 0, len(fields))
+	for _, name := range fields {
+		list = append(list, field{name: name, value: projected[name]})
+	}
+	return list
+}
+
+func (l fieldList) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, f := range l {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalXML encodes the fields as child elements. The list fields are encoded as a parent element holding
+// an element per item, named after the singular of the field name. e.g. <tags><tag>IBA</tag></tags>
+func (l fieldList) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, f := range l {
+		el := xml.StartElement{Name: xml.Name{Local: f.name}}
+		value := reflect.ValueOf(f.value)
+		if value.Kind() != reflect.Slice {
+			if err := e.EncodeElement(f.value, el); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := e.EncodeToken(el); err != nil {
+			return err
+		}
+		item := xml.StartElement{Name: xml.Name{Local: strings.TrimSuffix(f.name, "s")}}
+		for i := 0; i < value.Len(); i++ {
+			if err := e.EncodeElement(value.Index(i).Interface(), item); err != nil {
+				return err
+			}
+		}
+		if err := e.EncodeToken(el.End()); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// cocktailFieldNames are the JSON names of the entity.Cocktail fields, in declaration order, computed once.
+var cocktailFieldNames = newCocktailFieldNames()
+
+// newCocktailFieldNames returns the JSON names of the entity.Cocktail fields, in declaration order.
+func newCocktailFieldNames() []string {
+	names := make([]string, 0, len(cocktailJSONFields))
+	for name := range cocktailJSONFields {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return cocktailJSONFields[names[i]] < cocktailJSONFields[names[j]] })
+	return names
+}
@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller/mocks"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		accept    string
+		supported []format
+		want      format
+		wantErr   bool
+	}{
+		{
+			name:      "Default",
+			supported: listFormats,
+			want:      jsonFormat,
+		},
+		{
+			name:      "Accept",
+			accept:    "text/csv",
+			supported: listFormats,
+			want:      csvFormat,
+		},
+		{
+			name:      "Quality values",
+			accept:    "application/json;q=0.5, application/yaml, text/csv;q=0.8",
+			supported: listFormats,
+			want:      yamlFormat,
+		},
+		{
+			name:      "Browser",
+			accept:    "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			supported: listFormats,
+			want:      xmlFormat,
+		},
+		{
+			name:      "Any",
+			accept:    "*/*",
+			supported: listFormats,
+			want:      jsonFormat,
+		},
+		{
+			name:      "Type wildcard",
+			accept:    "text/*",
+			supported: listFormats,
+			want:      csvFormat,
+		},
+		{
+			name:      "Format overrides Accept",
+			query:     "format=NDJSON",
+			accept:    "text/csv",
+			supported: listFormats,
+			want:      ndjsonFormat,
+		},
+		{
+			name:      "Unsupported format",
+			query:     "format=pdf",
+			supported: listFormats,
+			wantErr:   true,
+		},
+		{
+			name:      "Unsupported Accept",
+			accept:    "application/pdf, text/html",
+			supported: listFormats,
+			wantErr:   true,
+		},
+		{
+			name:      "Refused",
+			accept:    "application/xml;q=0",
+			supported: []format{jsonFormat, xmlFormat},
+			wantErr:   true,
+		},
+		{
+			name:      "Not supported by the endpoint",
+			accept:    "application/yaml",
+			supported: []format{jsonFormat, ndjsonFormat, csvFormat},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/cocktails?"+tt.query, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			got, err := negotiateFormat(r, tt.supported...)
+			if tt.wantErr {
+				var errNA *NotAcceptableErr
+				assert.ErrorAs(t, err, &errNA)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCocktail_GetAllFormats(t *testing.T) {
+	date := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	cocktails := []entity.Cocktail{
+		{
+			ID:          1,
+			Name:        "Foo, Bar",
+			Ingredients: []entity.Ingredient{{Name: "Gin", Measure: "1 oz"}},
+			Tags:        []string{"IBA", "Classic"},
+			SrcDate:     date,
+			CreatedAt:   date,
+			UpdatedAt:   date,
+		},
+		{ID: 2, Name: "Baz"},
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		accept      string
+		code        int
+		contentType string
+		body        string
+	}{
+		{
+			name:        "CSV",
+			query:       "?fields=id,name,tags",
+			accept:      "text/csv",
+			code:        http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+			body:        "id,name,tags\n1,\"Foo, Bar\",\"IBA,Classic\"\n2,Baz,\n",
+		},
+		{
+			name:        "NDJSON",
+			query:       "?format=ndjson&fields=name,id",
+			code:        http.StatusOK,
+			contentType: ndjsonContentType,
+			body:        "{\"name\":\"Foo, Bar\",\"id\":1}\n{\"name\":\"Baz\",\"id\":2}\n",
+		},
+		{
+			name:        "XML",
+			query:       "?format=xml&fields=id,ingredients,tags&limit=1",
+			code:        http.StatusOK,
+			contentType: "application/xml; charset=utf-8",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<cocktails>
+  <cocktail>
+    <id>1</id>
+    <ingredients>
+      <ingredient>
+        <name>Gin</name>
+        <measure>1 oz</measure>
+      </ingredient>
+    </ingredients>
+    <tags>
+      <tag>IBA</tag>
+      <tag>Classic</tag>
+    </tags>
+  </cocktail>
+</cocktails>`,
+		},
+		{
+			name:        "YAML",
+			query:       "?fields=id,name,created_at&limit=1",
+			accept:      "application/yaml",
+			code:        http.StatusOK,
+			contentType: "application/yaml; charset=utf-8",
+			body:        "- id: 1\n  name: Foo, Bar\n  created_at: \"2023-10-01T12:00:00Z\"\n",
+		},
+		{
+			name:        "Not acceptable",
+			accept:      "application/pdf",
+			code:        http.StatusNotAcceptable,
+			contentType: problemContentType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Checksum").Return(testChecksum, nil)
+			mSvc.On("LastModified").Return(testLastModified, nil)
+			mSvc.On("GetAll").Return(cocktails, nil)
+			ctrl := Cocktail{svc: mSvc}
+
+			// Request
+			req, err := http.NewRequest("GET", "/cocktails"+tt.query, nil)
+			require.Nil(t, err)
+			req.Header.Set("Accept", tt.accept)
+
+			// Server instance
+			rr := httptest.NewRecorder()
+			srv := newTestRouter(ctrl)
+			srv.ServeHTTP(rr, req)
+
+			// Tests
+			assert.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.contentType, rr.Header().Get("Content-Type"))
+			if tt.body != "" {
+				assert.Equal(t, tt.body, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCocktail_StreamAllCSV(t *testing.T) {
+	cocktails := []entity.Cocktail{{ID: 1, Name: "Foo"}, {ID: 2, Name: "Bar"}}
+	mSvc := mocks.NewCocktailSvc()
+	mSvc.On("StreamAll", mock.Anything).Return(cocktails, nil)
+	ctrl := Cocktail{svc: mSvc}
+
+	req, err := http.NewRequest("GET", "/cocktails/stream?format=csv", nil)
+	require.Nil(t, err)
+	rr := httptest.NewRecorder()
+	newTestRouter(ctrl).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	recs, err := csv.NewReader(strings.NewReader(rr.Body.String())).ReadAll()
+	require.Nil(t, err)
+	require.Len(t, recs, 3)
+	assert.Equal(t, repository.CsvHeaders(), recs[0])
+	for i, cocktail := range cocktails {
+		exp, err := repository.CsvRecord(cocktail)
+		require.Nil(t, err)
+		assert.Equal(t, exp, recs[i+1])
+	}
+}
+
+func TestEncodeYAML(t *testing.T) {
+	var buf bytes.Buffer
+	data, err := json.Marshal(fieldList{{name: "b", value: 1}, {name: "a", value: []string{"x"}}})
+	require.Nil(t, err)
+	assert.Equal(t, `{"b":1,"a":["x"]}`, string(data))
+
+	require.Nil(t, encodeYAML(&buf, fieldList{{name: "b", value: 1}, {name: "a", value: []string{"x"}}}))
+	assert.Equal(t, "b: 1\na:\n  - x\n", buf.String())
+}
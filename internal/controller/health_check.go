@@ -22,6 +22,13 @@ func (h HealthCheck) SetRoutes(r chi.Router) {
 	r.Get("/healthz", h.heartbeat)
 }
 
+// Docs returns the description of the HealthCheck routes.
+func (h HealthCheck) Docs() []RouteDoc {
+	return []RouteDoc{
+		{Method: http.MethodGet, Pattern: "/healthz", Summary: "Heartbeat of the API", Response: basicMessage{}},
+	}
+}
+
 // heartbeat is a handler function that checks the heartbeat of the API.
 func (h HealthCheck) heartbeat(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, basicMessage{
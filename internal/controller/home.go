@@ -22,6 +22,13 @@ func (h Home) SetRoutes(r chi.Router) {
 	r.Get("/", h.HomePage)
 }
 
+// Docs returns the description of the Home routes.
+func (h Home) Docs() []RouteDoc {
+	return []RouteDoc{
+		{Method: http.MethodGet, Pattern: "/", Summary: "Home page", Response: "", MediaTypes: []string{"text/html"}},
+	}
+}
+
 // HomePage is a handler function that responses the home page in HTML format.
 func (Home) HomePage(w http.ResponseWriter, r *http.Request) {
 	render.HTML(w, r, `
@@ -0,0 +1,311 @@
+package controller
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+)
+
+const (
+	// maxListLimit is the highest number of records a list page can hold.
+	maxListLimit = 1000
+
+	// The list query parameters.
+	limitParam  = "limit"
+	offsetParam = "offset"
+	cursorParam = "cursor"
+	sortParam   = "sort"
+	fieldsParam = "fields"
+
+	// The list metadata headers.
+	totalCountHeader = "X-Total-Count"
+	nextCursorHeader = "X-Next-Cursor"
+	linkHeader       = "Link"
+)
+
+// cocktailSorters compare two entity.Cocktail records by the property of the sort field.
+var cocktailSorters = map[string]func(a, b entity.Cocktail) int{
+	"id":          func(a, b entity.Cocktail) int { return compareInt(a.ID, b.ID) },
+	"name":        func(a, b entity.Cocktail) int { return compareFold(a.Name, b.Name) },
+	"alcoholic":   func(a, b entity.Cocktail) int { return compareFold(a.Alcoholic, b.Alcoholic) },
+	"category":    func(a, b entity.Cocktail) int { return compareFold(a.Category, b.Category) },
+	"glass":       func(a, b entity.Cocktail) int { return compareFold(a.Glass, b.Glass) },
+	"iba":         func(a, b entity.Cocktail) int { return compareFold(a.IBA, b.IBA) },
+	"source_date": func(a, b entity.Cocktail) int { return compareTime(a.SrcDate, b.SrcDate) },
+	"created_at":  func(a, b entity.Cocktail) int { return compareTime(a.CreatedAt, b.CreatedAt) },
+	"updated_at":  func(a, b entity.Cocktail) int { return compareTime(a.UpdatedAt, b.UpdatedAt) },
+}
+
+// listOpts holds the pagination, sorting and field selection options of a list request.
+type listOpts struct {
+	limit  int
+	offset int
+	sort   []sortKey
+	fields []string
+	format format
+}
+
+// sortKey is a sort field and its direction.
+type sortKey struct {
+	field string
+	desc  bool
+}
+
+// newListRequestOpts returns the listOpts set by the query parameters of the given request, along with the
+// response format negotiated by the request. See newListOpts and negotiateFormat.
+func newListRequestOpts(r *http.Request) (listOpts, error) {
+	opts, err := newListOpts(r.URL.Query())
+	if err != nil {
+		return listOpts{}, err
+	}
+	opts.format, err = negotiateFormat(r, listFormats...)
+	if err != nil {
+		return listOpts{}, err
+	}
+	return opts, nil
+}
+
+// newListOpts returns the listOpts set by the given query parameters:
+//   - limit: the maximum number of records to retrieve. Zero or missing retrieves all of them.
+//   - offset: the number of records to skip.
+//   - cursor: the opaque cursor of the next page returned by a previous request. It holds the offset of the page,
+//     so it can not be combined with the offset parameter.
+//   - sort: a comma separated list of sort fields. A "-" prefix sorts in descending order. e.g. "name,-updated_at"
+//   - fields: a comma separated list of the fields to retrieve. e.g. "id,name,thumb"
+func newListOpts(query url.Values) (listOpts, error) {
+	opts := listOpts{}
+	var err error
+
+	opts.limit, err = parseLimit(query)
+	if err != nil {
+		return listOpts{}, err
+	}
+	if v := query.Get(offsetParam); v != "" {
+		opts.offset, err = strconv.Atoi(v)
+		if err != nil || opts.offset < 0 {
+			return listOpts{}, &ParamsErr{&ct.FieldErr{Field: offsetParam, Err: fmt.Errorf("%w: %q", ErrInvalidOffset, v)}}
+		}
+	}
+
+	sortValue := query.Get(sortParam)
+	if v := query.Get(cursorParam); v != "" {
+		if query.Get(offsetParam) != "" {
+			return listOpts{}, &ParamsErr{&ct.FieldErr{Field: cursorParam, Err: ErrCursorWithOffset}}
+		}
+		offset, cursorSort, ok := decodeCursor(v)
+		if !ok || (sortValue != "" && sortValue != cursorSort) {
+			return listOpts{}, &ParamsErr{&ct.FieldErr{Field: cursorParam, Err: ErrInvalidCursor}}
+		}
+		opts.offset = offset
+		sortValue = cursorSort
+	}
+
+	for _, field := range splitList(sortValue) {
+		key := sortKey{field: strings.TrimPrefix(field, "-"), desc: strings.HasPrefix(field, "-")}
+		if _, ok := cocktailSorters[key.field]; !ok {
+			return listOpts{}, &ParamsErr{&ct.FieldErr{Field: sortParam, Err: fmt.Errorf("%w: %q", ErrInvalidSortField, key.field)}}
+		}
+		opts.sort = append(opts.sort, key)
+	}
+
+	for _, field := range splitList(query.Get(fieldsParam)) {
+		if _, ok := cocktailJSONFields[field]; !ok {
+			return listOpts{}, &ParamsErr{&ct.FieldErr{Field: fieldsParam, Err: fmt.Errorf("%w: %q", ErrInvalidField, field)}}
+		}
+		opts.fields = append(opts.fields, field)
+	}
+
+	return opts, nil
+}
+
+// parseLimit returns the value of the limit query parameter, which must be between 0 and 1000.
+// Returns zero if it is missing.
+func parseLimit(query url.Values) (int, error) {
+	v := query.Get(limitParam)
+	if v == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 0 || limit > maxListLimit {
+		return 0, &ParamsErr{&ct.FieldErr{Field: limitParam, Err: fmt.Errorf("%w: %q, must be between 0 and %d", ErrInvalidLimit, v, maxListLimit)}}
+	}
+	return limit, nil
+}
+
+// sortValue returns the sort options in the form of the sort query parameter.
+func (o listOpts) sortValue() string {
+	fields := make([]string, 0, len(o.sort))
+	for _, key := range o.sort {
+		if key.desc {
+			fields = append(fields, "-"+key.field)
+			continue
+		}
+		fields = append(fields, key.field)
+	}
+	return strings.Join(fields, ",")
+}
+
+// apply sorts and paginates the given records.
+// It returns the records of the page and the offset of the next page, which is zero if it is the last page.
+func (o listOpts) apply(cocktails []entity.Cocktail) (page []entity.Cocktail, next int) {
+	if len(o.sort) > 0 {
+		cocktails = append([]entity.Cocktail(nil), cocktails...)
+		sort.SliceStable(cocktails, func(i, j int) bool {
+			for _, key := range o.sort {
+				c := cocktailSorters[key.field](cocktails[i], cocktails[j])
+				if c == 0 {
+					continue
+				}
+				if key.desc {
+					return c > 0
+				}
+				return c < 0
+			}
+			return false
+		})
+	}
+
+	if o.offset >= len(cocktails) {
+		return []entity.Cocktail{}, 0
+	}
+	page = cocktails[o.offset:]
+	if o.limit > 0 && o.limit < len(page) {
+		return page[:o.limit], o.offset + o.limit
+	}
+	return page, 0
+}
+
+// renderList renders the page of the given records set by the list options, in the negotiated format.
+// The total number of records is set in the X-Total-Count header. If there are more records,
+// the cursor of the next page is set in the X-Next-Cursor header, along with its URL in the Link header.
+// The conditional headers are checked by the handlers before reading the records. See Cocktail.checkNotModified.
+func renderList(w http.ResponseWriter, r *http.Request, opts listOpts, cocktails []entity.Cocktail) {
+	renderCocktails(w, r, opts.format, setListHeaders(w, r, opts, cocktails), opts.fields)
+}
+
+// setListHeaders sets the pagination headers of the given records, and returns the page set by the list options.
+// See renderList.
+func setListHeaders(w http.ResponseWriter, r *http.Request, opts listOpts, cocktails []entity.Cocktail) []entity.Cocktail {
+	total := len(cocktails)
+	page, next := opts.apply(cocktails)
+
+	w.Header().Set(totalCountHeader, strconv.Itoa(total))
+	if next > 0 {
+		cursor := encodeCursor(next, opts.sortValue())
+		query := r.URL.Query()
+		query.Del(offsetParam)
+		query.Del(sortParam)
+		query.Set(cursorParam, cursor)
+		nextURL := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+		w.Header().Set(nextCursorHeader, cursor)
+		w.Header().Set(linkHeader, fmt.Sprintf(`<%s>; rel="next"`, nextURL.String()))
+	}
+	return page
+}
+
+// encodeCursor returns the opaque cursor pointing to the given offset of the records sorted by the sort value.
+// The cursor is offset-based: the records written between the requests of two pages shift the next one.
+func encodeCursor(offset int, sortValue string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d|%s", offset, sortValue)))
+}
+
+// decodeCursor returns the offset and the sort value of the given cursor.
+func decodeCursor(cursor string) (offset int, sortValue string, ok bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", false
+	}
+	offsetValue, sortValue, found := strings.Cut(string(raw), "|")
+	if !found {
+		return 0, "", false
+	}
+	offset, err = strconv.Atoi(offsetValue)
+	if err != nil || offset < 0 {
+		return 0, "", false
+	}
+	return offset, sortValue, true
+}
+
+// cocktailJSONFields is the index of the entity.Cocktail fields keyed by their JSON name, computed once.
+var cocktailJSONFields = newCocktailJSONFields()
+
+// newCocktailJSONFields returns the index of the entity.Cocktail fields keyed by their JSON name.
+func newCocktailJSONFields() map[string]int {
+	fields := make(map[string]int)
+	typ := reflect.TypeOf(entity.Cocktail{})
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}
+
+// projectCocktails returns the given fields of each entity.Cocktail keyed by their JSON name. See projectCocktail.
+func projectCocktails(cocktails []entity.Cocktail, fields []string) []map[string]any {
+	projected := make([]map[string]any, 0, len(cocktails))
+	for _, cocktail := range cocktails {
+		projected = append(projected, projectCocktail(cocktail, fields))
+	}
+	return projected
+}
+
+// projectCocktail returns the given fields of the entity.Cocktail keyed by their JSON name.
+func projectCocktail(c entity.Cocktail, fields []string) map[string]any {
+	value := reflect.ValueOf(c)
+	projected := make(map[string]any, len(fields))
+	for _, field := range fields {
+		projected[field] = value.Field(cocktailJSONFields[field]).Interface()
+	}
+	return projected
+}
+
+// splitList returns the non-empty trimmed items of a comma separated list.
+func splitList(list string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// compareInt returns -1, 0 or +1 depending on whether a is less than, equal to or greater than b.
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareFold compares two strings case-insensitively.
+func compareFold(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// compareTime compares two times.
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller/mocks"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testListCocktails = []entity.Cocktail{
+	{ID: 1, Name: "Foo", Category: "Cocktail", Thumb: "foo.jpg"},
+	{ID: 2, Name: "bar", Category: "Shot", Thumb: "bar.jpg"},
+	{ID: 3, Name: "Baz", Category: "Cocktail", Thumb: "baz.jpg"},
+	{ID: 4, Name: "Qux", Category: "Shot", Thumb: "qux.jpg"},
+}
+
+func TestNewListOpts(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		exp   listOpts
+		err   error
+	}{
+		{
+			name:  "Empty",
+			query: "",
+			exp:   listOpts{},
+			err:   nil,
+		},
+		{
+			name:  "Negative limit",
+			query: "limit=-1",
+			err:   ErrInvalidLimit,
+		},
+		{
+			name:  "Limit too high",
+			query: "limit=1001",
+			err:   ErrInvalidLimit,
+		},
+		{
+			name:  "Bad offset",
+			query: "offset=foo",
+			err:   ErrInvalidOffset,
+		},
+		{
+			name:  "Bad cursor",
+			query: "cursor=foo",
+			err:   ErrInvalidCursor,
+		},
+		{
+			name:  "Cursor with a different sort",
+			query: "cursor=" + encodeCursor(2, "name") + "&sort=id",
+			err:   ErrInvalidCursor,
+		},
+		{
+			name:  "Cursor with an offset",
+			query: "offset=5&cursor=" + encodeCursor(20, "-name"),
+			err:   ErrCursorWithOffset,
+		},
+		{
+			name:  "Invalid sort field",
+			query: "sort=name,-instructions",
+			err:   ErrInvalidSortField,
+		},
+		{
+			name:  "Invalid field",
+			query: "fields=id,foo",
+			err:   ErrInvalidField,
+		},
+		{
+			name:  "Valid",
+			query: "limit=10&offset=20&sort=category,-updated_at&fields=id,name,thumb",
+			exp: listOpts{
+				limit:  10,
+				offset: 20,
+				sort:   []sortKey{{field: "category"}, {field: "updated_at", desc: true}},
+				fields: []string{"id", "name", "thumb"},
+			},
+			err: nil,
+		},
+		{
+			name:  "Cursor",
+			query: "limit=10&cursor=" + encodeCursor(20, "-name"),
+			exp: listOpts{
+				limit:  10,
+				offset: 20,
+				sort:   []sortKey{{field: "name", desc: true}},
+			},
+			err: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.Nil(t, err)
+
+			out, err := newListOpts(query)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.IsType(t, &ParamsErr{}, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp, out)
+		})
+	}
+}
+
+func TestListOpts_Apply(t *testing.T) {
+	tests := []struct {
+		name string
+		opts listOpts
+		exp  []int
+		next int
+	}{
+		{
+			name: "All",
+			opts: listOpts{},
+			exp:  []int{1, 2, 3, 4},
+			next: 0,
+		},
+		{
+			name: "First page",
+			opts: listOpts{limit: 3},
+			exp:  []int{1, 2, 3},
+			next: 3,
+		},
+		{
+			name: "Last page",
+			opts: listOpts{limit: 3, offset: 3},
+			exp:  []int{4},
+			next: 0,
+		},
+		{
+			name: "Offset out of range",
+			opts: listOpts{offset: 10},
+			exp:  []int{},
+			next: 0,
+		},
+		{
+			name: "Sort by name case-insensitively",
+			opts: listOpts{sort: []sortKey{{field: "name"}}},
+			exp:  []int{2, 3, 1, 4},
+			next: 0,
+		},
+		{
+			name: "Sort by many fields",
+			opts: listOpts{sort: []sortKey{{field: "category", desc: true}, {field: "id", desc: true}}, limit: 2},
+			exp:  []int{4, 2},
+			next: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, next := tt.opts.apply(testListCocktails)
+			ids := make([]int, 0, len(page))
+			for _, c := range page {
+				ids = append(ids, c.ID)
+			}
+			assert.Equal(t, tt.exp, ids)
+			assert.Equal(t, tt.next, next)
+		})
+	}
+}
+
+func TestCocktail_GetAllList(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		code   int
+		total  string
+		cursor string
+		exp    []map[string]any
+	}{
+		{
+			name:  "Bad parameters",
+			query: "limit=foo",
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:   "Page with projection",
+			query:  "limit=2&sort=-id&fields=id,name",
+			code:   http.StatusOK,
+			total:  "4",
+			cursor: encodeCursor(2, "-id"),
+			exp:    []map[string]any{{"id": float64(4), "name": "Qux"}, {"id": float64(3), "name": "Baz"}},
+		},
+		{
+			name:   "Next page",
+			query:  "limit=2&fields=thumb&cursor=" + encodeCursor(2, "-id"),
+			code:   http.StatusOK,
+			total:  "4",
+			cursor: "",
+			exp:    []map[string]any{{"thumb": "bar.jpg"}, {"thumb": "foo.jpg"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewCocktailSvc()
+			mSvc.On("Checksum").Return(testChecksum, nil)
+			mSvc.On("LastModified").Return(testLastModified, nil)
+			mSvc.On("GetAll").Return(testListCocktails, nil)
+			ctrl := Cocktail{svc: mSvc}
+
+			req, err := http.NewRequest("GET", "/cocktails?"+tt.query, nil)
+			require.Nil(t, err)
+			rr := httptest.NewRecorder()
+			newTestRouter(ctrl).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.code != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.total, rr.Header().Get(totalCountHeader))
+			assert.Equal(t, tt.cursor, rr.Header().Get(nextCursorHeader))
+			if tt.cursor != "" {
+				assert.Contains(t, rr.Header().Get(linkHeader), url.QueryEscape(tt.cursor))
+			}
+			var resp []map[string]any
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.exp, resp)
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package mocks
 
 import (
+	"context"
+	"time"
+
 	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
 
@@ -12,10 +15,11 @@ type CocktailSvc struct {
 	mock.Mock
 }
 
-// GetFiltered provides a mock function with given fields:
-func (o *CocktailSvc) GetFiltered(filter, value string) ([]entity.Cocktail, error) {
+// GetFilteredWithSuggestions provides a mock function with given fields: filter, value
+func (o *CocktailSvc) GetFilteredWithSuggestions(filter, value string) ([]entity.Cocktail, []ct.Suggestion, error) {
 	args := o.Called(filter, value)
-	return args.Get(0).([]entity.Cocktail), args.Error(1)
+	suggestions, _ := args.Get(1).([]ct.Suggestion)
+	return args.Get(0).([]entity.Cocktail), suggestions, args.Error(2)
 }
 
 // GetAll provides a mock function with given fields:
@@ -24,18 +28,132 @@ func (o *CocktailSvc) GetAll() ([]entity.Cocktail, error) {
 	return args.Get(0).([]entity.Cocktail), args.Error(1)
 }
 
+// Query provides a mock function with given fields:
+func (o *CocktailSvc) Query(q string) ([]entity.Cocktail, error) {
+	args := o.Called(q)
+	return args.Get(0).([]entity.Cocktail), args.Error(1)
+}
+
+// StreamAll provides a mock function with given fields:
+// The records returned by the mock are passed to fn one by one, before returning the mock error.
+func (o *CocktailSvc) StreamAll(ctx context.Context, fn func(entity.Cocktail) error) error {
+	args := o.Called(ctx)
+	for _, rec := range args.Get(0).([]entity.Cocktail) {
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+// GetStats provides a mock function with given fields:
+func (o *CocktailSvc) GetStats(q string, top int) (ct.CocktailStats, error) {
+	args := o.Called(q, top)
+	return args.Get(0).(ct.CocktailStats), args.Error(1)
+}
+
+// GetChanges provides a mock function with given fields:
+func (o *CocktailSvc) GetChanges(cursor string, limit int) (ct.ChangeFeed, error) {
+	args := o.Called(cursor, limit)
+	return args.Get(0).(ct.ChangeFeed), args.Error(1)
+}
+
 // GetCC provides a mock function with given fields:
 func (o *CocktailSvc) GetCC(nType, jobs, jWorker string) ([]entity.Cocktail, error) {
 	args := o.Called(nType, jobs, jWorker)
 	return args.Get(0).([]entity.Cocktail), args.Error(1)
 }
 
-// UpdateDB provides a mock function with given fields:
-func (o *CocktailSvc) UpdateDB() (ct.DBOpsSummary, error) {
+// UpdateDB provides a mock function with given fields: ctx, restoreDeleted
+func (o *CocktailSvc) UpdateDB(ctx context.Context, restoreDeleted bool) (ct.DBOpsSummary, error) {
+	args := o.Called(ctx, restoreDeleted)
+	return args.Get(0).(ct.DBOpsSummary), args.Error(1)
+}
+
+// Checksum provides a mock function with given fields:
+func (o *CocktailSvc) Checksum() (string, error) {
+	args := o.Called()
+	return args.String(0), args.Error(1)
+}
+
+// LastModified provides a mock function with given fields:
+func (o *CocktailSvc) LastModified() (time.Time, error) {
 	args := o.Called()
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+// Get provides a mock function with given fields: id
+func (o *CocktailSvc) Get(id int) (entity.Cocktail, error) {
+	args := o.Called(id)
+	return args.Get(0).(entity.Cocktail), args.Error(1)
+}
+
+// Update provides a mock function with given fields: ctx, id, version, c
+func (o *CocktailSvc) Update(ctx context.Context, id, version int, c entity.Cocktail) (entity.Cocktail, error) {
+	args := o.Called(ctx, id, version, c)
+	return args.Get(0).(entity.Cocktail), args.Error(1)
+}
+
+// Delete provides a mock function with given fields: ctx, id, version
+func (o *CocktailSvc) Delete(ctx context.Context, id, version int) error {
+	args := o.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+// History provides a mock function with given fields: id
+func (o *CocktailSvc) History(id int) ([]entity.Revision, error) {
+	args := o.Called(id)
+	return args.Get(0).([]entity.Revision), args.Error(1)
+}
+
+// GetAllAsOf provides a mock function with given fields: t
+func (o *CocktailSvc) GetAllAsOf(t time.Time) ([]entity.Cocktail, error) {
+	args := o.Called(t)
+	return args.Get(0).([]entity.Cocktail), args.Error(1)
+}
+
+// GetAsOf provides a mock function with given fields: id, t
+func (o *CocktailSvc) GetAsOf(id int, t time.Time) (entity.Cocktail, error) {
+	args := o.Called(id, t)
+	return args.Get(0).(entity.Cocktail), args.Error(1)
+}
+
+// Rollback provides a mock function with given fields: ctx, txID
+func (o *CocktailSvc) Rollback(ctx context.Context, txID string) (ct.DBOpsSummary, error) {
+	args := o.Called(ctx, txID)
 	return args.Get(0).(ct.DBOpsSummary), args.Error(1)
 }
 
+// Compact provides a mock function with given fields: ctx
+func (o *CocktailSvc) Compact(ctx context.Context) (ct.CompactSummary, error) {
+	args := o.Called(ctx)
+	return args.Get(0).(ct.CompactSummary), args.Error(1)
+}
+
+// GetAllWithDeleted provides a mock function with given fields:
+func (o *CocktailSvc) GetAllWithDeleted() ([]entity.Cocktail, error) {
+	args := o.Called()
+	return args.Get(0).([]entity.Cocktail), args.Error(1)
+}
+
+// GetWithDeleted provides a mock function with given fields: id
+func (o *CocktailSvc) GetWithDeleted(id int) (entity.Cocktail, error) {
+	args := o.Called(id)
+	return args.Get(0).(entity.Cocktail), args.Error(1)
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (o *CocktailSvc) Restore(ctx context.Context, id int) (entity.Cocktail, error) {
+	args := o.Called(ctx, id)
+	return args.Get(0).(entity.Cocktail), args.Error(1)
+}
+
+// Purge provides a mock function with given fields: ctx, id
+func (o *CocktailSvc) Purge(ctx context.Context, id int) error {
+	args := o.Called(ctx, id)
+	return args.Error(0)
+}
+
 // NewCocktailSvc creates a new instance of the CocktailSvc of type Mock.
 func NewCocktailSvc() *CocktailSvc {
 	return &CocktailSvc{}
@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// EventsSvc is a mock type for the EventsSvc dependency
+type EventsSvc struct {
+	mock.Mock
+}
+
+// Subscribe provides a mock function with given fields:
+// The events returned by the mock are delivered through a closed channel, so the subscription ends after them.
+// The returned cancel function calls the "Cancel" method of the mock.
+func (o *EventsSvc) Subscribe(lastID int64) ([]ct.Event, <-chan ct.Event, func()) {
+	args := o.Called(lastID)
+	events := args.Get(1).([]ct.Event)
+	ch := make(chan ct.Event, len(events))
+	for _, event := range events {
+		ch <- event
+	}
+	close(ch)
+	return args.Get(0).([]ct.Event), ch, func() { o.MethodCalled("Cancel") }
+}
+
+// NewEventsSvc creates a new instance of the EventsSvc of type Mock.
+func NewEventsSvc() *EventsSvc {
+	return &EventsSvc{}
+}
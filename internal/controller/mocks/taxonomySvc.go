@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// TaxonomySvc is a mock type for the TaxonomySvc dependency
+type TaxonomySvc struct {
+	mock.Mock
+}
+
+// GetTaxonomy provides a mock function with given fields:
+func (o *TaxonomySvc) GetTaxonomy(taxonomy string) ([]ct.TaxonomyValue, error) {
+	args := o.Called(taxonomy)
+	return args.Get(0).([]ct.TaxonomyValue), args.Error(1)
+}
+
+// NewTaxonomySvc creates a new instance of the TaxonomySvc of type Mock.
+func NewTaxonomySvc() *TaxonomySvc {
+	return &TaxonomySvc{}
+}
@@ -0,0 +1,47 @@
+package mocks
+
+import (
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// WebhookSvc is a mock type for the WebhookSvc dependency
+type WebhookSvc struct {
+	mock.Mock
+}
+
+// Register provides a mock function with given fields:
+func (o *WebhookSvc) Register(url, secret string, events []string) (entity.Webhook, error) {
+	args := o.Called(url, secret, events)
+	return args.Get(0).(entity.Webhook), args.Error(1)
+}
+
+// GetAll provides a mock function with given fields:
+func (o *WebhookSvc) GetAll() ([]entity.Webhook, error) {
+	args := o.Called()
+	return args.Get(0).([]entity.Webhook), args.Error(1)
+}
+
+// Delete provides a mock function with given fields:
+func (o *WebhookSvc) Delete(id string) error {
+	args := o.Called(id)
+	return args.Error(0)
+}
+
+// GetDeliveries provides a mock function with given fields:
+func (o *WebhookSvc) GetDeliveries(id string, limit int) ([]entity.Delivery, error) {
+	args := o.Called(id, limit)
+	return args.Get(0).([]entity.Delivery), args.Error(1)
+}
+
+// GetDeadLetters provides a mock function with given fields:
+func (o *WebhookSvc) GetDeadLetters(id string, limit int) ([]entity.DeadLetter, error) {
+	args := o.Called(id, limit)
+	return args.Get(0).([]entity.DeadLetter), args.Error(1)
+}
+
+// NewWebhookSvc creates a new instance of the WebhookSvc of type Mock.
+func NewWebhookSvc() *WebhookSvc {
+	return &WebhookSvc{}
+}
@@ -0,0 +1,546 @@
+package controller
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/config"
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+const (
+	// openAPIVersion is the version of the OpenAPI specification format. Ref: https://spec.openapis.org/oas/v3.0.3
+	openAPIVersion = "3.0.3"
+	// openAPITitle is the title of the API in the specification.
+	openAPITitle = "Capstone - Cocktails Recipes"
+
+	// The names of the security schemes authenticating the requests. See sharedhttp.Auth.
+	apiKeyScheme = "apiKey"
+	bearerScheme = "bearer"
+)
+
+// docsPage is the self-contained HTML page rendering the OpenAPI specification, with no external resources.
+//
+//go:embed openapi.html
+var docsPage []byte
+
+// routeParamPattern matches the regexp of the chi route parameters. e.g. "{id:[0-9]+}"
+var routeParamPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?}`)
+
+// schemaNames are the component names of the schemas whose Go type name is not descriptive enough.
+var schemaNames = map[reflect.Type]string{
+	reflect.TypeOf(errHTTP{}):                         "Problem",
+	reflect.TypeOf(fieldHTTP{}):                       "ProblemField",
+	reflect.TypeOf(filteredResult[entity.Cocktail]{}): "FilteredCocktails",
+}
+
+var _ HTTP = OpenAPI{}
+
+// OpenAPI configures the routes publishing the OpenAPI 3 specification of the API, and its documentation page.
+// The specification is generated from the routes registered in the router, described by their RouteDoc.
+type OpenAPI struct {
+	version  string
+	basePath string
+	routes   chi.Routes
+	docs     func() []RouteDoc
+	spec     *openAPICache
+}
+
+// openAPICache holds the specification, generated once all the routes are registered.
+type openAPICache struct {
+	once sync.Once
+	data []byte
+	err  error
+}
+
+// NewOpenAPI returns a new OpenAPI controller implementation.
+// routes is the router whose routes are documented, and docs returns the description of the routes,
+// so they are read at the first request, once all the controllers are registered.
+func NewOpenAPI(cfg config.Application, routes chi.Routes, docs func() []RouteDoc) OpenAPI {
+	return OpenAPI{
+		version:  cfg.Version(),
+		basePath: cfg.BasePath(),
+		routes:   routes,
+		docs:     docs,
+		spec:     &openAPICache{},
+	}
+}
+
+// SetRoutes sets a fresh middleware stack for the handle functions and mounts the routes in the provided sub router.
+// The specification and its documentation page are public.
+func (o OpenAPI) SetRoutes(r chi.Router) {
+	r.Get("/openapi.json", o.getSpec)
+	r.Get("/docs", o.getDocs)
+}
+
+// Docs returns the description of the OpenAPI routes.
+func (o OpenAPI) Docs() []RouteDoc {
+	return []RouteDoc{
+		{
+			Method:     http.MethodGet,
+			Pattern:    "/openapi.json",
+			Summary:    "OpenAPI specification",
+			Desc:       "The OpenAPI 3 specification of the API, generated from the registered routes.",
+			MediaTypes: []string{"application/json"},
+			Response:   map[string]any{},
+		},
+		{
+			Method:     http.MethodGet,
+			Pattern:    "/docs",
+			Summary:    "API documentation",
+			Desc:       "The documentation page of the API, rendering the OpenAPI specification.",
+			MediaTypes: []string{"text/html"},
+			Response:   "",
+		},
+	}
+}
+
+// getSpec is a handler function that responds the OpenAPI specification in JSON format.
+func (o OpenAPI) getSpec(w http.ResponseWriter, r *http.Request) {
+	o.spec.once.Do(func() {
+		o.spec.data, o.spec.err = json.Marshal(o.newDocument())
+	})
+	if o.spec.err != nil {
+		errJSON(w, r, o.spec.err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(o.spec.data); err != nil {
+		logger.Log().Error().Err(err).Msg("getSpec: write response failed")
+	}
+}
+
+// getDocs is a handler function that responds the documentation page in HTML format.
+func (o OpenAPI) getDocs(w http.ResponseWriter, r *http.Request) {
+	render.HTML(w, r, string(docsPage))
+}
+
+// RouteDoc describes a route in the OpenAPI specification.
+// Pattern is the route pattern relative to the base path, as registered in the router. e.g. "/cocktails/{id}"
+// Body and Response are sample values of the request and response bodies, whose types define their schemas;
+// a nil Response means the response has no body. JSONResponse is the sample value of the JSON response, if it
+// differs from the Response of the rest of the media types. Role is the role required by the route; empty means public.
+type RouteDoc struct {
+	Method       string
+	Pattern      string
+	Tag          string
+	Summary      string
+	Desc         string
+	Role         ct.Role
+	Params       []ParamDoc
+	Body         any
+	Status       int
+	Response     any
+	JSONResponse any
+	MediaTypes   []string
+}
+
+// ParamDoc describes a request parameter in the OpenAPI specification.
+// In is the parameter location: "query", "path" or "header". Type is its JSON type; empty means string.
+type ParamDoc struct {
+	Name     string
+	In       string
+	Desc     string
+	Type     string
+	Required bool
+	Enum     []string
+}
+
+// Documented is implemented by the controllers describing their routes in the OpenAPI specification.
+type Documented interface {
+	Docs() []RouteDoc
+}
+
+// listParamDocs are the query parameters of the list endpoints. See newListRequestOpts.
+var listParamDocs = []ParamDoc{
+	{Name: limitParam, In: "query", Type: "integer", Desc: "The maximum number of records to retrieve, up to 1000. Zero retrieves all of them."},
+	{Name: offsetParam, In: "query", Type: "integer", Desc: "The number of records to skip."},
+	{Name: cursorParam, In: "query", Desc: "The opaque cursor of the next page. It holds the offset of the next page, so it can not be combined with offset."},
+	{Name: sortParam, In: "query", Desc: "Comma separated list of the fields to sort by; a \"-\" prefix sorts in descending order. e.g. name,-updated_at"},
+	{Name: fieldsParam, In: "query", Desc: "Comma separated list of the fields to retrieve. e.g. id,name,thumb"},
+	{Name: formatParam, In: "query", Desc: "The response format. It takes precedence over the Accept header.", Enum: formatNames(listFormats)},
+}
+
+// recordFormatParamDoc is the query parameter of the format of the single record endpoints. See renderCocktail.
+var recordFormatParamDoc = ParamDoc{Name: formatParam, In: "query", Desc: "The response format. It takes precedence over the Accept header.",
+	Enum: formatNames(recordFormats)}
+
+// conditionalParamDocs are the headers of the conditional requests. See checkETag and checkModifiedSince.
+var conditionalParamDocs = []ParamDoc{
+	{Name: ifNoneMatchHeader, In: "header", Desc: "The ETag of the cached response; 304 Not Modified is responded if it is still current."},
+	{Name: ifModifiedSinceHeader, In: "header", Desc: "The Last-Modified time of the cached response; ignored along with If-None-Match."},
+}
+
+// ifMatchParamDoc is the header of the edits of a record. See parseIfMatch.
+var ifMatchParamDoc = ParamDoc{Name: ifMatchHeader, In: "header", Required: true,
+	Desc: "The ETag of the record version being edited, e.g. \"v3-kX3v2c8aQn7tZb1s\", its version alone, e.g. \"v3\", or * to edit any version."}
+
+// formatNames returns the names of the given formats.
+func formatNames(formats []format) []string {
+	names := make([]string, 0, len(formats))
+	for _, f := range formats {
+		names = append(names, string(f))
+	}
+	return names
+}
+
+// formatMediaTypes returns the media types of the given formats, without parameters.
+func formatMediaTypes(formats []format) []string {
+	types := make([]string, 0, len(formats))
+	for _, f := range formats {
+		mediaType, _, _ := strings.Cut(formatContentTypes[f], ";")
+		types = append(types, mediaType)
+	}
+	return types
+}
+
+// openAPIDoc is the root object of the OpenAPI specification.
+type openAPIDoc struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       openAPIInfo                     `json:"info"`
+	Servers    []openAPIServer                 `json:"servers"`
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components components                      `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type components struct {
+	Schemas         map[string]*schema        `json:"schemas"`
+	Responses       map[string]response       `json:"responses"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// schema is a JSON schema of the OpenAPI specification.
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+}
+
+// The problem responses shared by the operations.
+const (
+	problemResponse         = "Problem"
+	unauthorizedResponse    = "Unauthorized"
+	forbiddenResponse       = "Forbidden"
+	notAcceptableResponse   = "NotAcceptable"
+	tooManyRequestsResponse = "TooManyRequests"
+)
+
+// newDocument returns the OpenAPI specification of the routes registered in the router.
+// The routes with no RouteDoc are documented by their path parameters alone, and logged.
+func (o OpenAPI) newDocument() openAPIDoc {
+	docs := make(map[string]RouteDoc)
+	for _, doc := range o.docs() {
+		docs[doc.Method+" "+doc.Pattern] = doc
+	}
+
+	gen := schemaGen{schemas: make(map[string]*schema)}
+	problem := gen.schemaOf(reflect.TypeOf(errHTTP{}))
+	doc := openAPIDoc{
+		OpenAPI: openAPIVersion,
+		Info:    openAPIInfo{Title: openAPITitle, Version: o.version},
+		Servers: []openAPIServer{{URL: o.basePath}},
+		Paths:   make(map[string]map[string]operation),
+		Components: components{
+			Schemas: gen.schemas,
+			Responses: map[string]response{
+				problemResponse:         newProblemResponse("The request failed.", problem),
+				unauthorizedResponse:    newProblemResponse("Missing or invalid credentials.", problem),
+				forbiddenResponse:       newProblemResponse("The client is not granted the required role.", problem),
+				notAcceptableResponse:   newProblemResponse("None of the response formats is acceptable.", problem),
+				tooManyRequestsResponse: newProblemResponse("The rate limit or the daily quota is exceeded.", problem),
+			},
+			SecuritySchemes: map[string]securityScheme{
+				apiKeyScheme: {Type: "apiKey", In: "header", Name: "X-API-Key", Description: "A configured API key."},
+				bearerScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "A JWT signed by a trusted key."},
+			},
+		},
+	}
+
+	walkFn := func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if strings.HasSuffix(route, "*") {
+			return nil
+		}
+		pattern := routeParamPattern.ReplaceAllString(route, "{$1}")
+		pattern = strings.TrimPrefix(pattern, o.basePath)
+		if len(pattern) > 1 {
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		if pattern == "" {
+			pattern = "/"
+		}
+
+		routeDoc, ok := docs[method+" "+pattern]
+		if !ok {
+			logger.Log().Warn().Str("method", method).Str("route", route).Msg("openapi: undocumented route")
+			routeDoc = RouteDoc{Method: method, Pattern: pattern}
+		}
+		if doc.Paths[pattern] == nil {
+			doc.Paths[pattern] = make(map[string]operation)
+		}
+		doc.Paths[pattern][strings.ToLower(method)] = gen.operation(routeDoc)
+		return nil
+	}
+	if err := chi.Walk(o.routes, walkFn); err != nil {
+		logger.Log().Error().Err(err).Msg("openapi: walk routes failed")
+	}
+	return doc
+}
+
+// newProblemResponse returns a problem details response of the given description.
+func newProblemResponse(desc string, problem *schema) response {
+	return response{
+		Description: desc,
+		Content:     map[string]mediaType{problemContentType: {Schema: problem}},
+	}
+}
+
+// schemaGen generates the JSON schemas of the Go types, gathering the struct schemas as components.
+type schemaGen struct {
+	schemas map[string]*schema
+}
+
+// operation returns the OpenAPI operation described by the given RouteDoc.
+// The path parameters with no ParamDoc are documented as required strings.
+func (g schemaGen) operation(doc RouteDoc) operation {
+	op := operation{
+		Summary:     doc.Summary,
+		Description: doc.Desc,
+		Responses:   make(map[string]response),
+	}
+	if doc.Tag != "" {
+		op.Tags = []string{doc.Tag}
+	}
+
+	documented := make(map[string]bool)
+	for _, p := range doc.Params {
+		documented[p.In+" "+p.Name] = true
+		op.Parameters = append(op.Parameters, newParameter(p))
+	}
+	for _, match := range routeParamPattern.FindAllStringSubmatch(doc.Pattern, -1) {
+		if !documented["path "+match[1]] {
+			op.Parameters = append(op.Parameters, newParameter(ParamDoc{Name: match[1], In: "path", Required: true}))
+		}
+	}
+
+	if doc.Body != nil {
+		op.RequestBody = &requestBody{
+			Required: true,
+			Content:  map[string]mediaType{"application/json": {Schema: g.schemaOf(reflect.TypeOf(doc.Body))}},
+		}
+	}
+
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := response{Description: http.StatusText(status)}
+	if doc.Response != nil {
+		mediaTypes := doc.MediaTypes
+		if len(mediaTypes) == 0 {
+			mediaTypes = []string{"application/json"}
+		}
+		success.Content = make(map[string]mediaType)
+		for _, mt := range mediaTypes {
+			resp := doc.Response
+			if mt == "application/json" && doc.JSONResponse != nil {
+				resp = doc.JSONResponse
+			}
+			success.Content[mt] = mediaType{Schema: g.mediaTypeSchema(mt, reflect.TypeOf(resp))}
+		}
+		if len(mediaTypes) > 1 {
+			op.Responses[strconv.Itoa(http.StatusNotAcceptable)] = response{Ref: "#/components/responses/" + notAcceptableResponse}
+		}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	if doc.Role != "" {
+		op.Description = strings.TrimSpace(op.Description + " Requires the " + doc.Role.String() + " role.")
+		op.Security = []map[string][]string{{apiKeyScheme: {}}, {bearerScheme: {}}}
+		op.Responses[strconv.Itoa(http.StatusUnauthorized)] = response{Ref: "#/components/responses/" + unauthorizedResponse}
+		op.Responses[strconv.Itoa(http.StatusForbidden)] = response{Ref: "#/components/responses/" + forbiddenResponse}
+	}
+	op.Responses[strconv.Itoa(http.StatusTooManyRequests)] = response{Ref: "#/components/responses/" + tooManyRequestsResponse}
+	op.Responses["default"] = response{Ref: "#/components/responses/" + problemResponse}
+	return op
+}
+
+// newParameter returns the OpenAPI parameter described by the given ParamDoc.
+func newParameter(p ParamDoc) parameter {
+	typ := p.Type
+	if typ == "" {
+		typ = "string"
+	}
+	return parameter{
+		Name:        p.Name,
+		In:          p.In,
+		Description: p.Desc,
+		Required:    p.Required,
+		Schema:      &schema{Type: typ, Enum: p.Enum},
+	}
+}
+
+// mediaTypeSchema returns the schema of the given type responded in the given media type.
+// CSV and the text media types are plain strings, and NDJSON lines are the items of a list.
+func (g schemaGen) mediaTypeSchema(mt string, t reflect.Type) *schema {
+	switch {
+	case strings.HasPrefix(mt, "text/"):
+		return &schema{Type: "string"}
+	case mt == ndjsonContentType && t.Kind() == reflect.Slice:
+		return g.schemaOf(t.Elem())
+	default:
+		return g.schemaOf(t)
+	}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaOf returns the JSON schema of the given type, as encoded by the encoding/json package.
+// The named structs are added to the components, and referenced.
+func (g schemaGen) schemaOf(t reflect.Type) *schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return &schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &schema{Type: "string", Format: "byte"}
+		}
+		return &schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := schemaName(t)
+		if _, ok := g.schemas[name]; !ok {
+			g.schemas[name] = &schema{}
+			*g.schemas[name] = *g.structSchema(t)
+		}
+		return &schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &schema{}
+	}
+}
+
+// structSchema returns the object schema of the given struct type, holding its exported JSON fields.
+// The fields of the embedded structs are promoted, like the encoding/json package does.
+func (g schemaGen) structSchema(t reflect.Type) *schema {
+	s := &schema{Type: "object", Properties: make(map[string]*schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for prop, propSchema := range g.structSchema(f.Type).Properties {
+				s.Properties[prop] = propSchema
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = g.schemaOf(f.Type)
+	}
+	return s
+}
+
+// schemaName returns the component name of the given struct type.
+func schemaName(t reflect.Type) string {
+	if name, ok := schemaNames[t]; ok {
+		return name
+	}
+	return strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+}
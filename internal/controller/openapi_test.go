@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/config"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestOpenAPI returns the OpenAPI controller documenting the routes of all the controllers, mounted in a
+// new chi.Mux under the "/api/v1" base path, as the application does.
+func newTestOpenAPI() (OpenAPI, *chi.Mux) {
+	r := chi.NewRouter()
+	ctrls := []HTTP{NewHome(), NewHealthCheck(), Cocktail{}, Taxonomy{}, Events{}, Webhook{}}
+	docs := func() []RouteDoc {
+		all := make([]RouteDoc, 0)
+		for _, ctrl := range ctrls {
+			all = append(all, ctrl.(Documented).Docs()...)
+		}
+		return all
+	}
+	o := NewOpenAPI(config.NewApplication("v1.2.3"), r, docs)
+	ctrls = append(ctrls, o)
+	r.Route("/api/v1", func(r chi.Router) {
+		for _, ctrl := range ctrls {
+			ctrl := ctrl
+			r.Group(func(r chi.Router) { ctrl.SetRoutes(r) })
+		}
+	})
+	return o, r
+}
+
+func TestOpenAPI_NewDocument(t *testing.T) {
+	o, _ := newTestOpenAPI()
+	doc := o.newDocument()
+
+	// Every registered route is documented, and every documented route is registered.
+	routes := make(map[string]bool)
+	for path, ops := range doc.Paths {
+		for method, op := range ops {
+			routes[strings.ToUpper(method)+" "+path] = true
+			assert.NotEmpty(t, op.Summary, "undocumented route %s %s", method, path)
+		}
+	}
+	for _, routeDoc := range o.docs() {
+		assert.True(t, routes[routeDoc.Method+" "+routeDoc.Pattern], "unregistered route %s %s", routeDoc.Method, routeDoc.Pattern)
+	}
+
+	assert.Equal(t, "v1.2.3", doc.Info.Version)
+	assert.Equal(t, []openAPIServer{{URL: "/api/v1"}}, doc.Servers)
+	assert.Contains(t, doc.Paths, "/")
+	assert.Contains(t, doc.Paths, "/openapi.json")
+
+	// Parameters
+	getCC := doc.Paths["/cocktails/{type}/{items}/{items-worker}"]["get"]
+	require.NotEmpty(t, getCC.Parameters)
+	assert.Equal(t, parameter{Name: "type", In: "path", Description: "The type of the record IDs.", Required: true,
+		Schema: &schema{Type: "string", Enum: []string{"odd", "even"}}}, getCC.Parameters[0])
+	deleteWebhook := doc.Paths["/webhooks/{id}"]["delete"]
+	assert.Equal(t, []parameter{{Name: "id", In: "path", Required: true, Schema: &schema{Type: "string"}}}, deleteWebhook.Parameters)
+
+	// Responses
+	getAll := doc.Paths["/cocktails"]["get"]
+	assert.Equal(t, &schema{Type: "array", Items: &schema{Ref: "#/components/schemas/Cocktail"}}, getAll.Responses["200"].Content["application/json"].Schema)
+	assert.Equal(t, &schema{Ref: "#/components/schemas/Cocktail"}, getAll.Responses["200"].Content[ndjsonContentType].Schema)
+	assert.Equal(t, &schema{Type: "string"}, getAll.Responses["200"].Content["text/csv"].Schema)
+	assert.Equal(t, "#/components/responses/NotAcceptable", getAll.Responses["406"].Ref)
+	assert.Equal(t, "#/components/responses/Unauthorized", getAll.Responses["401"].Ref)
+	assert.Equal(t, "Requires the reader role.", getAll.Description)
+	assert.Empty(t, doc.Paths["/healthz"]["get"].Security)
+	assert.Empty(t, deleteWebhook.Responses["204"].Content)
+	getFiltered := doc.Paths["/cocktail/{filter}/{value}"]["get"]
+	assert.Equal(t, &schema{Ref: "#/components/schemas/FilteredCocktails"}, getFiltered.Responses["200"].Content["application/json"].Schema)
+	assert.Equal(t, &schema{Ref: "#/components/schemas/Cocktail"}, getFiltered.Responses["200"].Content[ndjsonContentType].Schema)
+	updateDB := doc.Paths["/cocktail/updatedb"]["get"]
+	assert.Equal(t, &schema{Ref: "#/components/schemas/DBOpsSummary"}, updateDB.Responses["200"].Content["application/json"].Schema)
+
+	// Schemas
+	cocktail := doc.Components.Schemas["Cocktail"]
+	require.NotNil(t, cocktail)
+	assert.Len(t, cocktail.Properties, len(cocktailJSONFields))
+	assert.Equal(t, &schema{Type: "string", Format: "date-time"}, cocktail.Properties["created_at"])
+	assert.Equal(t, &schema{Type: "array", Items: &schema{Ref: "#/components/schemas/Ingredient"}}, cocktail.Properties["ingredients"])
+	problem := doc.Components.Schemas["Problem"]
+	require.NotNil(t, problem)
+	assert.Equal(t, &schema{Type: "array", Items: &schema{Ref: "#/components/schemas/ProblemField"}}, problem.Properties["errors"])
+	deadLetter := doc.Components.Schemas["DeadLetter"]
+	require.NotNil(t, deadLetter)
+	assert.Contains(t, deadLetter.Properties, "webhook_id")
+	assert.Equal(t, &schema{}, deadLetter.Properties["payload"])
+}
+
+func TestOpenAPI_Routes(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+	}{
+		{
+			name:        "Specification",
+			path:        "/api/v1/openapi.json",
+			contentType: "application/json",
+			body:        `"openapi":"3.0.3"`,
+		},
+		{
+			name:        "Documentation page",
+			path:        "/api/v1/docs",
+			contentType: "text/html; charset=utf-8",
+			body:        `fetch("openapi.json")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, srv := newTestOpenAPI()
+			req, err := http.NewRequest("GET", tt.path, nil)
+			require.Nil(t, err)
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tt.contentType, rr.Header().Get("Content-Type"))
+			assert.Contains(t, rr.Body.String(), tt.body)
+			if tt.contentType == "application/json" {
+				assert.True(t, json.Valid(rr.Body.Bytes()))
+			}
+		})
+	}
+}
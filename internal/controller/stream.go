@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/repository"
+)
+
+const (
+	// ndjsonContentType is the media type of the newline delimited JSON format. Ref: https://github.com/ndjson/ndjson-spec
+	ndjsonContentType = "application/x-ndjson"
+	// streamFlushEvery is the number of records written between flushes of the stream.
+	streamFlushEvery = 100
+	// streamWriteTimeout is the time given to write every batch of records before the stream is aborted.
+	streamWriteTimeout = 30 * time.Second
+)
+
+// recordStreamer writes entity.Cocktail records as they come, either as the items of a JSON array, as NDJSON lines
+// or as CSV rows in the column layout of the CSV database.
+// The response headers are written along with the first record, so errors raised before it can still be
+// responded as an error JSON.
+type recordStreamer struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	enc     *json.Encoder
+	csv     *csv.Writer
+	format  format
+	count   int
+	started bool
+}
+
+// newRecordStreamer returns a new recordStreamer writing to w in the given format: JSON, NDJSON or CSV.
+func newRecordStreamer(w http.ResponseWriter, f format) *recordStreamer {
+	return &recordStreamer{
+		w:      w,
+		rc:     http.NewResponseController(w),
+		enc:    json.NewEncoder(w),
+		csv:    csv.NewWriter(w),
+		format: f,
+	}
+}
+
+// start writes the response headers and opens the JSON array or writes the CSV header, if needed.
+func (s *recordStreamer) start() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	s.w.Header().Set("Content-Type", formatContentTypes[s.format])
+	s.w.WriteHeader(http.StatusOK)
+	s.extendDeadline()
+	switch s.format {
+	case jsonFormat:
+		_, err := s.w.Write([]byte("["))
+		return err
+	case csvFormat:
+		return s.csv.Write(repository.CsvHeaders())
+	default:
+		return nil
+	}
+}
+
+// write writes the given record, flushing the response every streamFlushEvery records.
+func (s *recordStreamer) write(cocktail entity.Cocktail) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	switch s.format {
+	case csvFormat:
+		rec, err := repository.CsvRecord(cocktail)
+		if err != nil {
+			return err
+		}
+		if err := s.csv.Write(rec); err != nil {
+			return err
+		}
+	case jsonFormat:
+		if s.count > 0 {
+			if _, err := s.w.Write([]byte(",")); err != nil {
+				return err
+			}
+		}
+		fallthrough
+	default:
+		if err := s.enc.Encode(cocktail); err != nil {
+			return err
+		}
+	}
+	s.count++
+	if s.count%streamFlushEvery == 0 {
+		return s.flush()
+	}
+	return nil
+}
+
+// close closes the JSON array, if needed, and flushes the response.
+func (s *recordStreamer) close() error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	if s.format == jsonFormat {
+		if _, err := s.w.Write([]byte("]\n")); err != nil {
+			return err
+		}
+	}
+	return s.flush()
+}
+
+// flush sends the buffered data to the client and extends the write deadline for the next batch.
+func (s *recordStreamer) flush() error {
+	s.csv.Flush()
+	if err := s.csv.Error(); err != nil {
+		return err
+	}
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	s.extendDeadline()
+	return nil
+}
+
+// extendDeadline sets the write deadline of the response to streamWriteTimeout from now.
+func (s *recordStreamer) extendDeadline() {
+	err := s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logger.Log().Warn().Err(err).Msg("recordStreamer: set write deadline failed")
+	}
+}
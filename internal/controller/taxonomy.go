@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+var _ HTTP = Taxonomy{}
+
+// taxonomies are the cocktail properties whose distinct values can be listed, used as route paths.
+var taxonomies = []string{"categories", "glasses", "ingredients", "tags", "alcoholic-types"}
+
+// Taxonomy configures the routes and handler functions listing the distinct values of the cocktail properties.
+type Taxonomy struct {
+	svc TaxonomySvc
+}
+
+// TaxonomySvc is the abstraction of the Taxonomy service dependency.
+type TaxonomySvc interface {
+	GetTaxonomy(taxonomy string) ([]ct.TaxonomyValue, error)
+}
+
+// NewTaxonomy returns a new Taxonomy controller implementation.
+func NewTaxonomy(svc TaxonomySvc) Taxonomy {
+	return Taxonomy{
+		svc: svc,
+	}
+}
+
+// SetRoutes sets a fresh middleware stack for the handle functions and mounts the routes in the provided sub router.
+// Listing the values requires the reader role.
+func (t Taxonomy) SetRoutes(r chi.Router) {
+	r = r.With(requireRole(ct.ReaderRole))
+	for _, taxonomy := range taxonomies {
+		r.Get("/"+taxonomy, t.getValues(taxonomy))
+	}
+}
+
+// Docs returns the description of the Taxonomy routes.
+func (t Taxonomy) Docs() []RouteDoc {
+	docs := make([]RouteDoc, 0, len(taxonomies))
+	for _, taxonomy := range taxonomies {
+		docs = append(docs, RouteDoc{
+			Method:   http.MethodGet,
+			Pattern:  "/" + taxonomy,
+			Summary:  "List the distinct " + strings.ReplaceAll(taxonomy, "-", " "),
+			Role:     ct.ReaderRole,
+			Response: []ct.TaxonomyValue{},
+		})
+	}
+	return docs
+}
+
+// getValues returns a handler function that retrieve the distinct values of the given taxonomy in JSON format.
+func (t Taxonomy) getValues(taxonomy string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		values, err := t.svc.GetTaxonomy(taxonomy)
+		if err != nil {
+			errJSON(w, r, err)
+			return
+		}
+		render.JSON(w, r, values)
+	}
+}
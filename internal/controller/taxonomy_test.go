@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller/mocks"
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ TaxonomySvc = &mocks.TaxonomySvc{}
+
+func TestNewTaxonomy(t *testing.T) {
+	mSvc := mocks.NewTaxonomySvc()
+	require.NotNil(t, mSvc)
+	out := NewTaxonomy(mSvc)
+	assert.IsType(t, Taxonomy{}, out)
+}
+
+func TestTaxonomy_GetValues(t *testing.T) {
+	type svc struct {
+		resp []ct.TaxonomyValue
+		err  error
+	}
+	tests := []struct {
+		name     string
+		taxonomy string
+		code     int
+		err      errHTTP
+		svc      svc
+		wantErr  bool
+	}{
+		{
+			name:     "Repository CSV error",
+			taxonomy: "categories",
+			code:     http.StatusInternalServerError,
+			err:      newTestProblem(repository.CsvErrCode, "Database file error"),
+			svc:      svc{resp: nil, err: &repository.CsvErr{}},
+			wantErr:  true,
+		},
+		{
+			name:     "Not supported",
+			taxonomy: "colors",
+			code:     http.StatusNotFound,
+			wantErr:  true,
+		},
+		{
+			name:     "Glasses",
+			taxonomy: "glasses",
+			code:     http.StatusOK,
+			svc: svc{
+				resp: []ct.TaxonomyValue{
+					{Value: "Martini glass", Normalized: "martini glass", Count: 3, Variants: []string{"Martini Glass"}},
+					{Value: "Shot glass", Normalized: "shot glass", Count: 1},
+				},
+			},
+		},
+		{
+			name:     "Alcoholic types",
+			taxonomy: "alcoholic-types",
+			code:     http.StatusOK,
+			svc: svc{
+				resp: []ct.TaxonomyValue{{Value: "Alcoholic", Normalized: "alcoholic", Count: 3}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewTaxonomySvc()
+			mSvc.On("GetTaxonomy", tt.taxonomy).Return(tt.svc.resp, tt.svc.err)
+			ctrl := Taxonomy{svc: mSvc}
+
+			// Request
+			req, err := http.NewRequest("GET", "/"+tt.taxonomy, nil)
+			require.Nil(t, err)
+
+			// Server instance
+			rr := httptest.NewRecorder()
+			srv := newTestRouter(ctrl)
+			srv.ServeHTTP(rr, req)
+
+			// Tests
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.wantErr {
+				if tt.err.Status != 0 {
+					var errMsg errHTTP
+					require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errMsg))
+					assert.Equal(t, tt.err, errMsg)
+				}
+				return
+			}
+
+			var resp []ct.TaxonomyValue
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.svc.resp, resp)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+var _ HTTP = Webhook{}
+
+// Webhook configures the routes and handler functions managing the webhooks notified of the database events.
+type Webhook struct {
+	svc WebhookSvc
+}
+
+// WebhookSvc is the abstraction of the Webhook service dependency.
+type WebhookSvc interface {
+	Register(url, secret string, events []string) (entity.Webhook, error)
+	GetAll() ([]entity.Webhook, error)
+	Delete(id string) error
+	GetDeliveries(id string, limit int) ([]entity.Delivery, error)
+	GetDeadLetters(id string, limit int) ([]entity.DeadLetter, error)
+}
+
+// NewWebhook returns a new Webhook controller implementation.
+func NewWebhook(svc WebhookSvc) Webhook {
+	return Webhook{
+		svc: svc,
+	}
+}
+
+// SetRoutes sets a fresh middleware stack for the handle functions and mounts the routes in the provided sub router.
+// Managing the webhooks requires the admin role.
+func (wh Webhook) SetRoutes(r chi.Router) {
+	r = r.With(requireRole(ct.AdminRole))
+	r.Post("/webhooks", wh.register)
+	r.Get("/webhooks", wh.getAll)
+	r.Delete("/webhooks/{id}", wh.delete)
+	r.Get("/webhooks/{id}/deliveries", wh.getDeliveries)
+	r.Get("/webhooks/{id}/dead-letters", wh.getDeadLetters)
+}
+
+// Docs returns the description of the Webhook routes.
+func (wh Webhook) Docs() []RouteDoc {
+	limit := []ParamDoc{{Name: limitParam, In: "query", Type: "integer", Desc: "The maximum number of records to retrieve."}}
+	return []RouteDoc{
+		{
+			Method:   http.MethodPost,
+			Pattern:  "/webhooks",
+			Summary:  "Register a webhook",
+			Desc:     "The response holds the secret signing the notifications; it is not retrievable afterwards.",
+			Role:     ct.AdminRole,
+			Body:     webhookRequest{},
+			Status:   http.StatusCreated,
+			Response: entity.Webhook{},
+		},
+		{
+			Method:   http.MethodGet,
+			Pattern:  "/webhooks",
+			Summary:  "List the webhooks",
+			Role:     ct.AdminRole,
+			Response: []entity.Webhook{},
+		},
+		{
+			Method:  http.MethodDelete,
+			Pattern: "/webhooks/{id}",
+			Summary: "Unregister a webhook",
+			Role:    ct.AdminRole,
+			Status:  http.StatusNoContent,
+		},
+		{
+			Method:   http.MethodGet,
+			Pattern:  "/webhooks/{id}/deliveries",
+			Summary:  "Latest deliveries of a webhook",
+			Role:     ct.AdminRole,
+			Params:   limit,
+			Response: []entity.Delivery{},
+		},
+		{
+			Method:   http.MethodGet,
+			Pattern:  "/webhooks/{id}/dead-letters",
+			Summary:  "Undelivered notifications of a webhook",
+			Role:     ct.AdminRole,
+			Params:   limit,
+			Response: []entity.DeadLetter{},
+		},
+	}
+}
+
+// webhookRequest is the JSON request body registering a webhook.
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// register is a handler function that registers a new webhook, responding it in JSON format along with its secret.
+func (wh Webhook) register(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errJSON(w, r, &ParamsErr{fmt.Errorf("%w: %s", ErrInvalidBody, err)})
+		return
+	}
+
+	webhook, err := wh.svc.Register(req.URL, req.Secret, req.Events)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, webhook)
+}
+
+// getAll is a handler function that retrieve the registered webhooks in JSON format, without their secrets.
+func (wh Webhook) getAll(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := wh.svc.GetAll()
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	render.JSON(w, r, webhooks)
+}
+
+// delete is a handler function that unregisters a webhook.
+func (wh Webhook) delete(w http.ResponseWriter, r *http.Request) {
+	if err := wh.svc.Delete(chi.URLParam(r, "id")); err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getDeliveries is a handler function that retrieve the latest deliveries of a webhook in JSON format.
+func (wh Webhook) getDeliveries(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r.URL.Query())
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+
+	deliveries, err := wh.svc.GetDeliveries(chi.URLParam(r, "id"), limit)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	render.JSON(w, r, deliveries)
+}
+
+// getDeadLetters is a handler function that retrieve the latest undelivered notifications of a webhook in JSON format.
+func (wh Webhook) getDeadLetters(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r.URL.Query())
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+
+	letters, err := wh.svc.GetDeadLetters(chi.URLParam(r, "id"), limit)
+	if err != nil {
+		errJSON(w, r, err)
+		return
+	}
+	render.JSON(w, r, letters)
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller/mocks"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ WebhookSvc = &mocks.WebhookSvc{}
+
+func TestWebhook_Register(t *testing.T) {
+	type svc struct {
+		resp entity.Webhook
+		err  error
+	}
+	tests := []struct {
+		name    string
+		body    string
+		code    int
+		err     errHTTP
+		svc     svc
+		wantErr bool
+	}{
+		{
+			name:    "Invalid body",
+			body:    `{"url":`,
+			code:    http.StatusBadRequest,
+			err:     newTestProblem(ParamsErrCode, "request parameters: invalid request body: unexpected EOF"),
+			wantErr: true,
+		},
+		{
+			name:    "Service arguments error",
+			body:    `{"url":"/hook"}`,
+			code:    http.StatusUnprocessableEntity,
+			err:     newTestProblem(service.ArgsErrCode, "service arguments: invalid webhook url, must be an absolute http or https url"),
+			svc:     svc{err: &service.ArgsErr{Err: service.ErrWebhookURLInvalid}},
+			wantErr: true,
+		},
+		{
+			name: "Created",
+			body: `{"url":"https://foo.com/hook","secret":"s3cr3t","events":["sync.completed"]}`,
+			code: http.StatusCreated,
+			svc: svc{
+				resp: entity.Webhook{ID: "a1", URL: "https://foo.com/hook", Secret: "s3cr3t", Events: []string{"sync.completed"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req webhookRequest
+			_ = json.Unmarshal([]byte(tt.body), &req)
+			mSvc := mocks.NewWebhookSvc()
+			mSvc.On("Register", req.URL, req.Secret, req.Events).Return(tt.svc.resp, tt.svc.err)
+			ctrl := NewWebhook(mSvc)
+
+			// Request
+			r, err := http.NewRequest("POST", "/webhooks", strings.NewReader(tt.body))
+			require.Nil(t, err)
+
+			// Server instance
+			rr := httptest.NewRecorder()
+			srv := newTestRouter(ctrl)
+			srv.ServeHTTP(rr, r)
+
+			// Tests
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.wantErr {
+				var errMsg errHTTP
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &errMsg))
+				assert.Equal(t, tt.err, errMsg)
+				return
+			}
+			var resp entity.Webhook
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.svc.resp, resp)
+		})
+	}
+}
+
+func TestWebhook_Delete(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     string
+		svcErr error
+		code   int
+	}{
+		{
+			name:   "Not found",
+			id:     "c3",
+			svcErr: &service.NotFoundErr{Err: service.ErrWebhookNotFound},
+			code:   http.StatusNotFound,
+		},
+		{
+			name: "Deleted",
+			id:   "a1",
+			code: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewWebhookSvc()
+			mSvc.On("Delete", tt.id).Return(tt.svcErr)
+			ctrl := NewWebhook(mSvc)
+
+			r, err := http.NewRequest("DELETE", "/webhooks/"+tt.id, nil)
+			require.Nil(t, err)
+			rr := httptest.NewRecorder()
+			newTestRouter(ctrl).ServeHTTP(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+		})
+	}
+}
+
+func TestWebhook_GetDeliveries(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		code  int
+		resp  []entity.Delivery
+	}{
+		{
+			name:  "Invalid limit",
+			query: "limit=-1",
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:  "Valid",
+			query: "limit=2",
+			limit: 2,
+			code:  http.StatusOK,
+			resp: []entity.Delivery{
+				{ID: "d2", WebhookID: "a1", EventID: 2, EventType: "sync.completed", Status: entity.DeliveryFailed, Attempts: 5, Code: 500, Error: "unexpected response status code 500"},
+				{ID: "d1", WebhookID: "a1", EventID: 1, EventType: "sync.completed", Status: entity.DeliverySucceeded, Attempts: 1, Code: 200},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mSvc := mocks.NewWebhookSvc()
+			mSvc.On("GetDeliveries", "a1", tt.limit).Return(tt.resp, nil)
+			ctrl := NewWebhook(mSvc)
+
+			r, err := http.NewRequest("GET", "/webhooks/a1/deliveries?"+tt.query, nil)
+			require.Nil(t, err)
+			rr := httptest.NewRecorder()
+			newTestRouter(ctrl).ServeHTTP(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.code != http.StatusOK {
+				return
+			}
+			var resp []entity.Delivery
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.resp, resp)
+		})
+	}
+}
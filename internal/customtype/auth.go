@@ -0,0 +1,75 @@
+package customtype
+
+import "context"
+
+const (
+	InvalidRole Role = "invalid"
+	ReaderRole  Role = "reader"
+	EditorRole  Role = "editor"
+	AdminRole   Role = "admin"
+)
+
+// roleLevels rank the roles, so higher roles are granted the permissions of the lower ones.
+var roleLevels = map[Role]int{
+	ReaderRole: 1,
+	EditorRole: 2,
+	AdminRole:  3,
+}
+
+// Role represents the permissions granted to a client. e.g. reader, editor, admin
+type Role string
+
+func (r Role) String() string {
+	return string(r)
+}
+
+// Allows reports whether the role is granted the permissions of the required role.
+func (r Role) Allows(required Role) bool {
+	level, ok := roleLevels[r]
+	return ok && level >= roleLevels[required]
+}
+
+// NewRole returns the Role associated to the given string role.
+// Returns InvalidRole if the role is not supported.
+func NewRole(role string) Role {
+	switch role {
+	case ReaderRole.String():
+		return ReaderRole
+	case EditorRole.String():
+		return EditorRole
+	case AdminRole.String():
+		return AdminRole
+	default:
+		return InvalidRole
+	}
+}
+
+// The methods authenticating a Principal.
+const (
+	AnonymousAuth = "anonymous"
+	APIKeyAuth    = "api_key"
+	JWTAuth       = "jwt"
+)
+
+// Principal is the authenticated client of a request.
+// Name identifies the client for audit logging: the API key name, or the JWT subject.
+type Principal struct {
+	Name   string
+	Role   Role
+	Method string
+	Issuer string
+}
+
+// principalKey is the context key of the request Principal.
+type principalKey struct{}
+
+// WithPrincipal returns a copy of the context holding the given Principal.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the Principal held by the context, if any.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
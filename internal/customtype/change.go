@@ -0,0 +1,11 @@
+package customtype
+
+import "github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+
+// ChangeFeed represents a page of the database change log.
+// NextCursor is the cursor to request the following changes with; HasMore reports whether there are more changes.
+type ChangeFeed struct {
+	Changes    []entity.Change `json:"changes"`
+	NextCursor string          `json:"next_cursor"`
+	HasMore    bool            `json:"has_more"`
+}
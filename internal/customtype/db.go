@@ -1,15 +1,72 @@
 package customtype
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
+
+// The policies of the records removed from the data API, applied by the database updates.
+const (
+	RemovedIgnore  RemovedPolicy = "ignore"
+	RemovedFlag    RemovedPolicy = "flag"
+	RemovedDelete  RemovedPolicy = "delete"
+	InvalidRemoved RemovedPolicy = "invalid"
+)
+
+// RemovedPolicy represents the handling of the records removed from the data API:
+// ignored, flagged as orphaned, or deleted.
+type RemovedPolicy string
+
+// NewRemovedPolicy returns the RemovedPolicy associated to the given string policy.
+// Returns InvalidRemoved if the policy is not supported.
+func NewRemovedPolicy(policy string) RemovedPolicy {
+	switch p := RemovedPolicy(policy); p {
+	case RemovedIgnore, RemovedFlag, RemovedDelete:
+		return p
+	default:
+		return InvalidRemoved
+	}
+}
 
 // DBOpsSummary represents the summary of database operations
+// TxID identifies the sync transaction that wrote the database, whose previous content can be rolled back to.
+// It is empty if the database was not written.
+// RemovedIDs are the IDs of the records removed from the data API, handled by the RemovedPolicy; the flagged records
+// are counted as modified, and the deleted ones as deleted.
 type DBOpsSummary struct {
+	TxID         string    `json:"tx_id,omitempty"`
 	Status       string    `json:"status"`
 	StartTime    time.Time `json:"start_time"`
 	EndTime      time.Time `json:"end_time"`
 	Duration     string    `json:"duration"`
 	NewRecs      int       `json:"new_records"`
 	ModifiedRecs int       `json:"modified_records"`
+	DeletedRecs  int       `json:"deleted_records"`
 	TotalOps     int       `json:"total_operations"`
 	TotalRecs    int       `json:"total_records"`
+
+	RemovedRecs   int           `json:"removed_records"`
+	RemovedIDs    []int         `json:"removed_ids,omitempty"`
+	RemovedPolicy RemovedPolicy `json:"removed_policy,omitempty"`
+}
+
+// CompactSummary represents the summary of a compaction, folding the journaled writes into the database.
+type CompactSummary struct {
+	Status        string    `json:"status"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	Duration      string    `json:"duration"`
+	FoldedEntries int       `json:"folded_entries"`
+}
+
+// VersionConflictErr is the error of a conditional write of a record which is not at the expected version, as it
+// was written since that version was read. Current is the current version of the record, zero if it does not exist.
+type VersionConflictErr struct {
+	ID      int
+	Version int
+	Current int
+}
+
+func (e VersionConflictErr) Error() string {
+	return fmt.Sprintf("record %d at version %d, expected at version %d", e.ID, e.Current, e.Version)
 }
@@ -0,0 +1,12 @@
+package customtype
+
+import "time"
+
+// Event represents a notification of something that happened in the database.
+// ID is unique and grows with every event, so clients can resume a stream from the last event they received.
+type Event struct {
+	ID   int64     `json:"id"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data"`
+}
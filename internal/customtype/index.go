@@ -0,0 +1,20 @@
+package customtype
+
+import "strings"
+
+// The fields of the cocktail records indexed by the repositories supporting it.
+const (
+	NameIndex       IndexField = "name"
+	IngredientIndex IndexField = "ingredient"
+	GlassIndex      IndexField = "glass"
+	CategoryIndex   IndexField = "category"
+)
+
+// IndexField represents an indexed field of the cocktail records.
+type IndexField string
+
+// IndexKey returns the normalized index key of the given field value, its lower case form, so the lookups are
+// case-insensitive.
+func IndexKey(value string) string {
+	return strings.ToLower(value)
+}
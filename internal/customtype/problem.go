@@ -0,0 +1,109 @@
+package customtype
+
+import "errors"
+
+// ProblemType describes a class of errors, responded as RFC 7807 problem details.
+// Code is the stable, machine-readable identifier of the class; clients rely on it rather than on the messages.
+type ProblemType struct {
+	Code   string
+	Title  string
+	Status int
+	Match  func(error) bool
+}
+
+// ProblemRegistry is the catalog of the ProblemType each layer contributes to.
+// The errors are matched in registration order, so the specific types must be registered first.
+type ProblemRegistry struct {
+	types []ProblemType
+}
+
+// NewProblemRegistry returns a new empty ProblemRegistry.
+func NewProblemRegistry() *ProblemRegistry {
+	return &ProblemRegistry{}
+}
+
+// Register appends the given problem types to the catalog.
+func (r *ProblemRegistry) Register(types ...ProblemType) {
+	r.types = append(r.types, types...)
+}
+
+// Match returns the first registered ProblemType matching the given error.
+func (r *ProblemRegistry) Match(err error) (ProblemType, bool) {
+	for _, t := range r.types {
+		if t.Match(err) {
+			return t, true
+		}
+	}
+	return ProblemType{}, false
+}
+
+// Get returns the registered ProblemType of the given code.
+func (r *ProblemRegistry) Get(code string) (ProblemType, bool) {
+	for _, t := range r.types {
+		if t.Code == code {
+			return t, true
+		}
+	}
+	return ProblemType{}, false
+}
+
+// Types returns the registered problem types, in registration order.
+func (r *ProblemRegistry) Types() []ProblemType {
+	return append([]ProblemType(nil), r.types...)
+}
+
+// MatchAs returns a matcher of the errors whose chain holds an error of type T.
+func MatchAs[T error]() func(error) bool {
+	return func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}
+}
+
+// MatchIs returns a matcher of the errors whose chain holds the given target error.
+func MatchIs(target error) func(error) bool {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
+
+// FieldErr is the error of a single input field, e.g. a query parameter or a request body field.
+// It is transparent: its message is the one of the wrapped error.
+type FieldErr struct {
+	Field string
+	Err   error
+}
+
+func (e FieldErr) Error() string {
+	return e.Err.Error()
+}
+
+func (e FieldErr) Unwrap() error {
+	return e.Err
+}
+
+// FieldErrors returns all the FieldErr in the chain of the given error, including the joined errors.
+func FieldErrors(err error) []FieldErr {
+	fields := make([]FieldErr, 0)
+	var walk func(error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case nil:
+			return
+		case *FieldErr:
+			fields = append(fields, *e)
+		case FieldErr:
+			fields = append(fields, e)
+		}
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			walk(u.Unwrap())
+		case interface{ Unwrap() []error }:
+			for _, e := range u.Unwrap() {
+				walk(e)
+			}
+		}
+	}
+	walk(err)
+	return fields
+}
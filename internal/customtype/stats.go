@@ -0,0 +1,26 @@
+package customtype
+
+// CocktailStats represents the aggregate statistics of a set of cocktail records.
+type CocktailStats struct {
+	TotalRecs               int                `json:"total_records"`
+	Categories              []FacetCount       `json:"categories"`
+	Glasses                 []FacetCount       `json:"glasses"`
+	Alcoholic               []FacetCount       `json:"alcoholic"`
+	IBA                     []FacetCount       `json:"iba"`
+	Tags                    []FacetCount       `json:"tags"`
+	TopIngredients          []FacetCount       `json:"top_ingredients"`
+	IngredientsDistribution []IngredientsCount `json:"ingredients_distribution"`
+	IngredientsAvg          float64            `json:"ingredients_average"`
+}
+
+// FacetCount is the number of records holding a value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// IngredientsCount is the number of records made of a given number of ingredients.
+type IngredientsCount struct {
+	Ingredients int `json:"ingredients"`
+	Count       int `json:"count"`
+}
@@ -0,0 +1,8 @@
+package customtype
+
+// Suggestion represents a "did you mean" alternative for a filter value that had no exact matches.
+type Suggestion struct {
+	Value   string  `json:"value"`
+	Score   float64 `json:"score"`
+	Matches int     `json:"matches"`
+}
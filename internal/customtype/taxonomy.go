@@ -0,0 +1,10 @@
+package customtype
+
+// TaxonomyValue represents a distinct value of a cocktail property, such as a category or a glass.
+// Value is the canonical spelling, the most common one, while Variants holds the rest of the spellings found.
+type TaxonomyValue struct {
+	Value      string   `json:"value"`
+	Normalized string   `json:"normalized"`
+	Count      int      `json:"count"`
+	Variants   []string `json:"variants,omitempty"`
+}
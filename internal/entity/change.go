@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// The change types of the database records.
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+
+	// The revision history holds the restored and purged tombstones too.
+	ChangeRestored ChangeType = "restored"
+	ChangePurged   ChangeType = "purged"
+)
+
+// ChangeType represents the kind of change made to a database record.
+type ChangeType string
+
+// Change is an entry of the database change log.
+// Seq is the position of the change in the log, which grows with every change.
+// Deleted records are tombstones holding no Cocktail, just its ID.
+type Change struct {
+	Seq      int64      `json:"seq"`
+	Type     ChangeType `json:"type"`
+	ID       int        `json:"id"`
+	Time     time.Time  `json:"time"`
+	Cocktail *Cocktail  `json:"cocktail,omitempty"`
+}
@@ -2,7 +2,17 @@ package entity
 
 import "time"
 
+// The origins of the database records.
+// The upstream records come from the data API, so they are kept in sync with it; the local ones do not.
+const (
+	OriginUpstream = "upstream"
+	OriginLocal    = "local"
+)
+
 // Cocktail is the representation of a Cocktail recipe used to hold the business logic.
+// Version is the revision number of the record, incremented on every write, so concurrent writes can be detected.
+// DeletedAt is set when the record is deleted: deleted records are kept as tombstones until purged.
+// Origin tells where the record comes from, and OrphanedAt is set when an upstream record is no longer found upstream.
 type Cocktail struct {
 	ID             int          `json:"id"`
 	Name           string       `json:"name"`
@@ -14,17 +24,38 @@ type Cocktail struct {
 	IBA            string       `json:"iba"`
 	ImgAttribution string       `json:"image_attribution"`
 	ImgSrc         string       `json:"image_source"`
-	Tags           string       `json:"tags"`
+	Tags           []string     `json:"tags"`
 	Thumb          string       `json:"thumb"`
 	Video          string       `json:"video"`
 
-	SrcDate   time.Time `json:"source_date"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	SrcDate   time.Time  `json:"source_date"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Version   int        `json:"version"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	Origin     string     `json:"origin"`
+	OrphanedAt *time.Time `json:"orphaned_at,omitempty"`
+}
+
+// Deleted reports whether the record is a tombstone.
+func (c Cocktail) Deleted() bool {
+	return c.DeletedAt != nil
 }
 
 // Ingredient provides the ingredient name and its measure.
 type Ingredient struct {
-	Name    string `json:"name"`
-	Measure string `json:"measure"`
+	Name    string `json:"name" xml:"name"`
+	Measure string `json:"measure" xml:"measure"`
+}
+
+// Upstream reports whether the record comes from the data API.
+// The records of no origin were written before it was tracked, when the data API was the only source of records.
+func (c Cocktail) Upstream() bool {
+	return c.Origin == OriginUpstream || c.Origin == ""
+}
+
+// Orphaned reports whether the upstream record is no longer found upstream.
+func (c Cocktail) Orphaned() bool {
+	return c.OrphanedAt != nil
 }
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// The sources of the database writes.
+// The import source dates the records written before the revision history was kept, and the recovery source the
+// writes a crash left out of the logs.
+const (
+	SourceImport   = "import"
+	SourceSync     = "sync"
+	SourceEdit     = "edit"
+	SourceRollback = "rollback"
+	SourceRecovery = "recovery"
+)
+
+// Author identifies who wrote a database record: Actor is the name of the client, empty for the system,
+// and Source the kind of write.
+type Author struct {
+	Actor  string `json:"actor,omitempty"`
+	Source string `json:"source"`
+}
+
+// Revision is an entry of the revision history of a database record.
+// Seq is the position of the revision in the history of all the records, which grows with every revision.
+// Diff holds the fields changed by an update, and Cocktail the record content after the change;
+// deleted records hold no content, just their ID.
+type Revision struct {
+	Seq     int64      `json:"seq"`
+	ID      int        `json:"id"`
+	Version int        `json:"version"`
+	Type    ChangeType `json:"type"`
+	Time    time.Time  `json:"time"`
+	Author
+	Diff     []FieldDiff `json:"diff,omitempty"`
+	Cocktail *Cocktail   `json:"cocktail,omitempty"`
+}
+
+// FieldDiff is the change of a single record field, holding its JSON values before and after the change.
+type FieldDiff struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
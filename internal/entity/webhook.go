@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// The delivery statuses of the webhook notifications.
+const (
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Webhook is a URL registered to be notified of the database events.
+// Secret is the key used to sign the notifications. Events are the notified event types; empty notifies all of them.
+type Webhook struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DeliveryStatus represents the outcome of a webhook notification.
+type DeliveryStatus string
+
+// Delivery is the record of a webhook notification, after all its attempts.
+// Code is the response status code of the last attempt, and Error its failure reason, if any.
+type Delivery struct {
+	ID        string         `json:"id"`
+	WebhookID string         `json:"webhook_id"`
+	EventID   int64          `json:"event_id"`
+	EventType string         `json:"event_type"`
+	Status    DeliveryStatus `json:"status"`
+	Attempts  int            `json:"attempts"`
+	Code      int            `json:"code,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	Time      time.Time      `json:"time"`
+}
+
+// DeadLetter is a webhook notification that could not be delivered, kept along with its payload.
+type DeadLetter struct {
+	Delivery
+	URL     string          `json:"url"`
+	Payload json.RawMessage `json:"payload"`
+}
@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+)
+
+// CocktailCache represents the Cocktail repository caching the CSV database in memory.
+// The records are loaded once, along with their indexes, and loaded again once the database is written, either
+// through the repository or by another process, detected by the modification time and size of its files.
+// The methods not cached are the ones of the Cocktail repository.
+type CocktailCache struct {
+	Cocktail
+	cache *cocktailCache
+}
+
+// NewCocktailCache returns a new CocktailCache repository implementation, caching the given Cocktail repository.
+func NewCocktailCache(repo Cocktail) CocktailCache {
+	return CocktailCache{
+		Cocktail: repo,
+		cache:    &cocktailCache{},
+	}
+}
+
+// cocktailCache holds the loaded records, shared by the copies of the CocktailCache repository.
+// A load in progress is shared by the concurrent reads, so a cold cache is loaded once.
+// gen counts the invalidations, so a load started before one is not cached.
+type cocktailCache struct {
+	mu      sync.Mutex
+	loaded  *cocktailIndex
+	loading *cacheLoad
+	gen     int
+}
+
+// cacheLoad is a load of the cache in progress, done once its done channel is closed.
+type cacheLoad struct {
+	done  chan struct{}
+	index *cocktailIndex
+	err   error
+}
+
+// cocktailIndex holds the records of the CSV database as of the stamps of its files, indexed.
+// recs holds all the records in file order, tombstones included, and live the rest; byID and byField hold the
+// positions of the live records, by ID and by the index key of their indexed fields.
+type cocktailIndex struct {
+	stamps  []fileStamp
+	recs    []entity.Cocktail
+	live    []entity.Cocktail
+	byID    map[int]int
+	byField map[ct.IndexField]map[string][]int
+}
+
+// fileStamp identifies a version of a file by its modification time and size.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileStamps returns the stamps of the given files.
+func fileStamps(names []string) ([]fileStamp, error) {
+	stamps := make([]fileStamp, 0, len(names))
+	for _, name := range names {
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		stamps = append(stamps, fileStamp{modTime: info.ModTime(), size: info.Size()})
+	}
+	return stamps, nil
+}
+
+// newCocktailIndex returns the index of the given records, read from files of the given stamps.
+func newCocktailIndex(stamps []fileStamp, recs []entity.Cocktail) *cocktailIndex {
+	idx := &cocktailIndex{
+		stamps: stamps,
+		recs:   recs,
+		live:   make([]entity.Cocktail, 0, len(recs)),
+		byID:   make(map[int]int, len(recs)),
+		byField: map[ct.IndexField]map[string][]int{
+			ct.NameIndex:       {},
+			ct.IngredientIndex: {},
+			ct.GlassIndex:      {},
+			ct.CategoryIndex:   {},
+		},
+	}
+	for _, rec := range recs {
+		if rec.Deleted() {
+			continue
+		}
+		pos := len(idx.live)
+		idx.live = append(idx.live, rec)
+		idx.byID[rec.ID] = pos
+		idx.add(ct.NameIndex, rec.Name, pos)
+		idx.add(ct.GlassIndex, rec.Glass, pos)
+		idx.add(ct.CategoryIndex, rec.Category, pos)
+		for _, ingr := range rec.Ingredients {
+			idx.add(ct.IngredientIndex, ingr.Name, pos)
+		}
+	}
+	return idx
+}
+
+// add indexes the live record at the given position by the given field value. Empty values are not indexed.
+func (idx *cocktailIndex) add(field ct.IndexField, value string, pos int) {
+	if value == "" {
+		return
+	}
+	key := ct.IndexKey(value)
+	positions := idx.byField[field][key]
+	if n := len(positions); n > 0 && positions[n-1] == pos {
+		return
+	}
+	idx.byField[field][key] = append(positions, pos)
+}
+
+// fresh reports whether the index still holds the content of the files of the given stamps.
+func (idx *cocktailIndex) fresh(stamps []fileStamp) bool {
+	if len(idx.stamps) != len(stamps) {
+		return false
+	}
+	for i, stamp := range stamps {
+		if !idx.stamps[i].modTime.Equal(stamp.modTime) || idx.stamps[i].size != stamp.size {
+			return false
+		}
+	}
+	return true
+}
+
+// invalidate drops the loaded records, so they are loaded again on the next read.
+func (cc *cocktailCache) invalidate() {
+	cc.mu.Lock()
+	cc.loaded = nil
+	cc.gen++
+	cc.mu.Unlock()
+}
+
+// index returns the index of the current content of the CSV database, loading it if the cached one is stale.
+// Returns a CsvErr if the file can not be read.
+func (c CocktailCache) index() (*cocktailIndex, error) {
+	file := c.csv.FilePath()
+	stamps, err := fileStamps(c.dbFiles())
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("CocktailCache: stat csv files failed")
+		return nil, &CsvErr{err}
+	}
+
+	cc := c.cache
+	cc.mu.Lock()
+	if cc.loaded != nil && cc.loaded.fresh(stamps) {
+		idx := cc.loaded
+		cc.mu.Unlock()
+		return idx, nil
+	}
+	if load := cc.loading; load != nil {
+		cc.mu.Unlock()
+		<-load.done
+		return load.index, load.err
+	}
+	load := &cacheLoad{done: make(chan struct{})}
+	cc.loading = load
+	gen := cc.gen
+	cc.mu.Unlock()
+
+	// The files are stated before being read, so a write in between leaves the index stale rather than wrongly fresh.
+	// The cached checksum is dropped too, as the file may have been written by another process.
+	c.checksum.invalidate()
+	recs, err := c.Cocktail.ReadAllWithDeleted()
+	if err == nil {
+		load.index = newCocktailIndex(stamps, recs)
+		logger.Log().Debug().Str("file", file).Int("records", len(recs)).Msg("CocktailCache: csv file loaded")
+	}
+	load.err = err
+
+	cc.mu.Lock()
+	cc.loading = nil
+	if err == nil && gen == cc.gen {
+		cc.loaded = load.index
+	}
+	cc.mu.Unlock()
+	close(load.done)
+	return load.index, load.err
+}
+
+// ReadAll returns all entity.Cocktail records from the cache, but the deleted ones.
+// The records are copied, so the caller can modify the list.
+func (c CocktailCache) ReadAll() ([]entity.Cocktail, error) {
+	idx, err := c.index()
+	if err != nil {
+		return nil, err
+	}
+	return append(make([]entity.Cocktail, 0, len(idx.live)), idx.live...), nil
+}
+
+// ReadAllWithDeleted returns all entity.Cocktail records from the cache, including the deleted ones.
+// The records are copied, so the caller can modify the list.
+func (c CocktailCache) ReadAllWithDeleted() ([]entity.Cocktail, error) {
+	idx, err := c.index()
+	if err != nil {
+		return nil, err
+	}
+	return append(make([]entity.Cocktail, 0, len(idx.recs)), idx.recs...), nil
+}
+
+// Stream calls fn with each entity.Cocktail record of the cache, in file order, but the deleted ones.
+// Reading stops when the context is done, returning its error, or when fn fails, returning the fn error.
+func (c CocktailCache) Stream(ctx context.Context, fn func(entity.Cocktail) error) error {
+	idx, err := c.index()
+	if err != nil {
+		return err
+	}
+	for _, rec := range idx.live {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadByID returns the entity.Cocktail record of the given ID from the cache, and whether it exists.
+// The deleted records do not exist.
+func (c CocktailCache) ReadByID(id int) (entity.Cocktail, bool, error) {
+	idx, err := c.index()
+	if err != nil {
+		return entity.Cocktail{}, false, err
+	}
+	pos, found := idx.byID[id]
+	if !found {
+		return entity.Cocktail{}, false, nil
+	}
+	return idx.live[pos], true, nil
+}
+
+// ReadIndexed returns the entity.Cocktail records of the cache whose given field has an index key satisfying match,
+// in file order, but the deleted ones. The distinct keys are matched rather than the records, so the lookups are
+// cheaper than scanning them. See ct.IndexKey.
+func (c CocktailCache) ReadIndexed(field ct.IndexField, match func(key string) bool) ([]entity.Cocktail, error) {
+	idx, err := c.index()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	positions := make([]int, 0)
+	for key, keyPositions := range idx.byField[field] {
+		if !match(key) {
+			continue
+		}
+		for _, pos := range keyPositions {
+			if !seen[pos] {
+				seen[pos] = true
+				positions = append(positions, pos)
+			}
+		}
+	}
+	sort.Ints(positions)
+
+	cocktails := make([]entity.Cocktail, 0, len(positions))
+	for _, pos := range positions {
+		cocktails = append(cocktails, idx.live[pos])
+	}
+	return cocktails, nil
+}
+
+// Checksum returns the hex encoded SHA-256 hash of the CSV database file content. See Cocktail.Checksum.
+// The cache is checked first, so the checksum is computed again once the file was written by another process.
+func (c CocktailCache) Checksum() (string, error) {
+	if _, err := c.index(); err != nil {
+		return "", err
+	}
+	return c.Cocktail.Checksum()
+}
+
+// ReplaceDB replaces the database entirely with the given entity.Cocktail records, and invalidates the cache.
+// See Cocktail.ReplaceDB.
+func (c CocktailCache) ReplaceDB(cocktails []entity.Cocktail, author entity.Author) error {
+	// Invalidated even on failure, as the file may have been written partially.
+	defer c.cache.invalidate()
+	return c.Cocktail.ReplaceDB(cocktails, author)
+}
+
+// ReplaceDBFunc replaces the database with the entity.Cocktail records returned by fn, and invalidates the cache.
+// fn is given the records of the file rather than the cached ones. See Cocktail.ReplaceDBFunc.
+func (c CocktailCache) ReplaceDBFunc(txID string, author entity.Author, fn func([]entity.Cocktail) ([]entity.Cocktail, error)) error {
+	defer c.cache.invalidate()
+	return c.Cocktail.ReplaceDBFunc(txID, author, fn)
+}
+
+// PutRecord writes the given entity.Cocktail record, and invalidates the cache. See Cocktail.PutRecord.
+func (c CocktailCache) PutRecord(rec entity.Cocktail, version int, author entity.Author) error {
+	defer c.cache.invalidate()
+	return c.Cocktail.PutRecord(rec, version, author)
+}
+
+// PurgeRecord removes the entity.Cocktail record of the given ID for good, and invalidates the cache.
+// See Cocktail.PurgeRecord.
+func (c CocktailCache) PurgeRecord(id, version int, author entity.Author) error {
+	defer c.cache.invalidate()
+	return c.Cocktail.PurgeRecord(id, version, author)
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+)
+
+// changeLogSuffix is appended to the CSV database file name, without extension, to name its change log file.
+// e.g. the change log of "cocktails.csv" is "cocktails_changes.jsonl"
+const changeLogSuffix = "_changes.jsonl"
+
+// changeLogPath returns the full path of the change log file, which lives next to the CSV database file.
+// The change log holds an entity.Change per line in JSON format, in sequence order.
+func (c Cocktail) changeLogPath() string {
+	return c.sidePath(changeLogSuffix)
+}
+
+// sidePath returns the full path of a file living next to the CSV database file, named after it with
+// the given suffix in place of its extension.
+func (c Cocktail) sidePath(suffix string) string {
+	name := c.csv.FileName()
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	return filepath.Join(c.csv.DataDir(), name+suffix)
+}
+
+// ReadChanges returns the changes of the change log with a sequence number greater than since, in sequence order.
+// At most limit changes are returned; a zero limit returns all of them.
+// If the change log does not exist yet, an empty list is returned.
+func (c Cocktail) ReadChanges(since int64, limit int) ([]entity.Change, error) {
+	changes := make([]entity.Change, 0)
+	err := c.scanChanges(func(change entity.Change) bool {
+		if change.Seq > since {
+			changes = append(changes, change)
+		}
+		return limit <= 0 || len(changes) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// scanChanges reads the change log and calls fn with each change, until fn returns false.
+// Malformed changes are skipped.
+func (c Cocktail) scanChanges(fn func(entity.Change) bool) error {
+	file := c.changeLogPath()
+	err := scanJSONLines(file, func(line []byte) bool {
+		var change entity.Change
+		if err := json.Unmarshal(line, &change); err != nil {
+			logger.Log().Warn().Err(err).Str("file", file).Msg("scanChanges: parsing change failed, skipped")
+			return true
+		}
+		return fn(change)
+	})
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("scanChanges: read change log failed")
+		return &ChangeLogErr{err}
+	}
+	return nil
+}
+
+// loggedRecords returns the live records as the change log holds them, replaying it, in creation order.
+func (c Cocktail) loggedRecords() ([]entity.Cocktail, error) {
+	state := make(map[int]*entity.Cocktail)
+	order := make([]int, 0)
+	err := c.scanChanges(func(change entity.Change) bool {
+		if _, found := state[change.ID]; !found {
+			order = append(order, change.ID)
+		}
+		state[change.ID] = change.Cocktail
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return liveRecords(order, state), nil
+}
+
+// liveRecords returns the records of the given state in the given ID order, but the ones missing.
+func liveRecords(order []int, state map[int]*entity.Cocktail) []entity.Cocktail {
+	recs := make([]entity.Cocktail, 0, len(order))
+	for _, id := range order {
+		if rec := state[id]; rec != nil {
+			recs = append(recs, *rec)
+		}
+	}
+	return recs
+}
+
+// lastChangeSeq returns the sequence number of the last change in the change log, or zero if it is empty.
+// The change log is read from its end, so its size does not matter.
+func (c Cocktail) lastChangeSeq() (int64, error) {
+	file := c.changeLogPath()
+	var seq int64
+	err := scanJSONLinesReverse(file, func(line []byte) bool {
+		var change entity.Change
+		if err := json.Unmarshal(line, &change); err != nil {
+			logger.Log().Warn().Err(err).Str("file", file).Msg("lastChangeSeq: parsing change failed, skipped")
+			return true
+		}
+		seq = change.Seq
+		return false
+	})
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("lastChangeSeq: read change log failed")
+		return 0, &ChangeLogErr{err}
+	}
+	return seq, nil
+}
+
+// appendChanges appends the given changes to the change log, numbering them after the given sequence number of the
+// last logged change. See lastChangeSeq.
+func (c Cocktail) appendChanges(seq int64, changes []entity.Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	for i := range changes {
+		seq++
+		changes[i].Seq = seq
+	}
+
+	if err := appendJSONLines(c.changeLogPath(), changes); err != nil {
+		logger.Log().Error().Err(err).Str("file", c.changeLogPath()).Msg("appendChanges: write change log failed")
+		return &ChangeLogErr{err}
+	}
+	return nil
+}
+
+// diffChanges returns the changes that turn the old records into the new ones, with no sequence numbers:
+// the new records missing in the old ones are created, the ones whose Version or UpdatedAt date differs are updated,
+// and the old records missing in the new ones are deleted.
+// The tombstones count as missing records: deleting a record logs it as deleted, restoring it logs it as created
+// again, and purging it logs nothing, as it was deleted already.
+// The changes are dated by the CreatedAt, UpdatedAt or DeletedAt dates of the records; deletions of removed records
+// are dated at the given time.
+func diffChanges(oldRecs, newRecs []entity.Cocktail, now time.Time) []entity.Change {
+	old := make(map[int]entity.Cocktail, len(oldRecs))
+	for _, rec := range oldRecs {
+		old[rec.ID] = rec
+	}
+
+	changes := make([]entity.Change, 0)
+	kept := make(map[int]bool, len(newRecs))
+	for _, rec := range newRecs {
+		rec := rec
+		kept[rec.ID] = true
+		prev, found := old[rec.ID]
+		switch {
+		case rec.Deleted():
+			if found && !prev.Deleted() {
+				changes = append(changes, entity.Change{Type: entity.ChangeDeleted, ID: rec.ID, Time: changeTime(*rec.DeletedAt, now)})
+			}
+		case !found:
+			changes = append(changes, entity.Change{Type: entity.ChangeCreated, ID: rec.ID, Time: changeTime(rec.CreatedAt, now), Cocktail: &rec})
+		case prev.Deleted():
+			changes = append(changes, entity.Change{Type: entity.ChangeCreated, ID: rec.ID, Time: changeTime(rec.UpdatedAt, now), Cocktail: &rec})
+		case prev.Version != rec.Version || !prev.UpdatedAt.Equal(rec.UpdatedAt):
+			changes = append(changes, entity.Change{Type: entity.ChangeUpdated, ID: rec.ID, Time: changeTime(rec.UpdatedAt, now), Cocktail: &rec})
+		}
+	}
+	for _, rec := range oldRecs {
+		if !kept[rec.ID] && !rec.Deleted() {
+			changes = append(changes, entity.Change{Type: entity.ChangeDeleted, ID: rec.ID, Time: now})
+		}
+	}
+	return changes
+}
+
+// changeTime returns the given record date, or now if it is not set.
+func changeTime(date, now time.Time) time.Time {
+	if date.IsZero() {
+		return now
+	}
+	return date
+}
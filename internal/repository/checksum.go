@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+)
+
+// checksumCache holds the checksum of the CSV database file, until the database is written.
+// It is shared by the copies of the Cocktail repository.
+type checksumCache struct {
+	mu  sync.Mutex
+	sum string
+}
+
+// invalidate drops the cached checksum, so it is computed again on the next read.
+func (cc *checksumCache) invalidate() {
+	if cc == nil {
+		return
+	}
+	cc.mu.Lock()
+	cc.sum = ""
+	cc.mu.Unlock()
+}
+
+// Checksum returns the hex encoded SHA-256 hash of the CSV database file content, followed by its journal if any.
+// It changes whenever the database content does, so it identifies a version of the database.
+// The checksum is computed once and cached until ReplaceDB writes the database.
+// The shared lock is taken before the cache, as ReplaceDB invalidates the cache holding the exclusive lock.
+func (c Cocktail) Checksum() (string, error) {
+	unlock, err := c.rlock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	if c.checksum != nil {
+		c.checksum.mu.Lock()
+		defer c.checksum.mu.Unlock()
+		if c.checksum.sum != "" {
+			return c.checksum.sum, nil
+		}
+	}
+
+	sum, err := fileChecksum(c.dbFiles()...)
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", c.csv.FilePath()).Msg("Checksum: hash csv file failed")
+		return "", &CsvErr{err}
+	}
+	if c.checksum != nil {
+		c.checksum.sum = sum
+	}
+	return sum, nil
+}
+
+// LastModified returns the latest modification time of the CSV database file and its journal, if any.
+// It changes with every database write, purges included, so it dates the database content as a whole.
+func (c Cocktail) LastModified() (time.Time, error) {
+	unlock, err := c.rlock()
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer unlock()
+
+	stamps, err := fileStamps(c.dbFiles())
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", c.csv.FilePath()).Msg("LastModified: stat csv file failed")
+		return time.Time{}, &CsvErr{err}
+	}
+	var last time.Time
+	for _, stamp := range stamps {
+		if stamp.modTime.After(last) {
+			last = stamp.modTime
+		}
+	}
+	return last, nil
+}
+
+// fileChecksum returns the hex encoded SHA-256 hash of the given files content, in order.
+func fileChecksum(names ...string) (string, error) {
+	h := sha256.New()
+	for _, name := range names {
+		if err := hashFile(h, name); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashFile writes the given file content to the given hash.
+func hashFile(h hash.Hash, name string) error {
+	fd, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := fd.Close(); err != nil {
+			logger.Log().Error().Err(err).Str("file", name).Msg("hashFile: close file failed")
+		}
+	}()
+	_, err = io.Copy(h, fd)
+	return err
+}
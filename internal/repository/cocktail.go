@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"bytes"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
@@ -8,7 +10,9 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/config"
 	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
@@ -21,9 +25,14 @@ type Cocktail struct {
 	csv        config.CsvDB
 	dataAPI    config.DataAPI
 	httpClient HttpClient
+	checksum   *checksumCache
+	journal    *journalState
 }
 
 // NewCocktail returns a new Cocktail repository implementation.
+// In journal mode, the journal left by the previous run is replayed first. See recoverJournal.
+// Then the writes a crash left out of the change and revision logs are logged. See reconcileLogs.
+// Returns a CsvErr if the configured mode is not supported.
 func NewCocktail(cfg config.Config) (Cocktail, error) {
 	dataAPI := cfg.HTTP.DataAPI
 	csvDB := cfg.Database.Csv
@@ -34,53 +43,150 @@ func NewCocktail(cfg config.Config) (Cocktail, error) {
 		return Cocktail{}, &CsvErr{err}
 	}
 
+	repo := Cocktail{
+		csv:        csvDB,
+		dataAPI:    dataAPI,
+		httpClient: &http.Client{},
+		checksum:   &checksumCache{},
+		journal:    &journalState{},
+	}
+	if err := repo.checkMode(); err != nil {
+		return Cocktail{}, err
+	}
+	if repo.journaled() {
+		if err := repo.recoverJournal(); err != nil {
+			return Cocktail{}, err
+		}
+	}
+	if err := repo.reconcileLogs(); err != nil {
+		return Cocktail{}, err
+	}
+
 	logger.Log().Debug().
 		Str("csv_file", csvDB.FilePath()).
 		Str("data_api", dataAPI.URL()).
+		Str("mode", csvDB.Mode()).
 		Msg("created Cocktail repository")
-	return Cocktail{
-		csv:        csvDB,
-		dataAPI:    dataAPI,
-		httpClient: &http.Client{},
-	}, nil
+	return repo, nil
 }
 
-// ReadAll returns all entity.Cocktail records from the CSV data file.
+// ReadAll returns all entity.Cocktail records from the CSV data file, but the deleted ones.
+// The file is read under the shared lock, so no write is seen half done.
 func (c Cocktail) ReadAll() ([]entity.Cocktail, error) {
+	unlock, err := c.rlock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return c.readAll(false)
+}
+
+// ReadAllWithDeleted returns all entity.Cocktail records from the CSV data file, including the deleted ones.
+// The writes read the records this way, so the tombstones are written back.
+// The file is read under the shared lock, so no write is seen half done.
+func (c Cocktail) ReadAllWithDeleted() ([]entity.Cocktail, error) {
+	unlock, err := c.rlock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return c.readAll(true)
+}
+
+// readAll returns the entity.Cocktail records from the CSV data file, including the deleted ones if withDeleted is set.
+// In journal mode, the journal is applied to the records.
+func (c Cocktail) readAll(withDeleted bool) ([]entity.Cocktail, error) {
+	if c.journaled() {
+		return c.readJournaled(withDeleted)
+	}
+	cocktails := make([]entity.Cocktail, 0)
+	err := c.stream(context.Background(), withDeleted, func(cocktail entity.Cocktail) error {
+		cocktails = append(cocktails, cocktail)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cocktails, nil
+}
+
+// Stream reads the CSV data file record by record and calls fn with each valid entity.Cocktail, in file order.
+// Unlike ReadAll, the records are not held in memory, so memory stays flat regardless of the database size.
+// Reading stops when the context is done, returning its error, or when fn fails, returning the fn error.
+// The deleted records are skipped. The shared lock is held until reading stops, so fn must not write the database,
+// and must bound the time it blocks, as the writes wait for it.
+// In journal mode, the journal is applied to the whole file, so the records are read at once under the shared lock,
+// and fn is called once it is released.
+func (c Cocktail) Stream(ctx context.Context, fn func(entity.Cocktail) error) error {
+	if !c.journaled() {
+		unlock, err := c.rlock()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		return c.stream(ctx, false, fn)
+	}
+
+	recs, err := c.ReadAll()
+	if err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stream is Stream, calling fn with the deleted records too if withDeleted is set.
+func (c Cocktail) stream(ctx context.Context, withDeleted bool, fn func(entity.Cocktail) error) error {
 	fd, err := os.Open(c.csv.FilePath())
 	if err != nil {
-		logger.Log().Error().Err(err).Str("file", c.csv.FilePath()).Msg("ReadAll: open csv file failed")
-		return nil, &CsvErr{err}
+		logger.Log().Error().Err(err).Str("file", c.csv.FilePath()).Msg("Stream: open csv file failed")
+		return &CsvErr{err}
 	}
 	defer func() {
 		if err := fd.Close(); err != nil {
-			logger.Log().Error().Err(err).Str("file", c.csv.FilePath()).Msg("ReadAll: close csv file failed")
+			logger.Log().Error().Err(err).Str("file", c.csv.FilePath()).Msg("Stream: close csv file failed")
 		}
 	}()
 
 	reader := csv.NewReader(fd)
 	reader.TrimLeadingSpace = true
-	cocktails := make([]entity.Cocktail, 0)
+	reader.ReuseRecord = true
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var rec cocktailCsvRec
 		rec, err = reader.Read()
 		if errors.Is(err, io.EOF) {
 			break
 		}
 		if err != nil {
-			logger.Log().Warn().Err(err).Msg("ReadAll: read record failed, skipped")
+			logger.Log().Warn().Err(err).Msg("Stream: read record failed, skipped")
 			continue
 		}
 
 		cocktail, err := rec.parse()
 		if err != nil {
-			logger.Log().Error().Err(err).Str("record", strings.Join(rec[:], ",")).Msg("ReadAll: parsing record failed, skipped")
+			logger.Log().Error().Err(err).Str("record", strings.Join(rec[:], ",")).Msg("Stream: parsing record failed, skipped")
 			continue
 		}
-		cocktails = append(cocktails, cocktail)
+		if cocktail.Deleted() && !withDeleted {
+			continue
+		}
+		if err := fn(cocktail); err != nil {
+			return err
+		}
 	}
 
-	return cocktails, nil
+	return nil
 }
 
 // ReadCC reads n number of csv records concurrently and returns a list of entity.Cocktail.
@@ -88,18 +194,40 @@ func (c Cocktail) ReadAll() ([]entity.Cocktail, error) {
 // nType: Is the number type. e.g. odd,even,...
 // maxJobs: is the amount of valid csv records to be processed.
 // jWorker: is the amount of jobs that each worker performs.
+// The deleted records are skipped, as the invalid ones are. The file is read under the shared lock.
 func (c Cocktail) ReadCC(nType ct.NumberType, maxJobs, jWorker int) ([]entity.Cocktail, error) {
-	fd, err := os.Open(c.csv.FilePath())
+	unlock, err := c.rlock()
 	if err != nil {
-		logger.Log().Error().Err(err).Str("file", c.csv.FilePath()).Msg("ReadCC: open csv file failed")
-		return nil, &CsvErr{err}
+		return nil, err
 	}
-	defer func() {
-		if err := fd.Close(); err != nil {
-			logger.Log().Error().Err(err).Str("file", c.csv.FilePath()).Msg("ReadCC: close csv file failed")
+	defer unlock()
+
+	var src io.Reader
+	if c.journaled() {
+		// The records with the journal applied are read from memory, in the CSV format.
+		recs, err := c.readJournaled(true)
+		if err != nil {
+			return nil, err
 		}
-	}()
-	reader := csv.NewReader(fd)
+		buf := &bytes.Buffer{}
+		if _, err := writeCsvRecs(csv.NewWriter(buf), recs); err != nil {
+			return nil, &CsvErr{err}
+		}
+		src = buf
+	} else {
+		fd, err := os.Open(c.csv.FilePath())
+		if err != nil {
+			logger.Log().Error().Err(err).Str("file", c.csv.FilePath()).Msg("ReadCC: open csv file failed")
+			return nil, &CsvErr{err}
+		}
+		defer func() {
+			if err := fd.Close(); err != nil {
+				logger.Log().Error().Err(err).Str("file", c.csv.FilePath()).Msg("ReadCC: close csv file failed")
+			}
+		}()
+		src = fd
+	}
+	reader := csv.NewReader(src)
 	reader.TrimLeadingSpace = true
 
 	wp, err := newWorkerPool(nType, maxJobs, jWorker)
@@ -115,16 +243,18 @@ func (c Cocktail) ReadCC(nType ct.NumberType, maxJobs, jWorker int) ([]entity.Co
 	return wp.resp, nil
 }
 
-// Fetch returns a list of entity.Cocktail records from the data API.
-func (c Cocktail) Fetch() ([]entity.Cocktail, error) {
+// Fetch returns a list of entity.Cocktail records from the data API, along with the IDs of the records skipped as
+// they failed to parse, so they are not taken as removed upstream. The records whose ID fails to parse match no
+// database record, and are not reported.
+func (c Cocktail) Fetch() ([]entity.Cocktail, []int, error) {
 	req, err := http.NewRequest(http.MethodGet, c.dataAPI.URL(), nil)
 	if err != nil {
-		return nil, &DataApiErr{err}
+		return nil, nil, &DataApiErr{err}
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, &DataApiErr{err}
+		return nil, nil, &DataApiErr{err}
 	}
 	defer func(Body io.ReadCloser) {
 		if err := Body.Close(); err != nil {
@@ -134,68 +264,254 @@ func (c Cocktail) Fetch() ([]entity.Cocktail, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		logger.Log().Error().Err(err).Int("code", resp.StatusCode).Msg("Fetch: bad status code, expected 200")
-		return nil, &DataApiErr{ErrInvalidRespCode}
+		return nil, nil, &DataApiErr{ErrInvalidRespCode}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, &DataApiErr{err}
+		return nil, nil, &DataApiErr{err}
 	}
 
 	data := drinksData{}
 	if err := json.Unmarshal(body, &data); err != nil {
-		return nil, &DataApiErr{err}
+		return nil, nil, &DataApiErr{err}
 	}
 
 	cocktails := make([]entity.Cocktail, 0)
+	var skipped []int
 	for i, rec := range data.Drinks {
 		cocktail, errP := rec.parse()
 		if errP != nil {
 			logger.Log().Error().Err(errP).Int("line", i+1).Str("record", fmt.Sprintf("%v - %v", rec.DrinkId, rec.DrinkName)).
 				Msg("Fetch: parsing cocktail failed, record skipped")
+			if id, err := strconv.Atoi(rec.DrinkId); err == nil {
+				skipped = append(skipped, id)
+			}
 			continue
 		}
 		cocktails = append(cocktails, cocktail)
 	}
 
-	return cocktails, nil
+	return cocktails, skipped, nil
 }
 
-// ReplaceDB replaces the database entirely with the given entity.Cocktail records.
-func (c Cocktail) ReplaceDB(cocktails []entity.Cocktail) error {
-	file := c.csv.FilePath()
+// ReplaceDB replaces the database entirely with the given entity.Cocktail records, tombstones included,
+// invalidating its Checksum. In journal mode, the journal is folded, as the records replace its entries too.
+// The differences between the previous and the written records are appended to the change and revision logs,
+// as written by the given author. See logWrite.
+// The database and its logs are written under the exclusive lock, so no read sees the write half done.
+func (c Cocktail) ReplaceDB(cocktails []entity.Cocktail, author entity.Author) error {
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	f, err := os.OpenFile(file, os.O_TRUNC|os.O_WRONLY, dataFileMode)
+	prevRecs, err := c.readAll(true)
 	if err != nil {
-		logger.Log().Error().Err(err).Str("file", file).Msg("ReplaceDB: open csv file failed")
-		return &CsvErr{err}
+		return err
 	}
-	defer func(f *os.File) {
-		err := f.Close()
-		if err != nil {
-			logger.Log().Error().Err(err).Str("file", file).Msg("ReplaceDB: close csv file failed")
+	return c.replace(prevRecs, cocktails, author)
+}
+
+// ReplaceDBFunc replaces the database with the entity.Cocktail records returned by fn, given the current ones,
+// tombstones included. The records are read, and the returned ones written, under the exclusive lock, so no other
+// write is made in between, by this process or another one. Nothing is written if fn returns nil records or an
+// error, which is returned. Unless txID is empty, the database is snapshotted under it before being written.
+// See ReplaceDB and Snapshot.
+func (c Cocktail) ReplaceDBFunc(txID string, author entity.Author, fn func([]entity.Cocktail) ([]entity.Cocktail, error)) error {
+	if txID != "" {
+		if err := validateTxID(txID); err != nil {
+			return err
+		}
+	}
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	prevRecs, err := c.readAll(true)
+	if err != nil {
+		return err
+	}
+	// fn is given a copy, as the previous records are diffed against the written ones.
+	cocktails, err := fn(append(make([]entity.Cocktail, 0, len(prevRecs)), prevRecs...))
+	if err != nil || cocktails == nil {
+		return err
+	}
+	if txID != "" {
+		if err := c.snapshot(txID); err != nil {
+			return err
 		}
-	}(f)
+	}
+	return c.replace(prevRecs, cocktails, author)
+}
 
-	w := csv.NewWriter(f)
+// replace is ReplaceDB, with the exclusive lock held and the previous records read.
+// Once the records are written, the write succeeds even if logging it fails. See logWritten.
+func (c Cocktail) replace(prevRecs, cocktails []entity.Cocktail, author entity.Author) error {
+	written, err := c.writeBase(cocktails)
+	c.checksum.invalidate()
+	if err != nil {
+		return err
+	}
+	c.logWritten(prevRecs, written, author)
+	return nil
+}
+
+// writeBase replaces the CSV data file with the given records, and returns the written records; the ones that can
+// not be written are discarded. The records are written to a temporary file renamed over the data file once synced
+// to disk, so a crash leaves either the previous or the new file. In journal mode, the journal is folded.
+// See writeJournaledBase.
+func (c Cocktail) writeBase(cocktails []entity.Cocktail) ([]entity.Cocktail, error) {
+	if c.journaled() {
+		return c.writeJournaledBase(cocktails)
+	}
+
+	file := c.csv.FilePath()
+	tmp := file + ".tmp"
+	written, err := writeCsvFile(tmp, cocktails)
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", tmp).Msg("writeBase: write csv file failed")
+		_ = os.Remove(tmp)
+		return nil, &CsvErr{err}
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("writeBase: replace csv file failed")
+		_ = os.Remove(tmp)
+		return nil, &CsvErr{err}
+	}
+	return written, nil
+}
+
+// checkVersion checks the record of the given ID is at the given version, zero meaning the record does not exist.
+// Returns a ct.VersionConflictErr holding the current version otherwise.
+func checkVersion(recs []entity.Cocktail, id, version int) error {
+	current := 0
+	for _, rec := range recs {
+		if rec.ID == id {
+			current = rec.Version
+			break
+		}
+	}
+	if current != version {
+		return &ct.VersionConflictErr{ID: id, Version: version, Current: current}
+	}
+	return nil
+}
+
+// writeCsvRecs writes the given records with the given CSV writer, and flushes it.
+// Returns the written records; the ones that can not be parsed or written are discarded.
+func writeCsvRecs(w *csv.Writer, cocktails []entity.Cocktail) ([]entity.Cocktail, error) {
+	written := make([]entity.Cocktail, 0, len(cocktails))
 	for i, cocktail := range cocktails {
 		rec, errP := parseCsvRec(cocktail)
 		if errP != nil {
-			logger.Log().Error().Err(err).Int("index", i).Str("cocktail", fmt.Sprintf("ID: %d, Name: %v", cocktail.ID, cocktail.Name)).
-				Msg("ReplaceDB: parsing cocktail to csv record failed, discarded record")
+			logger.Log().Error().Err(errP).Int("index", i).Str("cocktail", fmt.Sprintf("ID: %d, Name: %v", cocktail.ID, cocktail.Name)).
+				Msg("writeCsvRecs: parsing cocktail to csv record failed, discarded record")
 			continue
 		}
 		if err := w.Write(rec); err != nil {
-			logger.Log().Error().Err(err).Int("index", i).Str("file", file).Str("record", strings.Join(rec[:], ",")).
-				Msg("ReplaceDB: writing record failed, discarded record")
+			logger.Log().Error().Err(err).Int("index", i).Str("record", strings.Join(rec[:], ",")).
+				Msg("writeCsvRecs: writing record failed, discarded record")
+			continue
 		}
-
+		written = append(written, cocktail)
 	}
 	w.Flush()
-	if err := w.Error(); err != nil {
-		logger.Log().Error().Err(err).Msg("ReplaceDB: flush writer failed")
-		return &CsvErr{err}
+	return written, w.Error()
+}
+
+// logsSeeded reports whether both the change and revision logs hold entries already, so the writes can log the
+// records they write alone. See logWrite.
+func (c Cocktail) logsSeeded() (bool, error) {
+	changeSeq, err := c.lastChangeSeq()
+	if err != nil || changeSeq == 0 {
+		return false, err
+	}
+	revSeq, err := c.lastRevisionSeq()
+	return revSeq > 0, err
+}
+
+// reconcileLogs appends the changes and revisions missing from the logs, under the exclusive lock.
+// A write is durable in the data file, or the journal, before it is logged, so a crash in between leaves it out of
+// the logs. The records the logs hold, replayed, are compared with the database ones, and the differences are logged
+// as written by the recovery source. Empty logs are seeded by the next write instead. See logsSeeded.
+func (c Cocktail) reconcileLogs() error {
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	seeded, err := c.logsSeeded()
+	if err != nil || !seeded {
+		return err
+	}
+	current, err := c.readAll(true)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+
+	logged, err := c.loggedRecords()
+	if err != nil {
+		return err
+	}
+	lastSeq, err := c.lastChangeSeq()
+	if err != nil {
+		return err
+	}
+	changes := diffChanges(logged, current, now)
+	if err := c.appendChanges(lastSeq, changes); err != nil {
+		return err
 	}
 
+	revised, err := c.revisedRecords()
+	if err != nil {
+		return err
+	}
+	lastRevSeq, err := c.lastRevisionSeq()
+	if err != nil {
+		return err
+	}
+	revs := diffRevisions(revised, current, entity.Author{Source: entity.SourceRecovery}, now)
+	if err := c.appendRevisionList(lastRevSeq, revs); err != nil {
+		return err
+	}
+	if len(changes) > 0 || len(revs) > 0 {
+		logger.Log().Warn().Int("changes", len(changes)).Int("revisions", len(revs)).
+			Msg("reconcileLogs: writes missing from the logs, logged")
+	}
 	return nil
 }
+
+// logWritten logs the write of the given records, already durable in the database, like logWrite.
+// A logging failure is not returned, as the write is done: the caller retrying it would conflict with its own write.
+// The write left out of the logs is logged on the next start instead. See reconcileLogs.
+func (c Cocktail) logWritten(prevRecs, written []entity.Cocktail, author entity.Author) {
+	if err := c.logWrite(prevRecs, written, author); err != nil {
+		logger.Log().Error().Err(err).Msg("logWritten: logging the write failed, left to the next start")
+	}
+}
+
+// logWrite appends the differences between the previous and the written records to the change log, and to the
+// revision log as written by the given author. See appendRevisions.
+// While the change log is empty, every written record is logged as created, so the log holds the whole database.
+func (c Cocktail) logWrite(prevRecs, written []entity.Cocktail, author entity.Author) error {
+	lastSeq, err := c.lastChangeSeq()
+	if err != nil {
+		return err
+	}
+	changedRecs := prevRecs
+	if lastSeq == 0 {
+		changedRecs = nil
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := c.appendChanges(lastSeq, diffChanges(changedRecs, written, now)); err != nil {
+		return err
+	}
+	return c.appendRevisions(prevRecs, written, author, now)
+}
@@ -2,6 +2,9 @@ package repository
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,6 +14,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -27,6 +31,8 @@ import (
 var (
 	_ HttpClient = &mocks.HttpClient{}
 
+	testAuthor = entity.Author{Actor: "test", Source: entity.SourceEdit}
+
 	testReadAllValid = []byte(`1,foo,,,"[{""name"":""fooIngr"",""measure"":""someMeasure""}]",foo instructions,,,,,,,,0001-01-01 00:00:00,0001-01-01 00:00:00,0001-01-01 00:00:00
 2,bar,,,"[{""name"":""fooIngr"",""measure"":""someMeasure""}]",bar instructions,,,,,,,,0001-01-01 00:00:00,0001-01-01 00:00:00,0001-01-01 00:00:00
 3,baz,,,"[{""name"":""fooIngr"",""measure"":""someMeasure""}]",baz instructions,,,,,,,,0001-01-01 00:00:00,0001-01-01 00:00:00,0001-01-01 00:00:00
@@ -498,9 +504,9 @@ func (s *CocktailTestSuite) TestReadAll() {
 		{
 			name: "Valid",
 			exp: []entity.Cocktail{
-				{ID: 1, Name: "foo", Instructions: "foo instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
-				{ID: 2, Name: "bar", Instructions: "bar instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
-				{ID: 3, Name: "baz", Instructions: "baz instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 1, Name: "foo", Instructions: "foo instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 2, Name: "bar", Instructions: "bar instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 3, Name: "baz", Instructions: "baz instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
 			},
 			err:      nil,
 			file:     file{name: "cocktail_valid.csv", mode: dataFileMode, data: testReadAllValid},
@@ -535,6 +541,61 @@ func (s *CocktailTestSuite) TestReadAll() {
 
 }
 
+func (s *CocktailTestSuite) TestStream() {
+	csvCfg := config.NewCsv("cocktail_stream.csv", s.workdir)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), testReadAllValid, dataFileMode),
+		fmt.Sprintf("create the test file %q is mandatory", csvCfg.FilePath()))
+	repo := Cocktail{csv: csvCfg}
+	testFnErr := errors.New("test fn error")
+
+	tests := []struct {
+		name   string
+		cancel bool
+		failAt int
+		exp    []int
+		err    error
+	}{
+		{
+			name: "All records",
+			exp:  []int{1, 2, 3},
+			err:  nil,
+		},
+		{
+			name:   "Context canceled",
+			cancel: true,
+			exp:    []int{},
+			err:    context.Canceled,
+		},
+		{
+			name:   "Fn error",
+			failAt: 2,
+			exp:    []int{1},
+			err:    testFnErr,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			ids := make([]int, 0)
+			err := repo.Stream(ctx, func(cocktail entity.Cocktail) error {
+				if cocktail.ID == tt.failAt {
+					return testFnErr
+				}
+				ids = append(ids, cocktail.ID)
+				return nil
+			})
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.exp, ids)
+		})
+	}
+}
+
 func (s *CocktailTestSuite) TestReadCC() {
 	type file struct {
 		name string
@@ -607,9 +668,9 @@ func (s *CocktailTestSuite) TestReadCC() {
 			file: file{name: "cocktail_even_cc.csv", mode: dataFileMode, data: testReadCC},
 			args: args{nType: ct.EvenNum, maxJobs: 8, jWorker: 4},
 			exp: []entity.Cocktail{
-				{ID: 17222, Name: "A1", Alcoholic: "Alcoholic", Category: "Cocktail", Ingredients: []entity.Ingredient{{Name: "Gin", Measure: "1 3/4 shot "}, {Name: "Grand Marnier", Measure: "1 Shot "}, {Name: "Lemon Juice", Measure: "1/4 Shot"}, {Name: "Grenadine", Measure: "1/8 Shot"}}, Instructions: "Pour all ingredients into a cocktail shaker, mix and serve over ice into a chilled glass.", Glass: "Cocktail glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/2x8thr1504816928.jpg", Video: "", SrcDate: time.Date(2017, time.September, 7, 21, 42, 9, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
-				{ID: 14610, Name: "ACID", Alcoholic: "Alcoholic", Category: "Shot", Ingredients: []entity.Ingredient{{Name: "151 proof rum", Measure: "1 oz Bacardi "}, {Name: "Wild Turkey", Measure: "1 oz "}}, Instructions: "Poor in the 151 first followed by the 101 served with a Coke or Dr Pepper chaser.", Glass: "Shot glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/xuxpxt1479209317.jpg", Video: "", SrcDate: time.Date(2016, time.November, 15, 11, 28, 37, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
-				{ID: 13938, Name: "AT&T", Alcoholic: "Alcoholic", Category: "Ordinary Drink", Ingredients: []entity.Ingredient{{Name: "Absolut Vodka", Measure: "1 oz "}, {Name: "Gin", Measure: "1 oz "}, {Name: "Tonic water", Measure: "4 oz "}}, Instructions: "Pour Vodka and Gin over ice, add Tonic and Stir", Glass: "Highball Glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/rhhwmp1493067619.jpg", Video: "", SrcDate: time.Date(2017, time.April, 24, 22, 0, 19, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)}},
+				{ID: 17222, Name: "A1", Alcoholic: "Alcoholic", Category: "Cocktail", Ingredients: []entity.Ingredient{{Name: "Gin", Measure: "1 3/4 shot "}, {Name: "Grand Marnier", Measure: "1 Shot "}, {Name: "Lemon Juice", Measure: "1/4 Shot"}, {Name: "Grenadine", Measure: "1/8 Shot"}}, Instructions: "Pour all ingredients into a cocktail shaker, mix and serve over ice into a chilled glass.", Glass: "Cocktail glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: []string{}, Thumb: "https://www.thecocktaildb.com/images/media/drink/2x8thr1504816928.jpg", Video: "", SrcDate: time.Date(2017, time.September, 7, 21, 42, 9, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
+				{ID: 14610, Name: "ACID", Alcoholic: "Alcoholic", Category: "Shot", Ingredients: []entity.Ingredient{{Name: "151 proof rum", Measure: "1 oz Bacardi "}, {Name: "Wild Turkey", Measure: "1 oz "}}, Instructions: "Poor in the 151 first followed by the 101 served with a Coke or Dr Pepper chaser.", Glass: "Shot glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: []string{}, Thumb: "https://www.thecocktaildb.com/images/media/drink/xuxpxt1479209317.jpg", Video: "", SrcDate: time.Date(2016, time.November, 15, 11, 28, 37, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
+				{ID: 13938, Name: "AT&T", Alcoholic: "Alcoholic", Category: "Ordinary Drink", Ingredients: []entity.Ingredient{{Name: "Absolut Vodka", Measure: "1 oz "}, {Name: "Gin", Measure: "1 oz "}, {Name: "Tonic water", Measure: "4 oz "}}, Instructions: "Pour Vodka and Gin over ice, add Tonic and Stir", Glass: "Highball Glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: []string{}, Thumb: "https://www.thecocktaildb.com/images/media/drink/rhhwmp1493067619.jpg", Video: "", SrcDate: time.Date(2017, time.April, 24, 22, 0, 19, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)}},
 			err:      nil,
 			wantFile: true,
 		},
@@ -618,8 +679,8 @@ func (s *CocktailTestSuite) TestReadCC() {
 			file: file{name: "cocktail_odd_cc.csv", mode: dataFileMode, data: testReadCC},
 			args: args{nType: ct.OddNum, maxJobs: 10, jWorker: 2},
 			exp: []entity.Cocktail{
-				{ID: 13501, Name: "ABC", Alcoholic: "Alcoholic", Category: "Shot", Ingredients: []entity.Ingredient{{Name: "Amaretto", Measure: "1/3 "}, {Name: "Baileys irish cream", Measure: "1/3 "}, {Name: "Cognac", Measure: "1/3 "}}, Instructions: "Layered in a shot glass.", Glass: "Shot glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/tqpvqp1472668328.jpg", Video: "", SrcDate: time.Date(2016, time.August, 31, 19, 32, 8, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
-				{ID: 17225, Name: "Ace", Alcoholic: "Alcoholic", Category: "Cocktail", Ingredients: []entity.Ingredient{{Name: "Gin", Measure: "2 shots "}, {Name: "Grenadine", Measure: "1/2 shot "}, {Name: "Heavy cream", Measure: "1/2 shot "}, {Name: "Milk", Measure: "1/2 shot"}, {Name: "Egg White", Measure: "1/2 Fresh"}}, Instructions: "Shake all the ingredients in a cocktail shaker and ice then strain in a cold glass.", Glass: "Martini Glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/l3cd7f1504818306.jpg", Video: "", SrcDate: time.Date(2017, time.September, 7, 22, 5, 6, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)}},
+				{ID: 13501, Name: "ABC", Alcoholic: "Alcoholic", Category: "Shot", Ingredients: []entity.Ingredient{{Name: "Amaretto", Measure: "1/3 "}, {Name: "Baileys irish cream", Measure: "1/3 "}, {Name: "Cognac", Measure: "1/3 "}}, Instructions: "Layered in a shot glass.", Glass: "Shot glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: []string{}, Thumb: "https://www.thecocktaildb.com/images/media/drink/tqpvqp1472668328.jpg", Video: "", SrcDate: time.Date(2016, time.August, 31, 19, 32, 8, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
+				{ID: 17225, Name: "Ace", Alcoholic: "Alcoholic", Category: "Cocktail", Ingredients: []entity.Ingredient{{Name: "Gin", Measure: "2 shots "}, {Name: "Grenadine", Measure: "1/2 shot "}, {Name: "Heavy cream", Measure: "1/2 shot "}, {Name: "Milk", Measure: "1/2 shot"}, {Name: "Egg White", Measure: "1/2 Fresh"}}, Instructions: "Shake all the ingredients in a cocktail shaker and ice then strain in a cold glass.", Glass: "Martini Glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: []string{}, Thumb: "https://www.thecocktaildb.com/images/media/drink/l3cd7f1504818306.jpg", Video: "", SrcDate: time.Date(2017, time.September, 7, 22, 5, 6, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)}},
 			err:      nil,
 			wantFile: true,
 		},
@@ -671,9 +732,9 @@ func (s *CocktailTestSuite) TestReplaceDB() {
 		{
 			name: "Invalid CSV file",
 			args: []entity.Cocktail{
-				{ID: 1, Name: "foo", Instructions: "foo instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
-				{ID: 2, Name: "bar", Instructions: "bar instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
-				{ID: 3, Name: "baz", Instructions: "baz instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 1, Name: "foo", Instructions: "foo instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 2, Name: "bar", Instructions: "bar instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 3, Name: "baz", Instructions: "baz instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
 			},
 			exp:      nil,
 			err:      &CsvErr{&fs.PathError{}},
@@ -684,13 +745,13 @@ func (s *CocktailTestSuite) TestReplaceDB() {
 			name: "Discarded record because of a parseCocktail error.",
 			file: file{name: "cocktail_parse_errs.csv", mode: dataFileMode, data: nil},
 			args: []entity.Cocktail{
-				{ID: 1, Name: "foo", Instructions: "foo instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
-				{ID: 2, Name: "bar", Instructions: "bar instructions"},
-				{ID: 3, Name: "baz", Instructions: "baz instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 1, Name: "foo", Instructions: "foo instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 2, Name: "bar", Instructions: "bar instructions", Tags: []string{}},
+				{ID: 3, Name: "baz", Instructions: "baz instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
 			},
 			exp: []entity.Cocktail{
-				{ID: 1, Name: "foo", Instructions: "foo instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
-				{ID: 3, Name: "baz", Instructions: "baz instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 1, Name: "foo", Instructions: "foo instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 3, Name: "baz", Instructions: "baz instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
 			},
 			err:      nil,
 			wantFile: true,
@@ -699,14 +760,14 @@ func (s *CocktailTestSuite) TestReplaceDB() {
 			name: "Valid with no data",
 			file: file{name: "create_valid_empty.csv", mode: dataFileMode, data: nil},
 			args: []entity.Cocktail{
-				{ID: 1, Name: "foo", Instructions: "foo instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
-				{ID: 2, Name: "bar", Instructions: "bar instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
-				{ID: 3, Name: "baz", Instructions: "baz instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 1, Name: "foo", Instructions: "foo instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 2, Name: "bar", Instructions: "bar instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 3, Name: "baz", Instructions: "baz instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
 			},
 			exp: []entity.Cocktail{
-				{ID: 1, Name: "foo", Instructions: "foo instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
-				{ID: 2, Name: "bar", Instructions: "bar instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
-				{ID: 3, Name: "baz", Instructions: "baz instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 1, Name: "foo", Instructions: "foo instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 2, Name: "bar", Instructions: "bar instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 3, Name: "baz", Instructions: "baz instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
 			},
 			err:      nil,
 			wantFile: true,
@@ -715,12 +776,12 @@ func (s *CocktailTestSuite) TestReplaceDB() {
 			name: "Valid with data",
 			file: file{name: "create_valid_full.csv", mode: dataFileMode, data: testReadAllValid},
 			args: []entity.Cocktail{
-				{ID: 2, Name: "bar", Instructions: "bar instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
-				{ID: 3, Name: "baz", Instructions: "baz instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 2, Name: "bar", Instructions: "bar instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 3, Name: "baz", Instructions: "baz instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
 			},
 			exp: []entity.Cocktail{
-				{ID: 2, Name: "bar", Instructions: "bar instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
-				{ID: 3, Name: "baz", Instructions: "baz instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 2, Name: "bar", Instructions: "bar instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
+				{ID: 3, Name: "baz", Instructions: "baz instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr", Measure: "someMeasure"}}},
 			},
 			err:      nil,
 			wantFile: true,
@@ -736,7 +797,7 @@ func (s *CocktailTestSuite) TestReplaceDB() {
 			}
 			repo := Cocktail{csv: csvCfg}
 
-			err := repo.ReplaceDB(tt.args)
+			err := repo.ReplaceDB(tt.args, testAuthor)
 			if tt.err != nil {
 				require.NotNil(s.T(), err)
 				assert.IsType(t, tt.err, err)
@@ -755,6 +816,716 @@ func (s *CocktailTestSuite) TestReplaceDB() {
 	}
 }
 
+func (s *CocktailTestSuite) TestReplaceDBChanges() {
+	csvCfg := config.NewCsv("changes.csv", s.workdir)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), nil, dataFileMode))
+	repo := Cocktail{csv: csvCfg}
+	date := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	foo := entity.Cocktail{ID: 1, Name: "foo", Instructions: "foo instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr"}}, CreatedAt: date, UpdatedAt: date}
+	bar := entity.Cocktail{ID: 2, Name: "bar", Instructions: "bar instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "barIngr"}}, CreatedAt: date, UpdatedAt: date}
+	baz := entity.Cocktail{ID: 3, Name: "baz", Instructions: "baz instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "bazIngr"}}, CreatedAt: date, UpdatedAt: date}
+
+	changes, err := repo.ReadChanges(0, 0)
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), changes)
+
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{foo, bar}, testAuthor))
+	fooUpdated := foo
+	fooUpdated.Name = "foo updated"
+	fooUpdated.UpdatedAt = date.Add(time.Hour)
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{fooUpdated, baz}, testAuthor))
+
+	changes, err = repo.ReadChanges(0, 0)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), changes, 5)
+	tests := []struct {
+		seq      int64
+		typ      entity.ChangeType
+		id       int
+		cocktail *entity.Cocktail
+	}{
+		{seq: 1, typ: entity.ChangeCreated, id: 1, cocktail: &foo},
+		{seq: 2, typ: entity.ChangeCreated, id: 2, cocktail: &bar},
+		{seq: 3, typ: entity.ChangeUpdated, id: 1, cocktail: &fooUpdated},
+		{seq: 4, typ: entity.ChangeCreated, id: 3, cocktail: &baz},
+		{seq: 5, typ: entity.ChangeDeleted, id: 2, cocktail: nil},
+	}
+	for i, tt := range tests {
+		assert.Equal(s.T(), tt.seq, changes[i].Seq)
+		assert.Equal(s.T(), tt.typ, changes[i].Type)
+		assert.Equal(s.T(), tt.id, changes[i].ID)
+		assert.Equal(s.T(), tt.cocktail, changes[i].Cocktail)
+	}
+
+	changes, err = repo.ReadChanges(3, 1)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), changes, 1)
+	assert.Equal(s.T(), int64(4), changes[0].Seq)
+}
+
+func (s *CocktailTestSuite) TestReconcileLogs() {
+	csvCfg := config.NewCsv("reconcile.csv", s.workdir)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), nil, dataFileMode))
+	repo := Cocktail{csv: csvCfg, checksum: &checksumCache{}}
+	date := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	foo := entity.Cocktail{ID: 1, Name: "foo", Instructions: "foo instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr"}}, CreatedAt: date, UpdatedAt: date, Version: 1}
+	bar := entity.Cocktail{ID: 2, Name: "bar", Instructions: "bar instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "barIngr"}}, CreatedAt: date, UpdatedAt: date, Version: 1}
+	baz := entity.Cocktail{ID: 3, Name: "baz", Instructions: "baz instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "bazIngr"}}, CreatedAt: date, UpdatedAt: date, Version: 1}
+
+	// Empty logs are seeded by the next write.
+	require.Nil(s.T(), repo.reconcileLogs())
+	changes, err := repo.ReadChanges(0, 0)
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), changes)
+
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{foo, bar}, testAuthor))
+	require.Nil(s.T(), repo.reconcileLogs())
+	changes, err = repo.ReadChanges(0, 0)
+	require.Nil(s.T(), err)
+	assert.Len(s.T(), changes, 2)
+
+	// A crash left the write out of the logs.
+	fooUpdated := foo
+	fooUpdated.Name = "foo updated"
+	fooUpdated.UpdatedAt = date.Add(time.Hour)
+	fooUpdated.Version = 2
+	_, err = writeCsvFile(csvCfg.FilePath(), []entity.Cocktail{fooUpdated, baz})
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), repo.reconcileLogs())
+
+	changes, err = repo.ReadChanges(2, 0)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), changes, 3)
+	assert.Equal(s.T(), entity.Change{Seq: 3, Type: entity.ChangeUpdated, ID: 1, Time: fooUpdated.UpdatedAt, Cocktail: &fooUpdated}, changes[0])
+	assert.Equal(s.T(), entity.Change{Seq: 4, Type: entity.ChangeCreated, ID: 3, Time: date, Cocktail: &baz}, changes[1])
+	assert.Equal(s.T(), entity.ChangeDeleted, changes[2].Type)
+	assert.Equal(s.T(), 2, changes[2].ID)
+	history, err := repo.ReadHistory(1)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), history, 2)
+	assert.Equal(s.T(), entity.ChangeUpdated, history[1].Type)
+	assert.Equal(s.T(), entity.Author{Source: entity.SourceRecovery}, history[1].Author)
+	assert.Equal(s.T(), &fooUpdated, history[1].Cocktail)
+
+	// Reconciled logs are left as they are.
+	require.Nil(s.T(), repo.reconcileLogs())
+	changes, err = repo.ReadChanges(0, 0)
+	require.Nil(s.T(), err)
+	assert.Len(s.T(), changes, 5)
+	revSeq, err := repo.lastRevisionSeq()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), int64(5), revSeq)
+}
+
+func (s *CocktailTestSuite) TestReplaceDBVersion() {
+	csvCfg := config.NewCsv("version.csv", s.workdir)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), nil, dataFileMode))
+	repo := Cocktail{csv: csvCfg}
+	date := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	foo := entity.Cocktail{ID: 1, Name: "foo", Instructions: "foo instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr"}}, CreatedAt: date, UpdatedAt: date, Version: 1}
+
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{foo}, testAuthor))
+	recs, err := repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Cocktail{foo}, recs)
+
+	// A new version is logged as updated, even within the same second.
+	foo.Name = "foo updated"
+	foo.Version = 2
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{foo}, testAuthor))
+	changes, err := repo.ReadChanges(1, 0)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), changes, 1)
+	assert.Equal(s.T(), entity.ChangeUpdated, changes[0].Type)
+	assert.Equal(s.T(), &foo, changes[0].Cocktail)
+}
+
+func (s *CocktailTestSuite) TestReplaceDBFunc() {
+	csvCfg := config.NewCsv("replace_func.csv", s.workdir)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), nil, dataFileMode))
+	repo := Cocktail{csv: csvCfg}
+	date := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	foo := entity.Cocktail{ID: 1, Name: "foo", Instructions: "foo instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr"}}, CreatedAt: date, UpdatedAt: date, Version: 1}
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{foo}, testAuthor))
+
+	// Nothing written without records, or on error
+	fnErr := errors.New("fn error")
+	require.Nil(s.T(), repo.ReplaceDBFunc("", testAuthor, func([]entity.Cocktail) ([]entity.Cocktail, error) { return nil, nil }))
+	assert.ErrorIs(s.T(), repo.ReplaceDBFunc("", testAuthor, func([]entity.Cocktail) ([]entity.Cocktail, error) { return []entity.Cocktail{}, fnErr }), fnErr)
+	recs, err := repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Cocktail{foo}, recs)
+
+	// Given the current records, snapshotted before the write
+	updated := foo
+	updated.Name = "foo updated"
+	updated.Version = 2
+	err = repo.ReplaceDBFunc("20231001T000000Z-00000001", testAuthor, func(recs []entity.Cocktail) ([]entity.Cocktail, error) {
+		assert.Equal(s.T(), []entity.Cocktail{foo}, recs)
+		recs[0] = updated
+		return recs, nil
+	})
+	require.Nil(s.T(), err)
+	recs, err = repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Cocktail{updated}, recs)
+	snapshot, found, err := repo.ReadSnapshot("20231001T000000Z-00000001")
+	require.Nil(s.T(), err)
+	assert.True(s.T(), found)
+	assert.Equal(s.T(), []entity.Cocktail{foo}, snapshot)
+	changes, err := repo.ReadChanges(1, 0)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), changes, 1)
+	assert.Equal(s.T(), entity.ChangeUpdated, changes[0].Type)
+	assert.ErrorIs(s.T(), repo.ReplaceDBFunc("../tx", testAuthor, nil), ErrTxIDInvalid)
+
+	// The single-record writes are checked against the current version
+	err = repo.PutRecord(foo, 1, testAuthor)
+	assert.Equal(s.T(), &ct.VersionConflictErr{ID: 1, Version: 1, Current: 2}, err)
+	err = repo.PurgeRecord(1, 1, testAuthor)
+	assert.Equal(s.T(), &ct.VersionConflictErr{ID: 1, Version: 1, Current: 2}, err)
+	updated.Version = 3
+	require.Nil(s.T(), repo.PutRecord(updated, 2, testAuthor))
+	require.Nil(s.T(), repo.PurgeRecord(1, 3, testAuthor))
+	recs, err = repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), recs)
+	_, err = os.Stat(csvCfg.FilePath() + ".tmp")
+	assert.ErrorIs(s.T(), err, os.ErrNotExist)
+}
+
+func (s *CocktailTestSuite) TestReplaceDBRevisions() {
+	csvCfg := config.NewCsv("revisions.csv", s.workdir)
+	date := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	foo := entity.Cocktail{ID: 1, Name: "foo", Instructions: "foo instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr"}}, CreatedAt: date, UpdatedAt: date, Version: 1}
+	bar := entity.Cocktail{ID: 2, Name: "bar", Instructions: "bar instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "barIngr"}}, CreatedAt: date, UpdatedAt: date, Version: 1}
+	rec, err := parseCsvRec(foo)
+	require.Nil(s.T(), err)
+	var data bytes.Buffer
+	w := csv.NewWriter(&data)
+	require.NoError(s.T(), w.Write(rec))
+	w.Flush()
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), data.Bytes(), dataFileMode))
+	repo := Cocktail{csv: csvCfg}
+
+	// Without revisions, the history is unknown and the current records are the ones created before.
+	recs, err := repo.ReadAsOf(date.Add(-time.Second))
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), recs)
+	recs, err = repo.ReadAsOf(date)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Cocktail{foo}, recs)
+
+	fooUpdated := foo
+	fooUpdated.Glass = "Shot glass"
+	fooUpdated.UpdatedAt = date.Add(time.Hour)
+	fooUpdated.Version = 2
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{fooUpdated, bar}, testAuthor))
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{bar}, entity.Author{Source: entity.SourceSync}))
+
+	history, err := repo.ReadHistory(1)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), history, 3)
+	assert.Equal(s.T(), entity.Revision{Seq: 1, ID: 1, Version: 1, Type: entity.ChangeCreated, Time: date,
+		Author: entity.Author{Source: entity.SourceImport}, Cocktail: &foo}, history[0])
+	assert.Equal(s.T(), int64(2), history[1].Seq)
+	assert.Equal(s.T(), entity.ChangeUpdated, history[1].Type)
+	assert.Equal(s.T(), testAuthor, history[1].Author)
+	assert.Equal(s.T(), []entity.FieldDiff{{Field: "glass", Old: json.RawMessage(`""`), New: json.RawMessage(`"Shot glass"`)}}, history[1].Diff)
+	assert.Equal(s.T(), &fooUpdated, history[1].Cocktail)
+	assert.Equal(s.T(), entity.ChangeDeleted, history[2].Type)
+	assert.Equal(s.T(), entity.SourceSync, history[2].Source)
+	assert.Nil(s.T(), history[2].Cocktail)
+
+	history, err = repo.ReadHistory(9)
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), history)
+
+	// The records as they were before and after the edits, replayed from the revisions.
+	recs, err = repo.ReadAsOf(date)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Cocktail{foo}, recs)
+	recs, err = repo.ReadAsOf(time.Now().Add(time.Hour))
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Cocktail{bar}, recs)
+}
+
+func (s *CocktailTestSuite) TestReplaceDBTombstones() {
+	csvCfg := config.NewCsv("tombstones.csv", s.workdir)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), nil, dataFileMode))
+	repo := Cocktail{csv: csvCfg}
+	date := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	foo := entity.Cocktail{ID: 1, Name: "foo", Instructions: "foo instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr"}}, CreatedAt: date, UpdatedAt: date, Version: 1}
+	bar := entity.Cocktail{ID: 2, Name: "bar", Instructions: "bar instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "barIngr"}}, CreatedAt: date, UpdatedAt: date, Version: 1}
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{foo, bar}, testAuthor))
+
+	deletedAt := date.Add(time.Hour)
+	fooDeleted := foo
+	fooDeleted.UpdatedAt = deletedAt
+	fooDeleted.DeletedAt = &deletedAt
+	fooDeleted.Version = 2
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{fooDeleted, bar}, testAuthor))
+
+	// The tombstones are read back only on request.
+	recs, err := repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Cocktail{bar}, recs)
+	recs, err = repo.ReadAllWithDeleted()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Cocktail{fooDeleted, bar}, recs)
+	streamed := make([]entity.Cocktail, 0)
+	require.Nil(s.T(), repo.Stream(context.Background(), func(c entity.Cocktail) error {
+		streamed = append(streamed, c)
+		return nil
+	}))
+	assert.Equal(s.T(), []entity.Cocktail{bar}, streamed)
+
+	fooRestored := foo
+	fooRestored.UpdatedAt = date.Add(2 * time.Hour)
+	fooRestored.Version = 3
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{fooRestored, bar}, testAuthor))
+	barDeleted := bar
+	barDeleted.UpdatedAt = deletedAt
+	barDeleted.DeletedAt = &deletedAt
+	barDeleted.Version = 2
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{fooRestored, barDeleted}, testAuthor))
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{fooRestored}, testAuthor))
+
+	// The change log holds the records as live ones: restored records are created again, purges are not logged.
+	changes, err := repo.ReadChanges(2, 0)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), changes, 3)
+	assert.Equal(s.T(), entity.Change{Seq: 3, Type: entity.ChangeDeleted, ID: 1, Time: deletedAt}, changes[0])
+	assert.Equal(s.T(), entity.Change{Seq: 4, Type: entity.ChangeCreated, ID: 1, Time: fooRestored.UpdatedAt, Cocktail: &fooRestored}, changes[1])
+	assert.Equal(s.T(), entity.Change{Seq: 5, Type: entity.ChangeDeleted, ID: 2, Time: deletedAt}, changes[2])
+
+	history, err := repo.ReadHistory(1)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), history, 3)
+	assert.Equal(s.T(), entity.ChangeDeleted, history[1].Type)
+	assert.Equal(s.T(), 2, history[1].Version)
+	assert.Equal(s.T(), entity.ChangeRestored, history[2].Type)
+	assert.Equal(s.T(), &fooRestored, history[2].Cocktail)
+	history, err = repo.ReadHistory(2)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), history, 3)
+	assert.Equal(s.T(), entity.ChangePurged, history[2].Type)
+	assert.Nil(s.T(), history[2].Cocktail)
+
+	recs, err = repo.ReadAsOf(time.Now().Add(time.Hour))
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Cocktail{fooRestored}, recs)
+}
+
+func (s *CocktailTestSuite) TestSnapshot() {
+	csvCfg := config.NewCsv("snapshot.csv", s.workdir)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), testReadAllValid, dataFileMode))
+	repo := Cocktail{csv: csvCfg}
+	before, err := repo.ReadAll()
+	require.Nil(s.T(), err)
+	require.NotEmpty(s.T(), before)
+
+	recs, found, err := repo.ReadSnapshot("20231001T000000Z-00000000")
+	require.Nil(s.T(), err)
+	assert.False(s.T(), found)
+	assert.Nil(s.T(), recs)
+
+	require.Nil(s.T(), repo.Snapshot("20231001T000000Z-00000000"))
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{{ID: 9, Name: "baz", Instructions: "baz instructions", Ingredients: []entity.Ingredient{{Name: "bazIngr"}}}}, testAuthor))
+
+	recs, found, err = repo.ReadSnapshot("20231001T000000Z-00000000")
+	require.Nil(s.T(), err)
+	assert.True(s.T(), found)
+	assert.Equal(s.T(), before, recs)
+	info, err := os.Stat(filepath.Join(s.workdir, "snapshot_snapshots", "20231001T000000Z-00000000.csv"))
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), dataFileMode, info.Mode())
+
+	for _, txID := range []string{"", "../snapshot", ".hidden"} {
+		assert.ErrorIs(s.T(), repo.Snapshot(txID), ErrTxIDInvalid)
+		_, found, err := repo.ReadSnapshot(txID)
+		assert.Nil(s.T(), err)
+		assert.False(s.T(), found)
+	}
+}
+
+func (s *CocktailTestSuite) TestChecksum() {
+	csvCfg := config.NewCsv("checksum.csv", s.workdir)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), testReadAllValid, dataFileMode))
+	repo := Cocktail{csv: csvCfg, checksum: &checksumCache{}}
+
+	sum, err := repo.Checksum()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), fmt.Sprintf("%x", sha256.Sum256(testReadAllValid)), sum)
+
+	// Cached until the database is written
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), nil, dataFileMode))
+	cached, err := repo.Checksum()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), sum, cached)
+
+	require.Nil(s.T(), repo.ReplaceDB([]entity.Cocktail{{ID: 1, Name: "foo", Instructions: "foo instructions", Ingredients: []entity.Ingredient{{Name: "fooIngr"}}}}, testAuthor))
+	written, err := repo.Checksum()
+	require.Nil(s.T(), err)
+	assert.NotEqual(s.T(), sum, written)
+	exp, err := fileChecksum(csvCfg.FilePath())
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), exp, written)
+
+	_, err = Cocktail{csv: config.NewCsv("missing.csv", s.workdir)}.Checksum()
+	assert.IsType(s.T(), &CsvErr{}, err)
+}
+
+func (s *CocktailTestSuite) TestLastModified() {
+	csvCfg := config.NewCsv("modified.csv", s.workdir).WithMode(journalMode, 0)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), testReadAllValid, dataFileMode))
+	repo := Cocktail{csv: csvCfg, checksum: &checksumCache{}}
+	base := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(s.T(), os.Chtimes(csvCfg.FilePath(), base, base))
+
+	modified, err := repo.LastModified()
+	require.Nil(s.T(), err)
+	assert.True(s.T(), base.Equal(modified))
+
+	// Dated by the journal once a record is purged
+	recs, err := repo.ReadAll()
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), repo.PurgeRecord(recs[0].ID, recs[0].Version, testAuthor))
+	purged, err := repo.LastModified()
+	require.Nil(s.T(), err)
+	assert.True(s.T(), purged.After(base))
+
+	_, err = Cocktail{csv: config.NewCsv("missing.csv", s.workdir)}.LastModified()
+	assert.IsType(s.T(), &CsvErr{}, err)
+}
+
+func (s *CocktailTestSuite) TestLock() {
+	csvCfg := config.NewCsv("lock.csv", s.workdir).WithLockTimeout(50 * time.Millisecond)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), testReadAllValid, dataFileMode))
+	repo := Cocktail{csv: csvCfg, checksum: &checksumCache{}}
+	other := Cocktail{csv: csvCfg, checksum: &checksumCache{}}
+
+	// Shared by the readers
+	unlock, err := repo.rlock()
+	require.Nil(s.T(), err)
+	_, err = other.ReadAll()
+	assert.Nil(s.T(), err)
+	err = other.ReplaceDB(nil, testAuthor)
+	assert.IsType(s.T(), &CsvErr{}, err)
+	assert.ErrorIs(s.T(), err, ErrLockTimeout)
+	unlock()
+
+	// Exclusive to the writers
+	unlock, err = repo.lock()
+	require.Nil(s.T(), err)
+	_, err = other.ReadAll()
+	assert.ErrorIs(s.T(), err, ErrLockTimeout)
+	_, err = other.ReadCC(ct.OddNum, 1, 1)
+	assert.ErrorIs(s.T(), err, ErrLockTimeout)
+	_, err = other.Checksum()
+	assert.ErrorIs(s.T(), err, ErrLockTimeout)
+
+	// Waiting for the release
+	released := make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		unlock()
+		close(released)
+	}()
+	recs, err := other.ReadAll()
+	assert.Nil(s.T(), err)
+	assert.NotEmpty(s.T(), recs)
+	<-released
+}
+
+func (s *CocktailTestSuite) TestCocktailCache() {
+	csvCfg := config.NewCsv("cache.csv", s.workdir)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), testReadCC, dataFileMode))
+	repo := NewCocktailCache(Cocktail{csv: csvCfg, checksum: &checksumCache{}})
+	exp, err := repo.Cocktail.ReadAll()
+	require.Nil(s.T(), err)
+	sum, err := repo.Checksum()
+	require.Nil(s.T(), err)
+
+	// Concurrent cold loads share one load
+	indexes := make(chan *cocktailIndex, 8)
+	wg := sync.WaitGroup{}
+	for i := 0; i < cap(indexes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			idx, err := repo.index()
+			assert.Nil(s.T(), err)
+			indexes <- idx
+		}()
+	}
+	wg.Wait()
+	close(indexes)
+	for idx := range indexes {
+		assert.Same(s.T(), repo.cache.loaded, idx)
+	}
+
+	recs, err := repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), exp, recs)
+	recs[0].Name = "changed"
+	cached, err := repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), exp, cached, "the cached records are copied")
+
+	rec, found, err := repo.ReadByID(17225)
+	require.Nil(s.T(), err)
+	assert.True(s.T(), found)
+	assert.Equal(s.T(), "Ace", rec.Name)
+	_, found, err = repo.ReadByID(1)
+	require.Nil(s.T(), err)
+	assert.False(s.T(), found)
+
+	gin, err := repo.ReadIndexed(ct.IngredientIndex, func(key string) bool { return key == "gin" })
+	require.Nil(s.T(), err)
+	exps := make([]entity.Cocktail, 0)
+	for _, rec := range exp {
+		for _, ingr := range rec.Ingredients {
+			if ingr.Name == "Gin" {
+				exps = append(exps, rec)
+				break
+			}
+		}
+	}
+	assert.NotEmpty(s.T(), gin)
+	assert.Equal(s.T(), exps, gin)
+
+	// Written by ReplaceDB
+	require.Nil(s.T(), repo.ReplaceDB(exp[:1], testAuthor))
+	recs, err = repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Len(s.T(), recs, 1)
+
+	// Written by another process
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), testReadAllValid, dataFileMode))
+	future := time.Now().Add(time.Hour)
+	require.NoError(s.T(), os.Chtimes(csvCfg.FilePath(), future, future))
+	recs, err = repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Len(s.T(), recs, 3)
+	written, err := repo.Checksum()
+	require.Nil(s.T(), err)
+	assert.NotEqual(s.T(), sum, written)
+	assert.Equal(s.T(), fmt.Sprintf("%x", sha256.Sum256(testReadAllValid)), written)
+	var streamed int
+	require.Nil(s.T(), repo.Stream(context.Background(), func(entity.Cocktail) error {
+		streamed++
+		return nil
+	}))
+	assert.Equal(s.T(), 3, streamed)
+
+	_, err = NewCocktailCache(Cocktail{csv: config.NewCsv("missing.csv", s.workdir)}).ReadAll()
+	assert.IsType(s.T(), &CsvErr{}, err)
+}
+
+func (s *CocktailTestSuite) TestJournal() {
+	csvCfg := config.NewCsv("journal.csv", s.workdir).WithMode(journalMode, 3)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), testReadAllValid, dataFileMode))
+	repo := NewCocktailCache(Cocktail{csv: csvCfg, checksum: &checksumCache{}})
+	base, err := repo.ReadAll()
+	require.Nil(s.T(), err)
+	require.Len(s.T(), base, 3)
+
+	// Appended to the journal, the data file is left untouched
+	updated := base[1]
+	updated.Category = "updated"
+	require.Nil(s.T(), repo.PutRecord(updated, base[1].Version, testAuthor))
+	created := entity.Cocktail{ID: 9, Name: "baz", Instructions: "baz instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "bazIngr"}}, Version: 1}
+	require.Nil(s.T(), repo.PutRecord(created, 0, testAuthor))
+	err = repo.PutRecord(created, 0, testAuthor)
+	assert.Equal(s.T(), &ct.VersionConflictErr{ID: 9, Version: 0, Current: 1}, err)
+	data, err := os.ReadFile(csvCfg.FilePath())
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), testReadAllValid, data)
+	entries, err := repo.readJournal()
+	require.Nil(s.T(), err)
+	assert.Len(s.T(), entries, 2)
+	exp := []entity.Cocktail{base[0], updated, base[2], created}
+	recs, err := repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), exp, recs)
+	odd, err := repo.Cocktail.ReadCC(ct.OddNum, 4, 1)
+	require.Nil(s.T(), err)
+	assert.ElementsMatch(s.T(), []entity.Cocktail{base[0], base[2], created}, odd)
+	require.Nil(s.T(), repo.Snapshot("20231001T000000Z-00000000"))
+	snapshot, found, err := repo.ReadSnapshot("20231001T000000Z-00000000")
+	require.Nil(s.T(), err)
+	assert.True(s.T(), found)
+	assert.Equal(s.T(), exp, snapshot)
+	changes, err := repo.ReadChanges(0, 0)
+	require.Nil(s.T(), err)
+	assert.NotEmpty(s.T(), changes)
+
+	// Compacted automatically past the threshold
+	sum, err := repo.Checksum()
+	require.Nil(s.T(), err)
+	require.Nil(s.T(), repo.PurgeRecord(base[0].ID, base[0].Version, testAuthor))
+	entries, err = repo.readJournal()
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), entries)
+	exp = exp[1:]
+	recs, err = repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), exp, recs)
+	recs, err = Cocktail{csv: config.NewCsv("journal.csv", s.workdir)}.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), exp, recs, "the data file holds the journal")
+	written, err := repo.Checksum()
+	require.Nil(s.T(), err)
+	assert.NotEqual(s.T(), sum, written)
+	n, err := repo.Compact()
+	require.Nil(s.T(), err)
+	assert.Zero(s.T(), n)
+
+	// Folded by the replacements
+	require.Nil(s.T(), repo.PutRecord(base[0], 0, testAuthor))
+	require.Nil(s.T(), repo.ReplaceDB(exp, testAuthor))
+	entries, err = repo.readJournal()
+	require.Nil(s.T(), err)
+	assert.Empty(s.T(), entries)
+	recs, err = repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), exp, recs)
+}
+
+func (s *CocktailTestSuite) TestWriteRecordLogFailure() {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		s.T().Skip("no /dev/full to fail the log writes")
+	}
+	csvCfg := config.NewCsv("logfail.csv", s.workdir).WithMode(journalMode, 0)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), testReadAllValid, dataFileMode))
+	repo := Cocktail{csv: csvCfg, checksum: &checksumCache{}, journal: &journalState{}}
+	require.NoError(s.T(), os.Symlink("/dev/full", repo.revisionLogPath()))
+	base, err := repo.ReadAll()
+	require.Nil(s.T(), err)
+
+	// The entry is appended, so the write succeeds though it is not logged, and it is not retried as a conflict.
+	updated := base[0]
+	updated.Category = "updated"
+	updated.Version = base[0].Version + 1
+	require.Nil(s.T(), repo.PutRecord(updated, base[0].Version, testAuthor))
+	recs, err := repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), updated, recs[0])
+	err = repo.PutRecord(updated, base[0].Version, testAuthor)
+	assert.Equal(s.T(), &ct.VersionConflictErr{ID: updated.ID, Version: base[0].Version, Current: updated.Version}, err)
+}
+
+func (s *CocktailTestSuite) TestJournalState() {
+	csvCfg := config.NewCsv("state.csv", s.workdir).WithMode(journalMode, 0)
+	require.NoError(s.T(), os.WriteFile(csvCfg.FilePath(), testReadAllValid, dataFileMode))
+	repo := Cocktail{csv: csvCfg, checksum: &checksumCache{}, journal: &journalState{}}
+	other := Cocktail{csv: csvCfg, checksum: &checksumCache{}, journal: &journalState{}}
+	base, err := repo.ReadAll()
+	require.Nil(s.T(), err)
+
+	// The first write logs the whole database, the next ones the written record alone
+	updated := base[0]
+	updated.Category = "updated"
+	require.Nil(s.T(), repo.PutRecord(updated, base[0].Version, testAuthor))
+	changes, err := repo.ReadChanges(0, 0)
+	require.Nil(s.T(), err)
+	require.NotEmpty(s.T(), changes)
+	last := changes[len(changes)-1].Seq
+	require.Nil(s.T(), repo.PurgeRecord(base[1].ID, base[1].Version, testAuthor))
+	changes, err = repo.ReadChanges(last, 0)
+	require.Nil(s.T(), err)
+	require.Len(s.T(), changes, 1)
+	assert.Equal(s.T(), entity.ChangeDeleted, changes[0].Type)
+	assert.Equal(s.T(), base[1].ID, changes[0].ID)
+	history, err := repo.ReadHistory(base[1].ID)
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), entity.ChangeDeleted, history[len(history)-1].Type)
+
+	// The writes of another process are read again
+	created := entity.Cocktail{ID: 9, Name: "baz", Instructions: "baz instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "bazIngr"}}, Version: 1}
+	require.Nil(s.T(), other.PutRecord(created, 0, testAuthor))
+	err = repo.PutRecord(created, 0, testAuthor)
+	assert.Equal(s.T(), &ct.VersionConflictErr{ID: 9, Version: 0, Current: 1}, err)
+	entries, err := repo.readJournal()
+	require.Nil(s.T(), err)
+	require.Len(s.T(), entries, 3)
+	assert.Equal(s.T(), []int64{1, 2, 3}, []int64{entries[0].Seq, entries[1].Seq, entries[2].Seq})
+	recs, err := repo.ReadAll()
+	require.Nil(s.T(), err)
+	assert.Equal(s.T(), []entity.Cocktail{updated, base[2], created}, recs)
+}
+
+func (s *CocktailTestSuite) TestRecoverJournal() {
+	foo := entity.Cocktail{ID: 1, Name: "foo", Instructions: "foo instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "fooIngr"}}}
+	bar := entity.Cocktail{ID: 2, Name: "bar", Instructions: "bar instructions", Tags: []string{}, Ingredients: []entity.Ingredient{{Name: "barIngr"}}}
+	stale := foo
+	stale.Name = "stale"
+	tests := []struct {
+		name       string
+		checkpoint func(sum string) journalCheckpoint
+		entries    []journalEntry
+		exp        []entity.Cocktail
+	}{
+		{
+			name:    "Replayed",
+			entries: []journalEntry{{Seq: 1, Op: journalPut, ID: 1, Record: &stale}, {Seq: 2, Op: journalPurge, ID: 2}},
+			exp:     []entity.Cocktail{stale},
+		},
+		{
+			name:       "Data file replaced",
+			checkpoint: func(sum string) journalCheckpoint { return journalCheckpoint{Seq: 1, Checksum: sum} },
+			entries:    []journalEntry{{Seq: 1, Op: journalPut, ID: 1, Record: &stale}, {Seq: 2, Op: journalPurge, ID: 2}},
+			exp:        []entity.Cocktail{foo},
+		},
+		{
+			name:       "Data file not replaced",
+			checkpoint: func(string) journalCheckpoint { return journalCheckpoint{Seq: 1, Checksum: "other"} },
+			entries:    []journalEntry{{Seq: 1, Op: journalPut, ID: 1, Record: &stale}, {Seq: 2, Op: journalPurge, ID: 2}},
+			exp:        []entity.Cocktail{stale},
+		},
+		{
+			name: "Partial entry only",
+			exp:  []entity.Cocktail{foo, bar},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			csvCfg := config.NewCsv("recover.csv", s.T().TempDir()).WithMode(journalMode, 0)
+			repo := Cocktail{csv: csvCfg, checksum: &checksumCache{}}
+			_, err := writeCsvFile(csvCfg.FilePath(), []entity.Cocktail{foo, bar})
+			require.Nil(s.T(), err)
+			if tt.checkpoint != nil {
+				sum, err := fileChecksum(csvCfg.FilePath())
+				require.Nil(s.T(), err)
+				cp, err := json.Marshal(tt.checkpoint(sum))
+				require.Nil(s.T(), err)
+				require.NoError(s.T(), os.WriteFile(repo.sidePath(checkpointSuffix), cp, dataFileMode))
+			}
+			require.Nil(s.T(), appendJSONLines(repo.journalPath(), tt.entries))
+			// A crash left the last entry partially written
+			f, err := os.OpenFile(repo.journalPath(), os.O_APPEND|os.O_WRONLY, dataFileMode)
+			require.Nil(s.T(), err)
+			_, err = f.WriteString(`{"seq":3,"op":"put","id":2,"rec`)
+			require.Nil(s.T(), err)
+			require.Nil(s.T(), f.Close())
+
+			require.Nil(s.T(), repo.recoverJournal())
+			entries, err := repo.readJournal()
+			require.Nil(s.T(), err)
+			assert.Empty(s.T(), entries)
+			recs, err := repo.ReadAll()
+			require.Nil(s.T(), err)
+			assert.Equal(s.T(), tt.exp, recs)
+		})
+	}
+
+	err := Cocktail{csv: config.NewCsv("mode.csv", s.workdir).WithMode("append", 0)}.checkMode()
+	assert.IsType(s.T(), &CsvErr{}, err)
+	assert.ErrorIs(s.T(), err, ErrCsvModeInvalid)
+}
+
 func (s *CocktailTestSuite) TestFetchData() {
 	type resp struct {
 		code int
@@ -762,11 +1533,12 @@ func (s *CocktailTestSuite) TestFetchData() {
 		err  error
 	}
 	tests := []struct {
-		name string
-		url  string
-		exp  []entity.Cocktail
-		err  error
-		resp resp
+		name    string
+		url     string
+		exp     []entity.Cocktail
+		skipped []int
+		err     error
+		resp    resp
 	}{
 		{
 			name: "URL empty",
@@ -838,10 +1610,11 @@ func (s *CocktailTestSuite) TestFetchData() {
 			name: "Parse error",
 			url:  "https://foo.com/api/v1/some-endpoint",
 			exp: []entity.Cocktail{
-				{ID: 2, Name: "Afterglow", Alcoholic: "Non alcoholic", Category: "Cocktail", Ingredients: []entity.Ingredient{{Name: "Grenadine", Measure: "1 part "}, {Name: "Orange juice", Measure: "4 parts "}, {Name: "Pineapple juice", Measure: "4 parts "}}, Instructions: "Mix. Serve over ice.", Glass: "Highball Glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/vuquyv1468876052.jpg", Video: "", SrcDate: time.Date(2016, time.July, 18, 22, 7, 32, 0, time.UTC), CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)},
-				{ID: 3, Name: "Americano", Alcoholic: "Alcoholic", Category: "Ordinary Drink", Ingredients: []entity.Ingredient{{Name: "Campari", Measure: "1 oz "}, {Name: "Sweet Vermouth", Measure: "1 oz red "}, {Name: "Lemon peel", Measure: "Twist of "}, {Name: "Orange peel", Measure: "Twist of "}}, Instructions: "Pour the Campari and vermouth over ice into glass, add a splash of soda water and garnish with half orange slice.", Glass: "Collins glass", IBA: "Unforgettables", ImgAttribution: "Author - Cher37 https://commons.wikimedia.org/wiki/File:Martini_Americano.jpg", ImgSrc: "https://commons.wikimedia.org/wiki/File:Martini_Americano.jpg", Tags: "IBA,Classic", Thumb: "https://www.thecocktaildb.com/images/media/drink/709s6m1613655124.jpg", Video: "https://www.youtube.com/watch?v=TmeUJ2g3ogM", SrcDate: time.Date(2016, time.November, 4, 9, 52, 6, 0, time.UTC), CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)},
+				{ID: 2, Name: "Afterglow", Alcoholic: "Non alcoholic", Category: "Cocktail", Ingredients: []entity.Ingredient{{Name: "Grenadine", Measure: "1 part "}, {Name: "Orange juice", Measure: "4 parts "}, {Name: "Pineapple juice", Measure: "4 parts "}}, Instructions: "Mix. Serve over ice.", Glass: "Highball Glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: []string{}, Thumb: "https://www.thecocktaildb.com/images/media/drink/vuquyv1468876052.jpg", Video: "", SrcDate: time.Date(2016, time.July, 18, 22, 7, 32, 0, time.UTC), CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), Origin: entity.OriginUpstream},
+				{ID: 3, Name: "Americano", Alcoholic: "Alcoholic", Category: "Ordinary Drink", Ingredients: []entity.Ingredient{{Name: "Campari", Measure: "1 oz "}, {Name: "Sweet Vermouth", Measure: "1 oz red "}, {Name: "Lemon peel", Measure: "Twist of "}, {Name: "Orange peel", Measure: "Twist of "}}, Instructions: "Pour the Campari and vermouth over ice into glass, add a splash of soda water and garnish with half orange slice.", Glass: "Collins glass", IBA: "Unforgettables", ImgAttribution: "Author - Cher37 https://commons.wikimedia.org/wiki/File:Martini_Americano.jpg", ImgSrc: "https://commons.wikimedia.org/wiki/File:Martini_Americano.jpg", Tags: []string{"IBA", "Classic"}, Thumb: "https://www.thecocktaildb.com/images/media/drink/709s6m1613655124.jpg", Video: "https://www.youtube.com/watch?v=TmeUJ2g3ogM", SrcDate: time.Date(2016, time.November, 4, 9, 52, 6, 0, time.UTC), CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), Origin: entity.OriginUpstream},
 			},
-			err: nil,
+			skipped: []int{1},
+			err:     nil,
 			resp: resp{
 				code: http.StatusOK,
 				body: testDrinkRecsWithNoIngrs,
@@ -852,9 +1625,9 @@ func (s *CocktailTestSuite) TestFetchData() {
 			name: "All records",
 			url:  "https://foo.com/api/v1/some-endpoint",
 			exp: []entity.Cocktail{
-				{ID: 1, Name: "Acapulco", Alcoholic: "Alcoholic", Category: "Ordinary Drink", Ingredients: []entity.Ingredient{{Name: "Light rum", Measure: "1 1/2 oz "}, {Name: "Triple sec", Measure: "1 1/2 tsp "}, {Name: "Lime juice", Measure: "1 tblsp "}, {Name: "Sugar", Measure: "1 tsp "}, {Name: "Egg white", Measure: "1 "}, {Name: "Mint", Measure: "1 "}}, Instructions: "Combine and shake all ingredients (except mint) with ice and strain into an old-fashioned glass over ice cubes. Add the sprig of mint and serve.", Glass: "Old-fashioned glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/il9e0r1582478841.jpg", Video: "", SrcDate: time.Date(2016, time.September, 2, 11, 26, 16, 0, time.UTC), CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)},
-				{ID: 2, Name: "Afterglow", Alcoholic: "Non alcoholic", Category: "Cocktail", Ingredients: []entity.Ingredient{{Name: "Grenadine", Measure: "1 part "}, {Name: "Orange juice", Measure: "4 parts "}, {Name: "Pineapple juice", Measure: "4 parts "}}, Instructions: "Mix. Serve over ice.", Glass: "Highball Glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/vuquyv1468876052.jpg", Video: "", SrcDate: time.Date(2016, time.July, 18, 22, 7, 32, 0, time.UTC), CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)},
-				{ID: 3, Name: "Americano", Alcoholic: "Alcoholic", Category: "Ordinary Drink", Ingredients: []entity.Ingredient{{Name: "Campari", Measure: "1 oz "}, {Name: "Sweet Vermouth", Measure: "1 oz red "}, {Name: "Lemon peel", Measure: "Twist of "}, {Name: "Orange peel", Measure: "Twist of "}}, Instructions: "Pour the Campari and vermouth over ice into glass, add a splash of soda water and garnish with half orange slice.", Glass: "Collins glass", IBA: "Unforgettables", ImgAttribution: "Author - Cher37 https://commons.wikimedia.org/wiki/File:Martini_Americano.jpg", ImgSrc: "https://commons.wikimedia.org/wiki/File:Martini_Americano.jpg", Tags: "IBA,Classic", Thumb: "https://www.thecocktaildb.com/images/media/drink/709s6m1613655124.jpg", Video: "https://www.youtube.com/watch?v=TmeUJ2g3ogM", SrcDate: time.Date(2016, time.November, 4, 9, 52, 6, 0, time.UTC), CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)},
+				{ID: 1, Name: "Acapulco", Alcoholic: "Alcoholic", Category: "Ordinary Drink", Ingredients: []entity.Ingredient{{Name: "Light rum", Measure: "1 1/2 oz "}, {Name: "Triple sec", Measure: "1 1/2 tsp "}, {Name: "Lime juice", Measure: "1 tblsp "}, {Name: "Sugar", Measure: "1 tsp "}, {Name: "Egg white", Measure: "1 "}, {Name: "Mint", Measure: "1 "}}, Instructions: "Combine and shake all ingredients (except mint) with ice and strain into an old-fashioned glass over ice cubes. Add the sprig of mint and serve.", Glass: "Old-fashioned glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: []string{}, Thumb: "https://www.thecocktaildb.com/images/media/drink/il9e0r1582478841.jpg", Video: "", SrcDate: time.Date(2016, time.September, 2, 11, 26, 16, 0, time.UTC), CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), Origin: entity.OriginUpstream},
+				{ID: 2, Name: "Afterglow", Alcoholic: "Non alcoholic", Category: "Cocktail", Ingredients: []entity.Ingredient{{Name: "Grenadine", Measure: "1 part "}, {Name: "Orange juice", Measure: "4 parts "}, {Name: "Pineapple juice", Measure: "4 parts "}}, Instructions: "Mix. Serve over ice.", Glass: "Highball Glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: []string{}, Thumb: "https://www.thecocktaildb.com/images/media/drink/vuquyv1468876052.jpg", Video: "", SrcDate: time.Date(2016, time.July, 18, 22, 7, 32, 0, time.UTC), CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), Origin: entity.OriginUpstream},
+				{ID: 3, Name: "Americano", Alcoholic: "Alcoholic", Category: "Ordinary Drink", Ingredients: []entity.Ingredient{{Name: "Campari", Measure: "1 oz "}, {Name: "Sweet Vermouth", Measure: "1 oz red "}, {Name: "Lemon peel", Measure: "Twist of "}, {Name: "Orange peel", Measure: "Twist of "}}, Instructions: "Pour the Campari and vermouth over ice into glass, add a splash of soda water and garnish with half orange slice.", Glass: "Collins glass", IBA: "Unforgettables", ImgAttribution: "Author - Cher37 https://commons.wikimedia.org/wiki/File:Martini_Americano.jpg", ImgSrc: "https://commons.wikimedia.org/wiki/File:Martini_Americano.jpg", Tags: []string{"IBA", "Classic"}, Thumb: "https://www.thecocktaildb.com/images/media/drink/709s6m1613655124.jpg", Video: "https://www.youtube.com/watch?v=TmeUJ2g3ogM", SrcDate: time.Date(2016, time.November, 4, 9, 52, 6, 0, time.UTC), CreatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), Origin: entity.OriginUpstream},
 			},
 			err: nil,
 			resp: resp{
@@ -880,10 +1653,11 @@ func (s *CocktailTestSuite) TestFetchData() {
 				httpClient: mClient,
 			}
 
-			out, err := repo.Fetch()
+			out, skipped, err := repo.Fetch()
 			if tt.err != nil {
 				require.NotNil(t, err)
 				assert.Nil(t, out)
+				assert.Nil(t, skipped)
 				assert.IsType(t, tt.err, err)
 				if errWrp := errors.Unwrap(tt.err); errWrp != nil {
 					assert.IsType(t, errWrp, errors.Unwrap(err))
@@ -893,6 +1667,7 @@ func (s *CocktailTestSuite) TestFetchData() {
 			require.Nil(t, err)
 			assert.Len(t, tt.exp, len(out))
 			assert.Equal(t, tt.exp, out)
+			assert.Equal(t, tt.skipped, skipped)
 		})
 	}
 }
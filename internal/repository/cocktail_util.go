@@ -35,6 +35,10 @@ const (
 	srcDateIdx        csvColIdx = 13
 	createdAtIdx      csvColIdx = 14
 	updatedAtIdx      csvColIdx = 15
+	versionIdx        csvColIdx = 16
+	deletedAtIdx      csvColIdx = 17
+	originIdx         csvColIdx = 18
+	orphanedAtIdx     csvColIdx = 19
 )
 
 // csvHeadersMap are the names of the fields used in the headers/columns of the CSV file
@@ -55,6 +59,10 @@ var csvHeadersMap = map[csvColIdx]string{
 	srcDateIdx:        "source_date",
 	createdAtIdx:      "created_at",
 	updatedAtIdx:      "updated_at",
+	versionIdx:        "version",
+	deletedAtIdx:      "deleted_at",
+	originIdx:         "origin",
+	orphanedAtIdx:     "orphaned_at",
 }
 
 // csvColIdx represents the column's position in the csv file.
@@ -69,8 +77,10 @@ func (cr cocktailCsvRec) parse() (entity.Cocktail, error) {
 		return entity.Cocktail{}, ErrCSVRecEmpty
 	}
 
+	// The records written before the version, deleted_at, origin and orphaned_at columns were added lack them; they
+	// are at version zero, not deleted, and of no origin. See entity.Cocktail.Upstream.
 	numFields := len(csvHeadersMap)
-	if len(cr) != numFields {
+	if len(cr) != numFields && len(cr) != int(originIdx) && len(cr) != int(deletedAtIdx) && len(cr) != int(versionIdx) {
 		logger.Log().Warn().Str("required", fmt.Sprintf("%d/%d", len(cr), numFields)).Str("record", strings.Join(cr[:], ",")).
 			Msg("parse: wrong number of fields")
 	}
@@ -131,6 +141,38 @@ func (cr cocktailCsvRec) parse() (entity.Cocktail, error) {
 		return entity.Cocktail{}, err
 	}
 
+	version := 0
+	if rec[versionIdx] != "" {
+		version, err = strconv.Atoi(rec[versionIdx])
+		if err != nil || version < 0 {
+			logger.Log().Error().Err(err).Str("version", rec[versionIdx]).
+				Msgf("parse: Version failure")
+			return entity.Cocktail{}, ErrCocktailVersionInvalid
+		}
+	}
+
+	var deletedAt *time.Time
+	if rec[deletedAtIdx] != "" {
+		date, err := time.Parse(time.DateTime, rec[deletedAtIdx])
+		if err != nil {
+			logger.Log().Error().Err(err).Str("deleted_at", rec[deletedAtIdx]).
+				Msgf("parse: Deleted At failure")
+			return entity.Cocktail{}, err
+		}
+		deletedAt = &date
+	}
+
+	var orphanedAt *time.Time
+	if rec[orphanedAtIdx] != "" {
+		date, err := time.Parse(time.DateTime, rec[orphanedAtIdx])
+		if err != nil {
+			logger.Log().Error().Err(err).Str("orphaned_at", rec[orphanedAtIdx]).
+				Msgf("parse: Orphaned At failure")
+			return entity.Cocktail{}, err
+		}
+		orphanedAt = &date
+	}
+
 	return entity.Cocktail{
 		ID:             recID,
 		Name:           rec[nameIdx],
@@ -142,15 +184,51 @@ func (cr cocktailCsvRec) parse() (entity.Cocktail, error) {
 		IBA:            rec[ibaIdx],
 		ImgAttribution: rec[imgAttributionIdx],
 		ImgSrc:         rec[imgSrcIdx],
-		Tags:           rec[tagsIdx],
+		Tags:           parseTags(rec[tagsIdx]),
 		Thumb:          rec[thumbIdx],
 		Video:          rec[videoIdx],
 		SrcDate:        srcDate,
 		CreatedAt:      createdAt,
 		UpdatedAt:      updatedAt,
+		Version:        version,
+		DeletedAt:      deletedAt,
+		Origin:         rec[originIdx],
+		OrphanedAt:     orphanedAt,
 	}, nil
 }
 
+// parseTags returns the tags of a comma separated list, e.g. "IBA,ContemporaryClassic".
+// The tags are trimmed and their inner whitespaces collapsed; empty tags and case-insensitive duplicates are dropped.
+// Returns an empty list if the list holds no tags, so the records with no tags are rendered with an empty list.
+func parseTags(list string) []string {
+	tags := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.Join(strings.Fields(tag), " ")
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// CsvHeaders returns the names of the columns of the CSV file, in order.
+func CsvHeaders() []string {
+	headers := make([]string, len(csvHeadersMap))
+	for idx, name := range csvHeadersMap {
+		headers[idx] = name
+	}
+	return headers
+}
+
+// CsvRecord returns the record of the given entity.Cocktail in the column layout of the CSV file.
+func CsvRecord(c entity.Cocktail) ([]string, error) {
+	return parseCsvRec(c)
+}
+
 // parseCsvRec returns a valid csv record from the given entity.Cocktail.
 func parseCsvRec(c entity.Cocktail) ([]string, error) {
 	ingredients, err := json.Marshal(c.Ingredients)
@@ -169,12 +247,20 @@ func parseCsvRec(c entity.Cocktail) ([]string, error) {
 	rec[ibaIdx] = c.IBA
 	rec[imgAttributionIdx] = c.ImgAttribution
 	rec[imgSrcIdx] = c.ImgSrc
-	rec[tagsIdx] = c.Tags
+	rec[tagsIdx] = strings.Join(c.Tags, ",")
 	rec[thumbIdx] = c.Thumb
 	rec[videoIdx] = c.Video
 	rec[srcDateIdx] = c.SrcDate.Format(time.DateTime)
 	rec[createdAtIdx] = c.CreatedAt.Format(time.DateTime)
 	rec[updatedAtIdx] = c.UpdatedAt.Format(time.DateTime)
+	rec[versionIdx] = strconv.Itoa(c.Version)
+	if c.Deleted() {
+		rec[deletedAtIdx] = c.DeletedAt.Format(time.DateTime)
+	}
+	rec[originIdx] = c.Origin
+	if c.Orphaned() {
+		rec[orphanedAtIdx] = c.OrphanedAt.Format(time.DateTime)
+	}
 	return rec, nil
 }
 
@@ -328,10 +414,11 @@ func (d drink) parse() (entity.Cocktail, error) {
 		IBA:            d.IBA,
 		ImgAttribution: d.ImageAttribution,
 		ImgSrc:         d.ImageSource,
-		Tags:           d.Tags,
+		Tags:           parseTags(d.Tags),
 		Thumb:          d.DrinkThumb,
 		Video:          d.Video,
 		SrcDate:        srcDate,
+		Origin:         entity.OriginUpstream,
 	}, nil
 }
 
@@ -427,7 +514,7 @@ func (wp *workerPool) worker(id int) {
 				Msgf("worker(%d): parsing record failed, skipped.", id)
 			continue
 		}
-		if validNumType(resp.ID, wp.nType) {
+		if !resp.Deleted() && validNumType(resp.ID, wp.nType) {
 			wp.results <- resp
 		}
 	}
@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -93,6 +96,114 @@ func createDataFile(name, dir string) error {
 	return nil
 }
 
+// maxJSONLineSize is the size of the largest line read from the JSON lines files.
+const maxJSONLineSize = 1024 * 1024
+
+// scanJSONLines reads the given JSON lines file line by line and calls fn with each line, until fn returns false.
+// A missing file is read as an empty one.
+func scanJSONLines(file string, fn func(line []byte) bool) error {
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			logger.Log().Error().Err(err).Str("file", file).Msg("scanJSONLines: close file failed")
+		}
+	}()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLineSize)
+	for scanner.Scan() {
+		if !fn(scanner.Bytes()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// reverseReadSize is the size of the blocks read by scanJSONLinesReverse.
+const reverseReadSize = 64 * 1024
+
+// scanJSONLinesReverse reads the given JSON lines file from its end, line by line, and calls fn with each line,
+// until fn returns false. The file is read by blocks, so reading the last lines of a large file is cheap.
+// The blank lines are skipped. A missing file is read as an empty one.
+func scanJSONLinesReverse(file string, fn func(line []byte) bool) error {
+	f, err := os.Open(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			logger.Log().Error().Err(err).Str("file", file).Msg("scanJSONLinesReverse: close file failed")
+		}
+	}()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// rest holds the beginning of the file not split into lines yet, up to the lines already read.
+	var rest []byte
+	block := make([]byte, reverseReadSize)
+	for end := info.Size(); end > 0; {
+		n := int64(len(block))
+		if end < n {
+			n = end
+		}
+		end -= n
+		if _, err := f.ReadAt(block[:n], end); err != nil {
+			return err
+		}
+		rest = append(append(make([]byte, 0, int(n)+len(rest)), block[:n]...), rest...)
+		for i := bytes.LastIndexByte(rest, '\n'); i >= 0; i = bytes.LastIndexByte(rest, '\n') {
+			if line := rest[i+1:]; len(bytes.TrimSpace(line)) > 0 && !fn(line) {
+				return nil
+			}
+			rest = rest[:i]
+		}
+		if len(rest) > maxJSONLineSize {
+			return bufio.ErrTooLong
+		}
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		fn(rest)
+	}
+	return nil
+}
+
+// appendJSONLines appends the given values to the JSON lines file, one per line, and syncs it to disk.
+// The file is created with 0600 permissions if it does not exist.
+func appendJSONLines[T any](file string, values []T) error {
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, dataFileMode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			logger.Log().Error().Err(err).Str("file", file).Msg("appendJSONLines: close file failed")
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, v := range values {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
 // checkEndpoint consumes and validates the given endpoint.
 // It consumes the given endpoint using the GET method.
 // The scheme, domain and path properties are mandatory.
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -293,6 +294,54 @@ func (s *FileTestSuite) TestCreateDataFile() {
 	}
 }
 
+func (s *FileTestSuite) TestScanJSONLinesReverse() {
+	large := make([]string, 0, 3000)
+	for i := 1; i <= 3000; i++ {
+		large = append(large, fmt.Sprintf(`{"seq":%d,"padding":"%s"}`, i, strings.Repeat("x", 32)))
+	}
+	tests := []struct {
+		name    string
+		content *string
+		limit   int
+		exp     []string
+	}{
+		{name: "Missing file", exp: []string{}},
+		{name: "Empty file", content: ptrString(""), exp: []string{}},
+		{name: "Last line", content: ptrString("{\"seq\":1}\n{\"seq\":2}\n"), limit: 1, exp: []string{`{"seq":2}`}},
+		{name: "Blank and partial lines", content: ptrString("{\"seq\":1}\n\n{\"seq\":2}\n{\"se"), exp: []string{`{"se`, `{"seq":2}`, `{"seq":1}`}},
+		{name: "Several blocks", content: ptrString(strings.Join(large, "\n") + "\n"), exp: reversed(large)},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(s.workDir, "reverse.jsonl")
+			require.NoError(t, os.RemoveAll(file))
+			if tt.content != nil {
+				require.NoError(t, os.WriteFile(file, []byte(*tt.content), dataFileMode))
+			}
+
+			lines := make([]string, 0)
+			err := scanJSONLinesReverse(file, func(line []byte) bool {
+				lines = append(lines, string(line))
+				return tt.limit == 0 || len(lines) < tt.limit
+			})
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp, lines)
+		})
+	}
+}
+
+func ptrString(s string) *string {
+	return &s
+}
+
+func reversed(lines []string) []string {
+	out := make([]string, 0, len(lines))
+	for i := len(lines) - 1; i >= 0; i-- {
+		out = append(out, lines[i])
+	}
+	return out
+}
+
 func TestCheckEndpoint(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -3,6 +3,20 @@ package repository
 import (
 	"errors"
 	"fmt"
+	"net/http"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+)
+
+// The stable codes of the repository errors, responded by the API.
+const (
+	CsvErrCode        = "repository.csv"
+	DataAPIErrCode    = "repository.data_api"
+	ChangeLogErrCode  = "repository.change_log"
+	RevisionErrCode   = "repository.revision_log"
+	SnapshotErrCode   = "repository.snapshot"
+	WebhookErrCode    = "repository.webhook"
+	WorkerPoolErrCode = "repository.worker_pool"
 )
 
 var (
@@ -21,10 +35,29 @@ var (
 	ErrCocktailNameEmpty         = errors.New("cocktail name empty")
 	ErrCocktailInstructionsEmpty = errors.New("cocktail instructions empty")
 	ErrCocktailIngredientsEmpty  = errors.New("cocktail ingredients empty")
+	ErrCocktailVersionInvalid    = errors.New("cocktail version invalid")
+
+	ErrTxIDInvalid = errors.New("invalid sync transaction id")
+
+	ErrLockTimeout    = errors.New("database lock timeout")
+	ErrCsvModeInvalid = errors.New("invalid csv database mode, must be replace or journal")
 
 	ErrWPInvalidArgs = errors.New("worker pool: invalid arguments")
 )
 
+// RegisterProblems contributes the repository errors to the given problems catalog.
+func RegisterProblems(reg *ct.ProblemRegistry) {
+	reg.Register(
+		ct.ProblemType{Code: CsvErrCode, Title: "Database file error", Status: http.StatusInternalServerError, Match: ct.MatchAs[*CsvErr]()},
+		ct.ProblemType{Code: DataAPIErrCode, Title: "Data API unavailable", Status: http.StatusBadGateway, Match: ct.MatchAs[*DataApiErr]()},
+		ct.ProblemType{Code: ChangeLogErrCode, Title: "Change log error", Status: http.StatusInternalServerError, Match: ct.MatchAs[*ChangeLogErr]()},
+		ct.ProblemType{Code: RevisionErrCode, Title: "Revision log error", Status: http.StatusInternalServerError, Match: ct.MatchAs[*RevisionLogErr]()},
+		ct.ProblemType{Code: SnapshotErrCode, Title: "Snapshot error", Status: http.StatusInternalServerError, Match: ct.MatchAs[*SnapshotErr]()},
+		ct.ProblemType{Code: WebhookErrCode, Title: "Webhook storage error", Status: http.StatusInternalServerError, Match: ct.MatchAs[*WebhookErr]()},
+		ct.ProblemType{Code: WorkerPoolErrCode, Title: "Worker pool error", Status: http.StatusInternalServerError, Match: ct.MatchIs(ErrWPInvalidArgs)},
+	)
+}
+
 // CsvErr covers all errors related to CSV operations and wraps the error that caused it.
 type CsvErr struct {
 	Err error
@@ -50,3 +83,55 @@ func (e DataApiErr) Error() string {
 func (e DataApiErr) Unwrap() error {
 	return e.Err
 }
+
+// ChangeLogErr covers all errors related to the change log operations and wraps the error that caused it.
+type ChangeLogErr struct {
+	Err error
+}
+
+func (e ChangeLogErr) Error() string {
+	return fmt.Sprintf("change log: %s", e.Err)
+}
+
+func (e ChangeLogErr) Unwrap() error {
+	return e.Err
+}
+
+// RevisionLogErr covers all errors related to the revision log operations and wraps the error that caused it.
+type RevisionLogErr struct {
+	Err error
+}
+
+func (e RevisionLogErr) Error() string {
+	return fmt.Sprintf("revision log: %s", e.Err)
+}
+
+func (e RevisionLogErr) Unwrap() error {
+	return e.Err
+}
+
+// SnapshotErr covers all errors related to the database snapshots and wraps the error that caused it.
+type SnapshotErr struct {
+	Err error
+}
+
+func (e SnapshotErr) Error() string {
+	return fmt.Sprintf("snapshot: %s", e.Err)
+}
+
+func (e SnapshotErr) Unwrap() error {
+	return e.Err
+}
+
+// WebhookErr covers all errors related to the webhook operations and wraps the error that caused it.
+type WebhookErr struct {
+	Err error
+}
+
+func (e WebhookErr) Error() string {
+	return fmt.Sprintf("webhook: %s", e.Err)
+}
+
+func (e WebhookErr) Unwrap() error {
+	return e.Err
+}
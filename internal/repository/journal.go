@@ -0,0 +1,561 @@
+package repository
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+)
+
+const (
+	// replaceMode rewrites the CSV data file on every write.
+	replaceMode = "replace"
+
+	// journalMode appends the single-record writes to the journal, folded into the CSV data file by the compactions.
+	journalMode = "journal"
+
+	// journalSuffix names the journal of the CSV database, next to it. e.g. "cocktails_journal.jsonl"
+	journalSuffix = "_journal.jsonl"
+
+	// checkpointSuffix names the checkpoint of the journal, next to it. e.g. "cocktails_journal.checkpoint"
+	checkpointSuffix = "_journal.checkpoint"
+
+	// defaultCompactThreshold is the number of journal entries past which the journal is compacted, unless configured.
+	defaultCompactThreshold = 1000
+)
+
+// The operations of the journal entries.
+const (
+	journalPut   = "put"
+	journalPurge = "purge"
+)
+
+// journalEntry is a single-record write of the journal: a put writes the record, created or replaced by its ID,
+// and a purge removes the record of the ID.
+type journalEntry struct {
+	Seq    int64            `json:"seq"`
+	Op     string           `json:"op"`
+	ID     int              `json:"id"`
+	Record *entity.Cocktail `json:"record,omitempty"`
+}
+
+// journalCheckpoint records the last journal entry folded into the CSV data file, and the checksum of the file
+// holding it. It is written before the file is replaced, so the recovery tells whether the replacement happened.
+type journalCheckpoint struct {
+	Seq      int64  `json:"seq"`
+	Checksum string `json:"checksum"`
+}
+
+// journalState holds the records of the CSV database with the journal applied, by ID, along with the number of
+// journal entries and the last sequence number, as of the stamps of the database files. It is shared by the copies
+// of the Cocktail repository, and accessed under the exclusive lock, so the single-record writes check and log the
+// record they write without reading the whole database.
+type journalState struct {
+	stamps  []fileStamp
+	recs    map[int]entity.Cocktail
+	entries int
+	seq     int64
+}
+
+// reset drops the state, so it is loaded again on the next write.
+func (st *journalState) reset() {
+	if st == nil {
+		return
+	}
+	st.stamps = nil
+	st.recs = nil
+}
+
+// fresh reports whether the state still holds the content of the files of the given stamps.
+func (st *journalState) fresh(stamps []fileStamp) bool {
+	if st.recs == nil {
+		return false
+	}
+	idx := cocktailIndex{stamps: st.stamps}
+	return idx.fresh(stamps)
+}
+
+// journalState returns the state of the journaled database, loading it if it is missing or stale, such as once
+// another process wrote the database. Must be called with the exclusive lock held.
+func (c Cocktail) journalState() (*journalState, error) {
+	st := c.journal
+	if st == nil {
+		st = &journalState{}
+	}
+	stamps, err := fileStamps(c.dbFiles())
+	if err != nil {
+		return nil, &CsvErr{err}
+	}
+	if st.fresh(stamps) {
+		return st, nil
+	}
+
+	recs, err := c.readJournaled(true)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := c.readJournal()
+	if err != nil {
+		return nil, err
+	}
+	seq, err := c.lastJournalSeq(entries)
+	if err != nil {
+		return nil, err
+	}
+	st.recs = make(map[int]entity.Cocktail, len(recs))
+	for _, rec := range recs {
+		st.recs[rec.ID] = rec
+	}
+	st.stamps, st.entries, st.seq = stamps, len(entries), seq
+	logger.Log().Debug().Int("records", len(recs)).Int("entries", len(entries)).Msg("journalState: journal state loaded")
+	return st, nil
+}
+
+// checkMode validates the configured write mode of the CSV database.
+func (c Cocktail) checkMode() error {
+	switch c.csv.Mode() {
+	case "", replaceMode, journalMode:
+		return nil
+	default:
+		return &CsvErr{fmt.Errorf("%w: %q", ErrCsvModeInvalid, c.csv.Mode())}
+	}
+}
+
+// journaled reports whether the CSV database is in journal mode.
+func (c Cocktail) journaled() bool {
+	return c.csv.Mode() == journalMode
+}
+
+// journalPath returns the path of the journal file. See journalSuffix.
+func (c Cocktail) journalPath() string {
+	return c.sidePath(journalSuffix)
+}
+
+// compactThreshold returns the configured number of journal entries past which the journal is compacted.
+func (c Cocktail) compactThreshold() int {
+	if n := c.csv.CompactThreshold(); n > 0 {
+		return n
+	}
+	return defaultCompactThreshold
+}
+
+// dbFiles returns the files holding the database content: the CSV data file, followed by the journal if it exists.
+func (c Cocktail) dbFiles() []string {
+	files := []string{c.csv.FilePath()}
+	if c.journaled() {
+		if _, err := os.Stat(c.journalPath()); err == nil {
+			files = append(files, c.journalPath())
+		}
+	}
+	return files
+}
+
+// readJournal returns the entries of the journal, in sequence order.
+// The lines that can not be parsed are skipped, such as the last one if a crash left it partially written.
+func (c Cocktail) readJournal() ([]journalEntry, error) {
+	entries := make([]journalEntry, 0)
+	err := scanJSONLines(c.journalPath(), func(line []byte) bool {
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			logger.Log().Warn().Err(err).Str("file", c.journalPath()).Msg("readJournal: parsing entry failed, skipped")
+			return true
+		}
+		entries = append(entries, entry)
+		return true
+	})
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", c.journalPath()).Msg("readJournal: read journal failed")
+		return nil, &CsvErr{err}
+	}
+	return entries, nil
+}
+
+// readCheckpoint returns the checkpoint of the journal, the zero one if there is none.
+func (c Cocktail) readCheckpoint() (journalCheckpoint, error) {
+	var cp journalCheckpoint
+	data, err := os.ReadFile(c.sidePath(checkpointSuffix))
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &cp)
+	}
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", c.sidePath(checkpointSuffix)).Msg("readCheckpoint: read checkpoint failed")
+		return cp, &CsvErr{err}
+	}
+	return cp, nil
+}
+
+// lastJournalSeq returns the sequence number of the last journal entry, or of the checkpoint if the journal is
+// empty, so the sequence numbers keep increasing past the folded entries.
+func (c Cocktail) lastJournalSeq(entries []journalEntry) (int64, error) {
+	if n := len(entries); n > 0 {
+		return entries[n-1].Seq, nil
+	}
+	cp, err := c.readCheckpoint()
+	return cp.Seq, err
+}
+
+// applyJournal returns the given records with the given journal entries applied, in sequence order.
+// A put replaces the record of its ID in place, or appends it; a purge removes the record of its ID.
+func applyJournal(recs []entity.Cocktail, entries []journalEntry) []entity.Cocktail {
+	if len(entries) == 0 {
+		return recs
+	}
+	positions := make(map[int]int, len(recs))
+	for i, rec := range recs {
+		positions[rec.ID] = i
+	}
+	purged := make(map[int]bool)
+	for _, entry := range entries {
+		switch entry.Op {
+		case journalPut:
+			if entry.Record == nil {
+				continue
+			}
+			if i, found := positions[entry.ID]; found {
+				recs[i] = *entry.Record
+			} else {
+				positions[entry.ID] = len(recs)
+				recs = append(recs, *entry.Record)
+			}
+			delete(purged, entry.ID)
+		case journalPurge:
+			if _, found := positions[entry.ID]; found {
+				purged[entry.ID] = true
+			}
+		}
+	}
+	if len(purged) == 0 {
+		return recs
+	}
+
+	kept := make([]entity.Cocktail, 0, len(recs)-len(purged))
+	for _, rec := range recs {
+		if !purged[rec.ID] {
+			kept = append(kept, rec)
+		}
+	}
+	return kept
+}
+
+// readJournaled returns the records of the CSV data file with the journal applied, including the deleted ones if
+// withDeleted is set.
+func (c Cocktail) readJournaled(withDeleted bool) ([]entity.Cocktail, error) {
+	recs := make([]entity.Cocktail, 0)
+	err := c.stream(context.Background(), true, func(cocktail entity.Cocktail) error {
+		recs = append(recs, cocktail)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := c.readJournal()
+	if err != nil {
+		return nil, err
+	}
+	recs = applyJournal(recs, entries)
+	if withDeleted {
+		return recs, nil
+	}
+
+	live := make([]entity.Cocktail, 0, len(recs))
+	for _, rec := range recs {
+		if !rec.Deleted() {
+			live = append(live, rec)
+		}
+	}
+	return live, nil
+}
+
+// PutRecord writes the given entity.Cocktail record, replacing the one of its ID or appending it, provided the
+// current record is at the given version, zero meaning it does not exist yet, and logs the write as ReplaceDB does.
+// The version is checked and the record written under the exclusive lock, so no other write is made in between.
+// In journal mode, the record is appended to the journal, compacted once it is past the threshold; otherwise the
+// database is replaced. Returns a ct.VersionConflictErr if the record is not at the given version.
+func (c Cocktail) PutRecord(rec entity.Cocktail, version int, author entity.Author) error {
+	return c.writeRecord(journalEntry{Op: journalPut, ID: rec.ID, Record: &rec}, version, author)
+}
+
+// PurgeRecord removes the entity.Cocktail record of the given ID for good, provided it is at the given version,
+// and logs the write as ReplaceDB does. The version is checked as PutRecord does.
+// In journal mode, the purge is appended to the journal, compacted once it is past the threshold; otherwise the
+// database is replaced.
+func (c Cocktail) PurgeRecord(id, version int, author entity.Author) error {
+	return c.writeRecord(journalEntry{Op: journalPurge, ID: id}, version, author)
+}
+
+// writeRecord writes the given single-record journal entry under the exclusive lock. See PutRecord.
+// In journal mode, the entry is appended and the record alone logged, so the write does not read the whole database,
+// but the first time the change or revision log is written. Once the entry is appended, the write succeeds even if
+// logging it fails. See logWritten.
+func (c Cocktail) writeRecord(entry journalEntry, version int, author entity.Author) error {
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !c.journaled() {
+		prevRecs, err := c.readAll(true)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(prevRecs, entry.ID, version); err != nil {
+			return err
+		}
+		recs := applyJournal(append(make([]entity.Cocktail, 0, len(prevRecs)+1), prevRecs...), []journalEntry{entry})
+		return c.replace(prevRecs, recs, author)
+	}
+
+	st, err := c.journalState()
+	if err != nil {
+		return err
+	}
+	prev, found := st.recs[entry.ID]
+	current := 0
+	if found {
+		current = prev.Version
+	}
+	if current != version {
+		return &ct.VersionConflictErr{ID: entry.ID, Version: version, Current: current}
+	}
+
+	// The previous and written records, the whole database if a log is empty, as it logs the whole database then.
+	prevRecs, recs := make([]entity.Cocktail, 0, 1), make([]entity.Cocktail, 0, 1)
+	if found {
+		prevRecs = append(prevRecs, prev)
+	}
+	if entry.Op == journalPut && entry.Record != nil {
+		recs = append(recs, *entry.Record)
+	}
+	seeded, err := c.logsSeeded()
+	if err != nil {
+		return err
+	}
+	if !seeded {
+		if prevRecs, err = c.readAll(true); err != nil {
+			return err
+		}
+		recs = applyJournal(append(make([]entity.Cocktail, 0, len(prevRecs)+1), prevRecs...), []journalEntry{entry})
+	}
+
+	entry.Seq = st.seq + 1
+	if err := appendJSONLines(c.journalPath(), []journalEntry{entry}); err != nil {
+		st.reset()
+		logger.Log().Error().Err(err).Str("file", c.journalPath()).Msg("writeRecord: append journal entry failed")
+		return &CsvErr{err}
+	}
+	c.checksum.invalidate()
+	switch {
+	case entry.Op == journalPut && entry.Record != nil:
+		st.recs[entry.ID] = *entry.Record
+	case entry.Op == journalPurge:
+		delete(st.recs, entry.ID)
+	}
+	st.seq, st.entries = entry.Seq, st.entries+1
+	if st.stamps, err = fileStamps(c.dbFiles()); err != nil {
+		st.reset()
+	}
+	c.logWritten(prevRecs, recs, author)
+
+	if st.entries >= c.compactThreshold() {
+		if _, err := c.compact(); err != nil {
+			logger.Log().Error().Err(err).Msg("writeRecord: automatic compaction failed")
+		}
+	}
+	return nil
+}
+
+// Compact folds the journal into the CSV data file under the exclusive lock, and returns the number of folded
+// entries. The database content is unchanged, so nothing is logged. Nothing is folded in replace mode.
+func (c Cocktail) Compact() (int, error) {
+	if !c.journaled() {
+		return 0, nil
+	}
+	unlock, err := c.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	return c.compact()
+}
+
+// compact is Compact, with the exclusive lock held.
+func (c Cocktail) compact() (int, error) {
+	entries, err := c.readJournal()
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+	recs, err := c.readAll(true)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := c.writeBase(recs); err != nil {
+		return 0, err
+	}
+	c.checksum.invalidate()
+	logger.Log().Info().Int("entries", len(entries)).Int("records", len(recs)).Msg("compact: journal compacted")
+	return len(entries), nil
+}
+
+// writeJournaledBase replaces the CSV data file with the given records, folding the journal, and returns the
+// written records. The records are written to a temporary file, and the checkpoint holding its checksum before the
+// file replaces the data file, so a crash at any step is recovered by recoverJournal. The journal is emptied last.
+func (c Cocktail) writeJournaledBase(cocktails []entity.Cocktail) ([]entity.Cocktail, error) {
+	file := c.csv.FilePath()
+	entries, err := c.readJournal()
+	if err != nil {
+		return nil, err
+	}
+	seq, err := c.lastJournalSeq(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp := file + ".tmp"
+	written, err := writeCsvFile(tmp, cocktails)
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", tmp).Msg("writeJournaledBase: write csv file failed")
+		_ = os.Remove(tmp)
+		return nil, &CsvErr{err}
+	}
+	sum, err := fileChecksum(tmp)
+	if err != nil {
+		_ = os.Remove(tmp)
+		return nil, &CsvErr{err}
+	}
+	cp, err := json.Marshal(journalCheckpoint{Seq: seq, Checksum: sum})
+	if err == nil {
+		err = writeFileSynced(c.sidePath(checkpointSuffix), cp)
+	}
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", c.sidePath(checkpointSuffix)).Msg("writeJournaledBase: write checkpoint failed")
+		_ = os.Remove(tmp)
+		return nil, &CsvErr{err}
+	}
+	c.journal.reset()
+	if err := os.Rename(tmp, file); err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("writeJournaledBase: replace csv file failed")
+		return nil, &CsvErr{err}
+	}
+	if err := os.Truncate(c.journalPath(), 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Log().Error().Err(err).Str("file", c.journalPath()).Msg("writeJournaledBase: empty journal failed")
+		return nil, &CsvErr{err}
+	}
+	return written, nil
+}
+
+// recoverJournal replays the journal left by a previous run, possibly ended by a crash, folding it into the CSV data
+// file. The entries already folded by a replacement of the data file, told by the checkpoint, are dropped; if the
+// data file was not replaced, they were not folded yet, so all the entries are replayed.
+func (c Cocktail) recoverJournal() error {
+	unlock, err := c.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := c.readJournal()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		// Emptied still, as it may hold a partially written entry, which the next entry would be appended to.
+		if err := os.Truncate(c.journalPath(), 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return &CsvErr{err}
+		}
+		return nil
+	}
+	cp, err := c.readCheckpoint()
+	if err != nil {
+		return err
+	}
+	if cp.Seq > 0 && entries[0].Seq <= cp.Seq {
+		sum, err := fileChecksum(c.csv.FilePath())
+		if err != nil {
+			return &CsvErr{err}
+		}
+		if sum == cp.Checksum {
+			pending := make([]journalEntry, 0, len(entries))
+			for _, entry := range entries {
+				if entry.Seq > cp.Seq {
+					pending = append(pending, entry)
+				}
+			}
+			logger.Log().Warn().Int("dropped", len(entries)-len(pending)).Msg("recoverJournal: folded entries dropped")
+			if err := c.rewriteJournal(pending); err != nil {
+				return err
+			}
+		}
+	}
+
+	n, err := c.compact()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		logger.Log().Info().Int("entries", n).Str("file", c.journalPath()).Msg("recoverJournal: journal replayed")
+	}
+	return nil
+}
+
+// rewriteJournal replaces the journal with the given entries.
+func (c Cocktail) rewriteJournal(entries []journalEntry) error {
+	tmp := c.journalPath() + ".tmp"
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return &CsvErr{err}
+	}
+	if err := appendJSONLines(tmp, entries); err != nil {
+		return &CsvErr{err}
+	}
+	if err := os.Rename(tmp, c.journalPath()); err != nil {
+		return &CsvErr{err}
+	}
+	return nil
+}
+
+// writeCsvFile writes the given records to the given file, truncated, and syncs it to disk.
+// Returns the written records; the ones that can not be written are discarded. See writeCsvRecs.
+func writeCsvFile(name string, cocktails []entity.Cocktail) ([]entity.Cocktail, error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, dataFileMode)
+	if err != nil {
+		return nil, err
+	}
+	written, err := writeCsvRecs(csv.NewWriter(f), cocktails)
+	if err == nil {
+		err = f.Sync()
+	}
+	if errC := f.Close(); err == nil {
+		err = errC
+	}
+	return written, err
+}
+
+// writeFileSynced writes the given data to the given file through a temporary file renamed once synced to disk.
+func writeFileSynced(name string, data []byte) error {
+	tmp := name + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, dataFileMode)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if errC := f.Close(); err == nil {
+		err = errC
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, name)
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+)
+
+const (
+	// lockFileSuffix names the lock file of the CSV database, next to it. e.g. "cocktails.lock"
+	// The data file itself is not locked, as it is replaced by the writes.
+	lockFileSuffix = ".lock"
+
+	// defaultLockTimeout is how long the accesses wait for the lock, unless configured.
+	defaultLockTimeout = 5 * time.Second
+
+	// lockRetryInterval is how long the accesses wait between two attempts to take the lock.
+	lockRetryInterval = 10 * time.Millisecond
+)
+
+// rwLocks holds the in-process rwLock of every CSV database file, by path, so the repositories of a same file share it.
+var rwLocks sync.Map
+
+// rwLock is an in-process reader/writer lock whose acquisitions time out.
+// A writer holds the gate from the time it waits for the lock, so the new readers wait behind it rather than
+// starving it, and the writers are serialized.
+type rwLock struct {
+	gate chan struct{}
+	mu   sync.RWMutex
+}
+
+// fileRWLock returns the in-process rwLock of the given file.
+func fileRWLock(name string) *rwLock {
+	l, _ := rwLocks.LoadOrStore(filepath.Clean(name), &rwLock{gate: make(chan struct{}, 1)})
+	return l.(*rwLock)
+}
+
+// acquire takes the lock, exclusive or shared, by the given deadline. Returns whether it was taken.
+func (l *rwLock) acquire(exclusive bool, deadline time.Time) bool {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case l.gate <- struct{}{}:
+	case <-timer.C:
+		return false
+	}
+
+	if !exclusive {
+		// No writer holds the lock without the gate, so the readers wait for none.
+		l.mu.RLock()
+		<-l.gate
+		return true
+	}
+	if !retryUntil(deadline, l.mu.TryLock) {
+		<-l.gate
+		return false
+	}
+	return true
+}
+
+// release releases the lock taken by acquire.
+func (l *rwLock) release(exclusive bool) {
+	if !exclusive {
+		l.mu.RUnlock()
+		return
+	}
+	l.mu.Unlock()
+	<-l.gate
+}
+
+// rlock takes the shared lock of the CSV database, held by the reads, and returns the function releasing it.
+// See lockDB.
+func (c Cocktail) rlock() (func(), error) {
+	return c.lockDB(false)
+}
+
+// lock takes the exclusive lock of the CSV database, held by the writes, and returns the function releasing it.
+// See lockDB.
+func (c Cocktail) lock() (func(), error) {
+	return c.lockDB(true)
+}
+
+// lockDB takes the lock of the CSV database, exclusive or shared, and returns the function releasing it.
+// The lock is taken in-process first, then on the lock file with an advisory flock, so the database is shared
+// safely with the other processes using the same data directory, such as other server instances.
+// Returns a CsvErr wrapping ErrLockTimeout if the lock is not taken within the configured lock timeout.
+func (c Cocktail) lockDB(exclusive bool) (func(), error) {
+	timeout := c.csv.LockTimeout()
+	if timeout <= 0 {
+		timeout = defaultLockTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	file := c.csv.FilePath()
+
+	l := fileRWLock(file)
+	if !l.acquire(exclusive, deadline) {
+		logger.Log().Error().Str("file", file).Bool("exclusive", exclusive).Msg("lockDB: lock timeout, in-process lock held")
+		return nil, &CsvErr{fmt.Errorf("%w: %s after %s", ErrLockTimeout, file, timeout)}
+	}
+
+	name := c.sidePath(lockFileSuffix)
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, dataFileMode)
+	if err != nil {
+		l.release(exclusive)
+		logger.Log().Error().Err(err).Str("file", name).Msg("lockDB: open lock file failed")
+		return nil, &CsvErr{err}
+	}
+	var errF error
+	locked := retryUntil(deadline, func() bool {
+		var ok bool
+		ok, errF = tryFlock(f, exclusive)
+		return ok || errF != nil
+	})
+	if errF != nil || !locked {
+		closeLockFile(f)
+		l.release(exclusive)
+		if errF != nil {
+			logger.Log().Error().Err(errF).Str("file", name).Msg("lockDB: lock file failed")
+			return nil, &CsvErr{errF}
+		}
+		logger.Log().Error().Str("file", name).Bool("exclusive", exclusive).Msg("lockDB: lock timeout, file lock held")
+		return nil, &CsvErr{fmt.Errorf("%w: %s after %s", ErrLockTimeout, file, timeout)}
+	}
+
+	return func() {
+		if err := funlock(f); err != nil {
+			logger.Log().Error().Err(err).Str("file", name).Msg("lockDB: unlock file failed")
+		}
+		closeLockFile(f)
+		l.release(exclusive)
+	}, nil
+}
+
+// closeLockFile closes the given lock file, releasing its flock if still held.
+func closeLockFile(f *os.File) {
+	if err := f.Close(); err != nil {
+		logger.Log().Error().Err(err).Str("file", f.Name()).Msg("closeLockFile: close lock file failed")
+	}
+}
+
+// retryUntil calls try until it succeeds or the given deadline passes, waiting lockRetryInterval between the calls.
+// Returns whether try succeeded.
+func retryUntil(deadline time.Time, try func() bool) bool {
+	for {
+		if try() {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
@@ -0,0 +1,49 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package repository
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockDB_Flock(t *testing.T) {
+	tests := []struct {
+		name      string
+		held      bool
+		exclusive bool
+		err       error
+	}{
+		{name: "Shared held, read", exclusive: false},
+		{name: "Shared held, write", exclusive: true, err: ErrLockTimeout},
+		{name: "Exclusive held, read", held: true, exclusive: false, err: ErrLockTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := Cocktail{csv: config.NewCsv("flock.csv", t.TempDir()).WithLockTimeout(30 * time.Millisecond)}
+			// The lock file is held through another open file, as another process would.
+			f, err := os.OpenFile(repo.sidePath(lockFileSuffix), os.O_CREATE|os.O_RDWR, dataFileMode)
+			require.Nil(t, err)
+			defer closeLockFile(f)
+			ok, err := tryFlock(f, tt.held)
+			require.Nil(t, err)
+			require.True(t, ok)
+
+			unlock, err := repo.lockDB(tt.exclusive)
+			if tt.err != nil {
+				assert.IsType(t, &CsvErr{}, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			unlock()
+		})
+	}
+}
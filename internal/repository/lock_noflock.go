@@ -0,0 +1,16 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package repository
+
+import "os"
+
+// tryFlock takes no lock on the platforms lacking flock, where the CSV database is only locked in-process, so it
+// must not be shared with other processes.
+func tryFlock(*os.File, bool) (bool, error) {
+	return true, nil
+}
+
+// funlock releases nothing on the platforms lacking flock.
+func funlock(*os.File) error {
+	return nil
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+)
+
+// revisionLogSuffix is appended to the CSV database file name, without extension, to name its revision log file.
+// e.g. the revision log of "cocktails.csv" is "cocktails_revisions.jsonl"
+const revisionLogSuffix = "_revisions.jsonl"
+
+// untrackedFields are the record fields set by every write, so they are left out of the revision diffs.
+var untrackedFields = map[string]bool{"updated_at": true, "version": true}
+
+// revisionLogPath returns the full path of the revision log file, which lives next to the CSV database file.
+// The revision log holds an entity.Revision per line in JSON format, in sequence order.
+func (c Cocktail) revisionLogPath() string {
+	return c.sidePath(revisionLogSuffix)
+}
+
+// ReadHistory returns the revisions of the record of the given ID, in sequence order.
+// If the record has no revisions, an empty list is returned.
+func (c Cocktail) ReadHistory(id int) ([]entity.Revision, error) {
+	revs := make([]entity.Revision, 0)
+	err := c.scanRevisions(func(rev entity.Revision) bool {
+		if rev.ID == id {
+			revs = append(revs, rev)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return revs, nil
+}
+
+// ReadAsOf returns the database records as they were at the given time, replaying the revision log up to it.
+// The records are returned in creation order. If the revision log is empty, the history is unknown and the
+// current records created up to the given time are returned instead.
+func (c Cocktail) ReadAsOf(t time.Time) ([]entity.Cocktail, error) {
+	logged := false
+	state := make(map[int]*entity.Cocktail)
+	order := make([]int, 0)
+	err := c.scanRevisions(func(rev entity.Revision) bool {
+		logged = true
+		if rev.Time.After(t) {
+			return true
+		}
+		if _, found := state[rev.ID]; !found {
+			order = append(order, rev.ID)
+		}
+		state[rev.ID] = rev.Cocktail
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !logged {
+		current, err := c.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		recs := make([]entity.Cocktail, 0, len(current))
+		for _, rec := range current {
+			if !rec.CreatedAt.After(t) {
+				recs = append(recs, rec)
+			}
+		}
+		return recs, nil
+	}
+	return liveRecords(order, state), nil
+}
+
+// revisedRecords returns the live records as the revision log holds them, replaying it, in creation order.
+func (c Cocktail) revisedRecords() ([]entity.Cocktail, error) {
+	state := make(map[int]*entity.Cocktail)
+	order := make([]int, 0)
+	err := c.scanRevisions(func(rev entity.Revision) bool {
+		if _, found := state[rev.ID]; !found {
+			order = append(order, rev.ID)
+		}
+		state[rev.ID] = rev.Cocktail
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return liveRecords(order, state), nil
+}
+
+// scanRevisions reads the revision log and calls fn with each revision, until fn returns false.
+// Malformed revisions are skipped.
+func (c Cocktail) scanRevisions(fn func(entity.Revision) bool) error {
+	file := c.revisionLogPath()
+	err := scanJSONLines(file, func(line []byte) bool {
+		var rev entity.Revision
+		if err := json.Unmarshal(line, &rev); err != nil {
+			logger.Log().Warn().Err(err).Str("file", file).Msg("scanRevisions: parsing revision failed, skipped")
+			return true
+		}
+		return fn(rev)
+	})
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("scanRevisions: read revision log failed")
+		return &RevisionLogErr{err}
+	}
+	return nil
+}
+
+// lastRevisionSeq returns the sequence number of the last revision in the revision log, or zero if it is empty.
+// The revision log is read from its end, so its size does not matter.
+func (c Cocktail) lastRevisionSeq() (int64, error) {
+	file := c.revisionLogPath()
+	var seq int64
+	err := scanJSONLinesReverse(file, func(line []byte) bool {
+		var rev entity.Revision
+		if err := json.Unmarshal(line, &rev); err != nil {
+			logger.Log().Warn().Err(err).Str("file", file).Msg("lastRevisionSeq: parsing revision failed, skipped")
+			return true
+		}
+		seq = rev.Seq
+		return false
+	})
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("lastRevisionSeq: read revision log failed")
+		return 0, &RevisionLogErr{err}
+	}
+	return seq, nil
+}
+
+// appendRevisions appends the revisions turning the old records into the new ones to the revision log, numbering
+// them after the last logged revision. The first time, the old records are logged as imported first, dated by
+// their UpdatedAt or CreatedAt dates, so the history of the records written before the log existed starts there.
+// The tombstones are not imported, as the history of a record deleted before the log existed is unknown.
+func (c Cocktail) appendRevisions(oldRecs, newRecs []entity.Cocktail, author entity.Author, now time.Time) error {
+	seq, err := c.lastRevisionSeq()
+	if err != nil {
+		return err
+	}
+
+	revs := make([]entity.Revision, 0)
+	if seq == 0 {
+		for _, rec := range oldRecs {
+			rec := rec
+			if rec.Deleted() {
+				continue
+			}
+			date := rec.UpdatedAt
+			if date.IsZero() {
+				date = rec.CreatedAt
+			}
+			revs = append(revs, entity.Revision{ID: rec.ID, Version: rec.Version, Type: entity.ChangeCreated,
+				Time: changeTime(date, now), Author: entity.Author{Source: entity.SourceImport}, Cocktail: &rec})
+		}
+	}
+	revs = append(revs, diffRevisions(oldRecs, newRecs, author, now)...)
+	return c.appendRevisionList(seq, revs)
+}
+
+// appendRevisionList appends the given revisions to the revision log, numbering them after the given sequence number
+// of the last logged revision. See lastRevisionSeq.
+func (c Cocktail) appendRevisionList(seq int64, revs []entity.Revision) error {
+	if len(revs) == 0 {
+		return nil
+	}
+	for i := range revs {
+		seq++
+		revs[i].Seq = seq
+	}
+
+	if err := appendJSONLines(c.revisionLogPath(), revs); err != nil {
+		logger.Log().Error().Err(err).Str("file", c.revisionLogPath()).Msg("appendRevisionList: write revision log failed")
+		return &RevisionLogErr{err}
+	}
+	return nil
+}
+
+// diffRevisions returns the revisions that turn the old records into the new ones, written by the given author at
+// the given time, with no sequence numbers. The records are compared as diffChanges does, and the updates hold
+// the changed fields. See fieldDiffs.
+// Unlike the change log, the tombstones are tracked: deleting a record logs it as deleted, restoring it as
+// restored, and removing its tombstone as purged.
+func diffRevisions(oldRecs, newRecs []entity.Cocktail, author entity.Author, now time.Time) []entity.Revision {
+	old := make(map[int]entity.Cocktail, len(oldRecs))
+	for _, rec := range oldRecs {
+		old[rec.ID] = rec
+	}
+
+	revs := make([]entity.Revision, 0)
+	kept := make(map[int]bool, len(newRecs))
+	for _, rec := range newRecs {
+		rec := rec
+		kept[rec.ID] = true
+		prev, found := old[rec.ID]
+		switch {
+		case rec.Deleted():
+			if found && !prev.Deleted() {
+				revs = append(revs, entity.Revision{ID: rec.ID, Version: rec.Version, Type: entity.ChangeDeleted, Time: now, Author: author})
+			}
+		case !found:
+			revs = append(revs, entity.Revision{ID: rec.ID, Version: rec.Version, Type: entity.ChangeCreated, Time: now, Author: author, Cocktail: &rec})
+		case prev.Deleted():
+			revs = append(revs, entity.Revision{ID: rec.ID, Version: rec.Version, Type: entity.ChangeRestored, Time: now, Author: author,
+				Diff: fieldDiffs(prev, rec), Cocktail: &rec})
+		case prev.Version != rec.Version || !prev.UpdatedAt.Equal(rec.UpdatedAt):
+			revs = append(revs, entity.Revision{ID: rec.ID, Version: rec.Version, Type: entity.ChangeUpdated, Time: now, Author: author,
+				Diff: fieldDiffs(prev, rec), Cocktail: &rec})
+		}
+	}
+	for _, rec := range oldRecs {
+		switch {
+		case kept[rec.ID]:
+		case rec.Deleted():
+			revs = append(revs, entity.Revision{ID: rec.ID, Version: rec.Version, Type: entity.ChangePurged, Time: now, Author: author})
+		default:
+			revs = append(revs, entity.Revision{ID: rec.ID, Version: rec.Version, Type: entity.ChangeDeleted, Time: now, Author: author})
+		}
+	}
+	return revs
+}
+
+// fieldDiffs returns the fields whose JSON values differ between the given records, sorted by name.
+// The fields set by every write are left out. See untrackedFields.
+func fieldDiffs(prev, rec entity.Cocktail) []entity.FieldDiff {
+	prevFields, err := jsonFields(prev)
+	if err != nil {
+		logger.Log().Error().Err(err).Int("id", prev.ID).Msg("fieldDiffs: encoding previous record failed")
+		return nil
+	}
+	recFields, err := jsonFields(rec)
+	if err != nil {
+		logger.Log().Error().Err(err).Int("id", rec.ID).Msg("fieldDiffs: encoding record failed")
+		return nil
+	}
+
+	diffs := make([]entity.FieldDiff, 0)
+	for field, value := range recFields {
+		if !untrackedFields[field] && !bytes.Equal(prevFields[field], value) {
+			diffs = append(diffs, entity.FieldDiff{Field: field, Old: prevFields[field], New: value})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Field < diffs[j].Field })
+	return diffs
+}
+
+// jsonFields returns the JSON values of the record fields, by field name.
+func jsonFields(rec entity.Cocktail) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
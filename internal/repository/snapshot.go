@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/config"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+)
+
+// snapshotDirSuffix is appended to the CSV database file name, without extension, to name its snapshots directory.
+// e.g. the snapshots of "cocktails.csv" live in the "cocktails_snapshots" directory
+const snapshotDirSuffix = "_snapshots"
+
+// snapshotPath returns the full path of the snapshot of the given sync transaction, a copy of the CSV database
+// file named after the transaction ID. e.g. "cocktails_snapshots/20231001T123015Z-1a2b3c4d.csv"
+func (c Cocktail) snapshotPath(txID string) string {
+	return filepath.Join(c.sidePath(snapshotDirSuffix), txID+filepath.Ext(c.csv.FileName()))
+}
+
+// Snapshot copies the CSV database file to the snapshot of the given sync transaction, so the database can be
+// restored to its content before the transaction. The copy is written to a temporary file and renamed, so a
+// failed snapshot leaves no partial copy. The file is copied under the shared lock.
+// In journal mode, the records with the journal applied are written instead.
+func (c Cocktail) Snapshot(txID string) error {
+	if err := validateTxID(txID); err != nil {
+		return err
+	}
+	unlock, err := c.rlock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return c.snapshot(txID)
+}
+
+// snapshot is Snapshot, with the lock held and the transaction ID validated.
+func (c Cocktail) snapshot(txID string) error {
+	dir := c.sidePath(snapshotDirSuffix)
+	if err := os.MkdirAll(dir, dataDirMode); err != nil {
+		logger.Log().Error().Err(err).Str("dir", dir).Msg("Snapshot: create snapshots directory failed")
+		return &SnapshotErr{err}
+	}
+
+	var err error
+	file := c.snapshotPath(txID)
+	if c.journaled() {
+		err = c.snapshotJournaled(file)
+	} else {
+		err = copyFile(c.csv.FilePath(), file)
+	}
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("Snapshot: copy csv file failed")
+		return &SnapshotErr{err}
+	}
+	return nil
+}
+
+// ReadSnapshot returns the records of the snapshot of the given sync transaction, including the deleted ones,
+// and whether it exists.
+// No snapshot exists for the invalid transaction IDs.
+func (c Cocktail) ReadSnapshot(txID string) ([]entity.Cocktail, bool, error) {
+	if validateTxID(txID) != nil {
+		return nil, false, nil
+	}
+	file := c.snapshotPath(txID)
+	if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+
+	snapshot := Cocktail{csv: config.NewCsv(filepath.Base(file), filepath.Dir(file))}
+	recs, err := snapshot.readAll(true)
+	if err != nil {
+		return nil, false, &SnapshotErr{err}
+	}
+	return recs, true, nil
+}
+
+// validateTxID checks the given sync transaction ID is a plain file name, so its snapshot stays in the
+// snapshots directory.
+func validateTxID(txID string) error {
+	if txID == "" || strings.HasPrefix(txID, ".") || filepath.Base(txID) != txID {
+		return &SnapshotErr{ErrTxIDInvalid}
+	}
+	return nil
+}
+
+// snapshotJournaled writes the records with the journal applied to the given snapshot file, through a temporary file
+// renamed once synced to disk.
+func (c Cocktail) snapshotJournaled(file string) error {
+	recs, err := c.readJournaled(true)
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if _, err := writeCsvFile(tmp, recs); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// copyFile copies the src file to the dst file, through a temporary file renamed once synced to disk.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := in.Close(); err != nil {
+			logger.Log().Error().Err(err).Str("file", src).Msg("copyFile: close source file failed")
+		}
+	}()
+
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, dataFileMode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
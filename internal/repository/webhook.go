@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/config"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+)
+
+const (
+	// The webhook data files, stored in the data directory.
+	webhooksFileName    = "webhooks.json"
+	deliveriesFileName  = "webhook_deliveries.jsonl"
+	deadLettersFileName = "webhook_dead_letters.jsonl"
+
+	// webhookPostTimeout is the time given to the webhook endpoints to respond.
+	webhookPostTimeout = 10 * time.Second
+)
+
+// Webhook represents the Webhook repository.
+// The registered webhooks are stored in a JSON file, and their deliveries and dead letters in JSON lines files.
+type Webhook struct {
+	dataDir    string
+	httpClient HttpClient
+}
+
+// NewWebhook returns a new Webhook repository implementation, storing its files in the database data directory.
+func NewWebhook(cfg config.Config) (Webhook, error) {
+	dataDir := cfg.Database.Csv.DataDir()
+	if err := createDataDir(dataDir); err != nil {
+		return Webhook{}, &WebhookErr{err}
+	}
+
+	logger.Log().Debug().Str("data_dir", dataDir).Msg("created Webhook repository")
+	return Webhook{
+		dataDir:    dataDir,
+		httpClient: &http.Client{Timeout: webhookPostTimeout},
+	}, nil
+}
+
+// ReadAll returns all the registered entity.Webhook records.
+func (wh Webhook) ReadAll() ([]entity.Webhook, error) {
+	file := filepath.Join(wh.dataDir, webhooksFileName)
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return []entity.Webhook{}, nil
+	}
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("ReadAll: read webhooks file failed")
+		return nil, &WebhookErr{err}
+	}
+
+	webhooks := make([]entity.Webhook, 0)
+	if len(data) == 0 {
+		return webhooks, nil
+	}
+	if err := json.Unmarshal(data, &webhooks); err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("ReadAll: parsing webhooks failed")
+		return nil, &WebhookErr{err}
+	}
+	return webhooks, nil
+}
+
+// ReplaceAll replaces the registered webhooks entirely with the given entity.Webhook records.
+// The webhooks file is replaced atomically, so it is never left half written.
+func (wh Webhook) ReplaceAll(webhooks []entity.Webhook) error {
+	file := filepath.Join(wh.dataDir, webhooksFileName)
+	data, err := json.MarshalIndent(webhooks, "", "  ")
+	if err != nil {
+		return &WebhookErr{err}
+	}
+
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, dataFileMode); err != nil {
+		logger.Log().Error().Err(err).Str("file", tmp).Msg("ReplaceAll: write webhooks file failed")
+		return &WebhookErr{err}
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("ReplaceAll: replace webhooks file failed")
+		return &WebhookErr{err}
+	}
+	return nil
+}
+
+// AppendDelivery appends the given entity.Delivery to the delivery history.
+func (wh Webhook) AppendDelivery(delivery entity.Delivery) error {
+	file := filepath.Join(wh.dataDir, deliveriesFileName)
+	if err := appendJSONLines(file, []entity.Delivery{delivery}); err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("AppendDelivery: write delivery failed")
+		return &WebhookErr{err}
+	}
+	return nil
+}
+
+// ReadDeliveries returns the delivery history of the given webhook, from the oldest delivery.
+func (wh Webhook) ReadDeliveries(webhookID string) ([]entity.Delivery, error) {
+	deliveries := make([]entity.Delivery, 0)
+	err := wh.scan(deliveriesFileName, func(line []byte) error {
+		var delivery entity.Delivery
+		if err := json.Unmarshal(line, &delivery); err != nil {
+			return err
+		}
+		if delivery.WebhookID == webhookID {
+			deliveries = append(deliveries, delivery)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// AppendDeadLetter appends the given entity.DeadLetter to the dead-letter log.
+func (wh Webhook) AppendDeadLetter(letter entity.DeadLetter) error {
+	file := filepath.Join(wh.dataDir, deadLettersFileName)
+	if err := appendJSONLines(file, []entity.DeadLetter{letter}); err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("AppendDeadLetter: write dead letter failed")
+		return &WebhookErr{err}
+	}
+	return nil
+}
+
+// ReadDeadLetters returns the dead letters of the given webhook, from the oldest one.
+func (wh Webhook) ReadDeadLetters(webhookID string) ([]entity.DeadLetter, error) {
+	letters := make([]entity.DeadLetter, 0)
+	err := wh.scan(deadLettersFileName, func(line []byte) error {
+		var letter entity.DeadLetter
+		if err := json.Unmarshal(line, &letter); err != nil {
+			return err
+		}
+		if letter.WebhookID == webhookID {
+			letters = append(letters, letter)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return letters, nil
+}
+
+// scan reads the given JSON lines file of the data directory and calls fn with each line.
+// The lines fn fails to parse are skipped.
+func (wh Webhook) scan(fileName string, fn func(line []byte) error) error {
+	file := filepath.Join(wh.dataDir, fileName)
+	err := scanJSONLines(file, func(line []byte) bool {
+		if err := fn(line); err != nil {
+			logger.Log().Warn().Err(err).Str("file", file).Msg("scan: parsing line failed, skipped")
+		}
+		return true
+	})
+	if err != nil {
+		logger.Log().Error().Err(err).Str("file", file).Msg("scan: read file failed")
+		return &WebhookErr{err}
+	}
+	return nil
+}
+
+// Post sends the given JSON body to the webhook URL and returns the response status code.
+func (wh Webhook) Post(ctx context.Context, url string, header http.Header, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wh.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func(Body io.ReadCloser) {
+		if _, err := io.Copy(io.Discard, Body); err != nil {
+			logger.Log().Debug().Err(err).Msg("Post: drain response body failed")
+		}
+		if err := Body.Close(); err != nil {
+			logger.Log().Error().Err(err).Msg("Post: close response body failed")
+		}
+	}(resp.Body)
+	return resp.StatusCode, nil
+}
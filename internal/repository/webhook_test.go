@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/repository/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook_ReplaceAll(t *testing.T) {
+	repo := Webhook{dataDir: t.TempDir()}
+
+	out, err := repo.ReadAll()
+	require.Nil(t, err)
+	assert.Equal(t, []entity.Webhook{}, out)
+
+	webhooks := []entity.Webhook{
+		{ID: "a1", URL: "https://foo.com/hook", Secret: "foo secret", Events: []string{"sync.completed"}},
+		{ID: "b2", URL: "https://bar.com/hook", Secret: "bar secret", Events: []string{}},
+	}
+	require.Nil(t, repo.ReplaceAll(webhooks))
+	out, err = repo.ReadAll()
+	require.Nil(t, err)
+	assert.Equal(t, webhooks, out)
+
+	info, err := os.Stat(filepath.Join(repo.dataDir, webhooksFileName))
+	require.Nil(t, err)
+	assert.Equal(t, dataFileMode, info.Mode())
+}
+
+func TestWebhook_Deliveries(t *testing.T) {
+	repo := Webhook{dataDir: t.TempDir()}
+	deliveries := []entity.Delivery{
+		{ID: "d1", WebhookID: "a1", EventID: 1, Status: entity.DeliverySucceeded, Attempts: 1, Code: 200},
+		{ID: "d2", WebhookID: "b2", EventID: 1, Status: entity.DeliverySucceeded, Attempts: 1, Code: 204},
+		{ID: "d3", WebhookID: "a1", EventID: 2, Status: entity.DeliveryFailed, Attempts: 5, Error: "connection refused"},
+	}
+	for _, delivery := range deliveries {
+		require.Nil(t, repo.AppendDelivery(delivery))
+	}
+	letter := entity.DeadLetter{Delivery: deliveries[2], URL: "https://foo.com/hook", Payload: []byte(`{"id":2}`)}
+	require.Nil(t, repo.AppendDeadLetter(letter))
+
+	out, err := repo.ReadDeliveries("a1")
+	require.Nil(t, err)
+	assert.Equal(t, []entity.Delivery{deliveries[0], deliveries[2]}, out)
+
+	letters, err := repo.ReadDeadLetters("a1")
+	require.Nil(t, err)
+	assert.Equal(t, []entity.DeadLetter{letter}, letters)
+
+	letters, err = repo.ReadDeadLetters("b2")
+	require.Nil(t, err)
+	assert.Empty(t, letters)
+}
+
+func TestWebhook_Post(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	mClient := mocks.NewHttpClient()
+	mClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		sent, _ := io.ReadAll(req.Body)
+		return req.Method == http.MethodPost &&
+			req.URL.String() == "https://foo.com/hook" &&
+			req.Header.Get("Content-Type") == "application/json" &&
+			req.Header.Get("X-Foo") == "bar" &&
+			bytes.Equal(sent, body)
+	})).Return(&http.Response{StatusCode: http.StatusAccepted, Body: io.NopCloser(bytes.NewReader(nil))}, nil)
+	repo := Webhook{httpClient: mClient}
+
+	code, err := repo.Post(context.Background(), "https://foo.com/hook", http.Header{"X-Foo": {"bar"}}, body)
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, code)
+}
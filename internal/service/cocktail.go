@@ -1,7 +1,10 @@
 package service
 
 import (
+	"context"
+	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
@@ -10,68 +13,203 @@ import (
 )
 
 // Cocktail performs the core operations for Cocktail.
+// The database writes are serialized in-process; the repository checks the records were not written in between
+// by another process. See CocktailRepo.ReplaceDBFunc and CocktailRecordWriter.
+// The database updates handle the records removed from the data API by the removed policy. See WithRemovedPolicy.
 type Cocktail struct {
-	repo CocktailRepo
+	repo    CocktailRepo
+	events  EventPublisher
+	mu      *sync.Mutex
+	removed ct.RemovedPolicy
 }
 
 // CocktailRepo is the abstraction of the Cocktail repository dependency.
 type CocktailRepo interface {
 	ReadAll() ([]entity.Cocktail, error)
+	ReadAllWithDeleted() ([]entity.Cocktail, error)
+	Stream(ctx context.Context, fn func(entity.Cocktail) error) error
 	ReadCC(nType ct.NumberType, maxJobs, jWorker int) ([]entity.Cocktail, error)
-	ReplaceDB(recs []entity.Cocktail) error
-	Fetch() ([]entity.Cocktail, error)
+	ReplaceDBFunc(txID string, author entity.Author, fn func([]entity.Cocktail) ([]entity.Cocktail, error)) error
+	ReadChanges(since int64, limit int) ([]entity.Change, error)
+	ReadHistory(id int) ([]entity.Revision, error)
+	ReadAsOf(t time.Time) ([]entity.Cocktail, error)
+	ReadSnapshot(txID string) ([]entity.Cocktail, bool, error)
+	Fetch() ([]entity.Cocktail, []int, error)
+	Checksum() (string, error)
+	LastModified() (time.Time, error)
 }
 
 // NewCocktail returns a new Cocktail service implementation.
-func NewCocktail(repo CocktailRepo) Cocktail {
-	logger.Log().Debug().Msg("created Cocktail service")
+// The database changes are published to the given events publisher; nil publishes no events.
+// The records removed from the data API are ignored by the database updates.
+func NewCocktail(repo CocktailRepo, events EventPublisher) Cocktail {
 	return Cocktail{
-		repo: repo,
+		repo:    repo,
+		events:  events,
+		mu:      &sync.Mutex{},
+		removed: ct.RemovedIgnore,
 	}
 }
 
+// WithRemovedPolicy returns a copy of the service handling the records removed from the data API by the given policy.
+// Returns an ArgsErr if the policy is not supported.
+func (s Cocktail) WithRemovedPolicy(policy string) (Cocktail, error) {
+	removed := ct.NewRemovedPolicy(policy)
+	if removed == ct.InvalidRemoved {
+		return Cocktail{}, &ArgsErr{fmt.Errorf("%w: %q", ErrRemovedPolicyInvalid, policy)}
+	}
+	s.removed = removed
+	return s, nil
+}
+
 // GetFiltered returns a filtered list of entity.Cocktail records from the database.
+// The records are looked up by their indexes if the repository implements CocktailIndex, and the filter is indexed.
 func (s Cocktail) GetFiltered(filter, value string) ([]entity.Cocktail, error) {
+	cocktails, _, err := s.getFiltered(filter, value)
+	return cocktails, err
+}
+
+// GetFilteredWithSuggestions returns a filtered list of entity.Cocktail records from the database like GetFiltered,
+// along with the "did you mean" suggestions for the value if no record matches it, ranked from the most similar one.
+// Typo-tolerant matching is supported by the name, category, ingredient and glass filters; any other valid filter
+// returns no suggestions.
+// The suggestions are drawn from the records read by the filter, so the database is read once, unless the records
+// were looked up by their indexes.
+func (s Cocktail) GetFilteredWithSuggestions(filter, value string) ([]entity.Cocktail, []ct.Suggestion, error) {
+	cocktails, recs, err := s.getFiltered(filter, value)
+	if err != nil {
+		return nil, nil, err
+	}
+	fltr := newCocktailFltr(filter)
+	if len(cocktails) > 0 || !suggestible(fltr) {
+		return cocktails, []ct.Suggestion{}, nil
+	}
+	if recs == nil {
+		if recs, err = s.repo.ReadAll(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return cocktails, suggestValues(value, fuzzyValues(fltr, recs)), nil
+}
+
+// getFiltered returns the records matching the given filter and value, along with the database records read to
+// filter them, nil if they were looked up by their indexes. See GetFiltered.
+func (s Cocktail) getFiltered(filter, value string) (cocktails, recs []entity.Cocktail, err error) {
 	if filter == "" {
-		return nil, &FilterErr{ErrFltrTypeEmpty}
+		return nil, nil, &FilterErr{ErrFltrTypeEmpty}
 	}
 	if value == "" {
-		return nil, &FilterErr{ErrFltrValueEmpty}
+		return nil, nil, &FilterErr{ErrFltrValueEmpty}
 	}
 	fltr := newCocktailFltr(filter)
 	if fltr == invalidFltr {
-		return nil, &FilterErr{ErrFltrInvalid}
+		return nil, nil, &FilterErr{ErrFltrInvalid}
+	}
+	if idx, ok := s.repo.(CocktailIndex); ok {
+		if cocktails, indexed, err := cocktailsByIndex(idx, fltr, value); indexed {
+			return cocktails, nil, err
+		}
 	}
 
-	recs, err := s.repo.ReadAll()
+	recs, err = s.repo.ReadAll()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if len(recs) == 0 {
-		return []entity.Cocktail{}, nil
+		return []entity.Cocktail{}, recs, nil
 	}
 
 	switch fltr {
 	case idFltr:
 		id, e := strconv.Atoi(value)
 		if e != nil {
-			return nil, &FilterErr{e}
+			return nil, nil, &FilterErr{e}
 		}
-		return cocktailsById(id, recs), nil
+		return cocktailsById(id, recs), recs, nil
 	case nameFltr:
-		return cocktailsByName(value, recs), nil
+		return cocktailsByName(value, recs), recs, nil
 	case alcoholicFltr:
-		return cocktailsByAlcoholic(value, recs), nil
+		return cocktailsByAlcoholic(value, recs), recs, nil
 	case categoryFltr:
-		return cocktailsByCategory(value, recs), nil
+		return cocktailsByCategory(value, recs), recs, nil
 	case ingredientFltr:
-		return cocktailsByIngredient(value, recs), nil
+		return cocktailsByIngredient(value, recs), recs, nil
 	case glassFltr:
-		return cocktailsByGlass(value, recs), nil
+		return cocktailsByGlass(value, recs), recs, nil
+	case tagFltr:
+		return cocktailsByTag(value, recs), recs, nil
 	default:
 		logger.Log().Error().Err(ErrFltrInvalid).Str("filter", filter).Str("value", value).
-			Msgf("GetFiltered: filter not supported")
-		return nil, &FilterErr{ErrFltrInvalid}
+			Msgf("getFiltered: filter not supported")
+		return nil, nil, &FilterErr{ErrFltrInvalid}
+	}
+}
+
+// Query returns the entity.Cocktail records from the database satisfying the given filter query.
+// A query combines field:value terms with the AND, OR and NOT operators and parentheses.
+// Values with whitespaces must be quoted. e.g. `ingredient:vodka AND (glass:"old fashioned" OR category:shot)`
+// The query syntax errors are wrapped in a FilterErr holding a QueryErr with the error position.
+func (s Cocktail) Query(q string) ([]entity.Cocktail, error) {
+	node, err := parseQuery(q)
+	if err != nil {
+		logger.Log().Error().Err(err).Str("query", q).Msg("Query: parsing query failed")
+		return nil, &FilterErr{err}
+	}
+
+	recs, err := s.repo.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return cocktailsByQuery(node, recs), nil
+}
+
+// GetStats returns the aggregate statistics of the entity.Cocktail records from the database.
+// If the filter query is not empty, only the records satisfying it are aggregated, so the counts
+// describe the current results for faceted navigation. See Query.
+// top is the number of most common ingredients reported; defaults to 10 if it is not positive.
+func (s Cocktail) GetStats(q string, top int) (ct.CocktailStats, error) {
+	if top <= 0 {
+		top = defaultTopIngredients
+	}
+
+	var (
+		recs []entity.Cocktail
+		err  error
+	)
+	if q != "" {
+		recs, err = s.Query(q)
+	} else {
+		recs, err = s.repo.ReadAll()
+	}
+	if err != nil {
+		return ct.CocktailStats{}, err
+	}
+	return cocktailStats(recs, top), nil
+}
+
+// GetTaxonomy returns the distinct values of a cocktail property, along with their usage counts
+// and canonical spellings, derived from the database records.
+// Supported taxonomies: "categories", "glasses", "ingredients", "tags" and "alcoholic-types".
+func (s Cocktail) GetTaxonomy(taxonomy string) ([]ct.TaxonomyValue, error) {
+	tx := newCocktailTaxonomy(taxonomy)
+	if tx == invalidTaxonomy {
+		return nil, &ArgsErr{ErrInvalidTaxonomy}
+	}
+
+	recs, err := s.repo.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return taxonomyValues(tx, recs), nil
+}
+
+// suggestible reports whether the filter supports the typo-tolerant "did you mean" suggestions.
+func suggestible(fltr cocktailFilter) bool {
+	switch fltr {
+	case nameFltr, categoryFltr, ingredientFltr, glassFltr:
+		return true
+	default:
+		return false
 	}
 }
 
@@ -80,6 +218,57 @@ func (s Cocktail) GetAll() ([]entity.Cocktail, error) {
 	return s.repo.ReadAll()
 }
 
+// Checksum returns the checksum of the database content, which changes with every database write.
+func (s Cocktail) Checksum() (string, error) {
+	return s.repo.Checksum()
+}
+
+// LastModified returns the time of the last database write, which dates the database content as a whole.
+func (s Cocktail) LastModified() (time.Time, error) {
+	return s.repo.LastModified()
+}
+
+// StreamAll calls fn with each entity.Cocktail record from the database, one at a time, in database order.
+// It stops when the context is done or fn fails, returning the error.
+func (s Cocktail) StreamAll(ctx context.Context, fn func(entity.Cocktail) error) error {
+	return s.repo.Stream(ctx, fn)
+}
+
+// GetChanges returns the page of database changes made after the given cursor, in the order they were made.
+// An empty cursor starts from the beginning of the change log; the returned NextCursor continues from the last change.
+// limit is the maximum number of changes returned; defaults to 100 if it is not positive, and is capped to 1000.
+func (s Cocktail) GetChanges(cursor string, limit int) (ct.ChangeFeed, error) {
+	var since int64
+	if cursor != "" {
+		var err error
+		since, err = strconv.ParseInt(cursor, 10, 64)
+		if err != nil || since < 0 {
+			return ct.ChangeFeed{}, &ArgsErr{ErrInvalidChangeCursor}
+		}
+	}
+	switch {
+	case limit <= 0:
+		limit = defaultChangesLimit
+	case limit > maxChangesLimit:
+		limit = maxChangesLimit
+	}
+
+	changes, err := s.repo.ReadChanges(since, limit+1)
+	if err != nil {
+		return ct.ChangeFeed{}, err
+	}
+	feed := ct.ChangeFeed{Changes: changes}
+	if len(changes) > limit {
+		feed.Changes = changes[:limit]
+		feed.HasMore = true
+	}
+	if n := len(feed.Changes); n > 0 {
+		since = feed.Changes[n-1].Seq
+	}
+	feed.NextCursor = strconv.FormatInt(since, 10)
+	return feed, nil
+}
+
 // GetCC returns a list of entity.Cocktail from the database concurrently.
 // nType: is the type number. Only support "odd" or "even"
 // jobs: is the amount of valid records to be processed.
@@ -111,62 +300,137 @@ func (s Cocktail) GetCC(nType, jobs, jWorker string) ([]entity.Cocktail, error)
 // A new record is created if the fetched one does not exist in the database.
 // If the record exists but the fetched record's date is newer, the record gets updated in the database.
 // If the record exists, the fetched record date is the same, and any of the values is different, the record gets updated in the database.
-func (s Cocktail) UpdateDB() (ct.DBOpsSummary, error) {
-	dataSet, err := s.repo.ReadAll()
-	if err != nil {
-		return ct.DBOpsSummary{}, err
-	}
+// The created records are at version 1, and the updated ones get their version incremented.
+// The deleted records are left deleted, so a sync does not bring back the records deleted on purpose, unless
+// restoreDeleted is set: then the fetched records restore them, counted as updated.
+// The upstream records no longer fetched are reported in the summary, and handled by the removed policy: ignored,
+// flagged as orphaned, counted as updated, or deleted. A flagged record found again gets its flag cleared.
+// Once the database is updated, an event is published for every created, updated, restored and deleted record,
+// followed by a sync completed event holding the summary.
+// The revisions are authored by the principal of the given context, if any, as a sync.
+// Before the database is written, its content is snapshotted under a new sync transaction ID, returned in the
+// summary, so the sync can be rolled back. See Rollback.
+// The records are fetched first, then read, merged and written at once by the repository, so no write made in
+// between, by this process or another one, is overwritten.
+func (s Cocktail) UpdateDB(ctx context.Context, restoreDeleted bool) (ct.DBOpsSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	extData, err := s.repo.Fetch()
+	extData, skippedIDs, err := s.repo.Fetch()
 	if err != nil {
 		return ct.DBOpsSummary{}, err
 	}
 
 	status := noChangesDBStatus
 	start := time.Now().UTC()
-	nCreated := 0
-	nModified := 0
-	for _, rec := range extData {
-		index, found := findCocktail(rec.ID, dataSet)
-		if !found {
-			nCreated++
-			rec.CreatedAt = dateTimeNow()
-			rec.UpdatedAt = rec.CreatedAt
-			dataSet = append(dataSet, rec)
-			continue
-		}
+	txID := newTxID(start)
+	created := make([]entity.Cocktail, 0)
+	modified := make([]entity.Cocktail, 0)
+	restored := make([]entity.Cocktail, 0)
+	var (
+		flagged, deleted []entity.Cocktail
+		removedIDs       []int
+		totalRecs        int
+	)
+	err = s.repo.ReplaceDBFunc(txID, newAuthor(ctx, entity.SourceSync), func(dataSet []entity.Cocktail) ([]entity.Cocktail, error) {
+		skipped := 0
+		for _, rec := range extData {
+			index, found := findCocktail(rec.ID, dataSet)
+			if !found {
+				rec.CreatedAt = dateTimeNow()
+				rec.UpdatedAt = rec.CreatedAt
+				rec.Version = 1
+				created = append(created, rec)
+				dataSet = append(dataSet, rec)
+				continue
+			}
+
+			if dataSet[index].Deleted() {
+				if !restoreDeleted {
+					skipped++
+					continue
+				}
+				rec.CreatedAt = dataSet[index].CreatedAt
+				rec.UpdatedAt = dateTimeNow()
+				rec.Version = dataSet[index].Version + 1
+				restored = append(restored, rec)
+				dataSet[index] = rec
+				continue
+			}
+
+			if rec.SrcDate.After(dataSet[index].SrcDate) {
+				rec.UpdatedAt = dateTimeNow()
+				rec.Version = dataSet[index].Version + 1
+				modified = append(modified, rec)
+				dataSet[index] = rec
+				continue
+			}
 
-		if rec.SrcDate.After(dataSet[index].SrcDate) {
-			nModified++
-			rec.UpdatedAt = dateTimeNow()
-			dataSet[index] = rec
-			continue
+			if rec.SrcDate == dataSet[index].SrcDate && (!cocktailsEqual(rec, dataSet[index]) || dataSet[index].Orphaned()) {
+				rec.UpdatedAt = dateTimeNow()
+				rec.Version = dataSet[index].Version + 1
+				modified = append(modified, rec)
+				dataSet[index] = rec
+			}
 		}
 
-		if rec.SrcDate == dataSet[index].SrcDate && !cocktailsEqual(rec, dataSet[index]) {
-			nModified++
-			rec.UpdatedAt = dateTimeNow()
-			dataSet[index] = rec
+		if skipped > 0 {
+			logger.Log().Info().Int("skipped", skipped).Msg("UpdateDB: deleted records left deleted")
 		}
+		removedIDs, flagged, deleted = s.handleRemoved(dataSet, extData, skippedIDs)
+		totalRecs = len(dataSet)
+		if len(created)+len(modified)+len(restored)+len(flagged)+len(deleted) == 0 {
+			return nil, nil
+		}
+		return dataSet, nil
+	})
+	if err != nil {
+		return ct.DBOpsSummary{}, err
 	}
 
-	totalOps := nCreated + nModified
+	nCreated := len(created)
+	nModified := len(modified) + len(restored) + len(flagged)
+	totalOps := nCreated + nModified + len(deleted)
 	if totalOps > 0 {
-		if err := s.repo.ReplaceDB(dataSet); err != nil {
-			return ct.DBOpsSummary{}, err
-		}
 		status = successfulUpdateDBStatus
+	} else {
+		txID = ""
 	}
 
 	end := time.Now().UTC()
-	return ct.DBOpsSummary{
-		Status:       status,
-		StartTime:    start,
-		EndTime:      end,
-		Duration:     end.Sub(start).String(),
-		NewRecs:      nCreated,
-		ModifiedRecs: nModified,
-		TotalOps:     totalOps,
-		TotalRecs:    len(dataSet),
-	}, nil
+	summary := ct.DBOpsSummary{
+		TxID:          txID,
+		Status:        status,
+		StartTime:     start,
+		EndTime:       end,
+		Duration:      end.Sub(start).String(),
+		NewRecs:       nCreated,
+		ModifiedRecs:  nModified,
+		DeletedRecs:   len(deleted),
+		TotalOps:      totalOps,
+		TotalRecs:     totalRecs,
+		RemovedRecs:   len(removedIDs),
+		RemovedIDs:    removedIDs,
+		RemovedPolicy: s.removed,
+	}
+
+	if s.events != nil {
+		for _, rec := range created {
+			s.events.Publish(CocktailCreatedEvent, rec)
+		}
+		for _, rec := range modified {
+			s.events.Publish(CocktailUpdatedEvent, rec)
+		}
+		for _, rec := range restored {
+			s.events.Publish(CocktailRestoredEvent, rec)
+		}
+		for _, rec := range flagged {
+			s.events.Publish(CocktailUpdatedEvent, rec)
+		}
+		for _, rec := range deleted {
+			s.events.Publish(CocktailDeletedEvent, rec)
+		}
+		s.events.Publish(SyncCompletedEvent, summary)
+	}
+	return summary, nil
 }
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+)
+
+// History returns the revisions of the record of the given ID, from its creation on.
+// Deleted records keep their history.
+// Returns a NotFoundErr if the record has no revisions and is not in the database.
+func (s Cocktail) History(id int) ([]entity.Revision, error) {
+	revs, err := s.repo.ReadHistory(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(revs) > 0 {
+		return revs, nil
+	}
+
+	// The records written before the revision history was kept have none until their next write.
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+	return revs, nil
+}
+
+// GetAllAsOf returns all the entity.Cocktail records of the database as they were at the given time.
+func (s Cocktail) GetAllAsOf(t time.Time) ([]entity.Cocktail, error) {
+	return s.repo.ReadAsOf(t)
+}
+
+// GetAsOf returns the entity.Cocktail record of the given ID as it was at the given time.
+// Returns a NotFoundErr if the record did not exist at that time.
+func (s Cocktail) GetAsOf(id int, t time.Time) (entity.Cocktail, error) {
+	recs, err := s.repo.ReadAsOf(t)
+	if err != nil {
+		return entity.Cocktail{}, err
+	}
+	index, found := findCocktail(id, recs)
+	if !found {
+		return entity.Cocktail{}, &NotFoundErr{fmt.Errorf("%w: %d as of %s", ErrCocktailNotFound, id, t.Format(time.RFC3339))}
+	}
+	return recs[index], nil
+}
+
+// newAuthor returns the author of the database writes of the given source, made on behalf of the principal of
+// the given context. The writes with no principal are authored by the system.
+func newAuthor(ctx context.Context, source string) entity.Author {
+	author := entity.Author{Source: source}
+	if p, ok := ct.PrincipalFrom(ctx); ok {
+		author.Actor = p.Name
+	}
+	return author
+}
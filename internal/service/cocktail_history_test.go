@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCocktail_History(t *testing.T) {
+	revs := []entity.Revision{
+		{Seq: 1, ID: 1, Version: 1, Type: entity.ChangeCreated, Author: entity.Author{Source: entity.SourceImport}},
+		{Seq: 4, ID: 1, Version: 2, Type: entity.ChangeUpdated, Author: testEditor},
+	}
+	tests := []struct {
+		name    string
+		id      int
+		revs    []entity.Revision
+		repoErr error
+		exp     []entity.Revision
+		err     error
+	}{
+		{name: "Repository error", id: 1, revs: []entity.Revision{}, repoErr: testRepoErr, err: testRepoErr},
+		{name: "Revisions", id: 1, revs: revs, exp: revs},
+		{name: "No revisions yet", id: 2, revs: []entity.Revision{}, exp: []entity.Revision{}},
+		{name: "Not found", id: 9, revs: []entity.Revision{}, err: ErrCocktailNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadHistory", tt.id).Return(tt.revs, tt.repoErr)
+			mRepo.On("ReadAll").Return(testVersionedCocktails(), nil)
+			svc := NewCocktail(mRepo, nil)
+
+			out, err := svc.History(tt.id)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp, out)
+		})
+	}
+}
+
+func TestCocktail_GetAsOf(t *testing.T) {
+	asOf := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		id      int
+		repoErr error
+		err     error
+	}{
+		{name: "Repository error", id: 1, repoErr: testRepoErr, err: testRepoErr},
+		{name: "Did not exist", id: 9, err: ErrCocktailNotFound},
+		{name: "Found", id: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadAsOf", asOf).Return(testVersionedCocktails(), tt.repoErr)
+			svc := NewCocktail(mRepo, nil)
+
+			out, err := svc.GetAsOf(tt.id, asOf)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, testVersionedCocktails()[1], out)
+		})
+	}
+}
+
+func TestNewAuthor(t *testing.T) {
+	assert.Equal(t, entity.Author{Source: entity.SourceSync}, newAuthor(context.Background(), entity.SourceSync))
+	ctx := ct.WithPrincipal(context.Background(), ct.Principal{Name: "ops", Role: ct.AdminRole})
+	assert.Equal(t, entity.Author{Actor: "ops", Source: entity.SourceSync}, newAuthor(ctx, entity.SourceSync))
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+)
+
+// CocktailIndex is the abstraction of the Cocktail repositories indexing the records, such as a cache.
+// When the CocktailRepo implements it, the records are looked up by their indexes rather than scanned.
+// The deleted records are not indexed.
+type CocktailIndex interface {
+	ReadByID(id int) (entity.Cocktail, bool, error)
+	ReadIndexed(field ct.IndexField, match func(key string) bool) ([]entity.Cocktail, error)
+}
+
+// indexedFilters are the index fields of the filters looked up by CocktailIndex, keyed by filter type.
+// Their matchers match a value contained in the field, case-insensitively, as the index keys do.
+var indexedFilters = map[cocktailFilter]ct.IndexField{
+	nameFltr:       ct.NameIndex,
+	categoryFltr:   ct.CategoryIndex,
+	ingredientFltr: ct.IngredientIndex,
+	glassFltr:      ct.GlassIndex,
+}
+
+// cocktailsByIndex returns the records of the given filter and value looked up by the index, in file order, and
+// whether the filter is indexed. Returns a FilterErr if the ID filter value is not a number.
+func cocktailsByIndex(idx CocktailIndex, fltr cocktailFilter, value string) ([]entity.Cocktail, bool, error) {
+	if fltr == idFltr {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, true, &FilterErr{err}
+		}
+		rec, found, err := idx.ReadByID(id)
+		if err != nil {
+			return nil, true, err
+		}
+		if !found {
+			return []entity.Cocktail{}, true, nil
+		}
+		return []entity.Cocktail{rec}, true, nil
+	}
+
+	field, indexed := indexedFilters[fltr]
+	if !indexed {
+		return nil, false, nil
+	}
+	key := ct.IndexKey(value)
+	recs, err := idx.ReadIndexed(field, func(k string) bool {
+		return strings.Contains(k, key)
+	})
+	return recs, true, err
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCocktail_GetFilteredIndexed(t *testing.T) {
+	foo := entity.Cocktail{ID: 1, Name: "Foo Fizz", Glass: "Shot glass", Alcoholic: "Alcoholic"}
+	bar := entity.Cocktail{ID: 2, Name: "Bar", Glass: "Cocktail glass", Alcoholic: "Non alcoholic"}
+	tests := []struct {
+		name    string
+		filter  string
+		value   string
+		indexed bool
+		exp     []entity.Cocktail
+		err     error
+	}{
+		{name: "ID", filter: "id", value: "2", indexed: true, exp: []entity.Cocktail{bar}},
+		{name: "ID not found", filter: "id", value: "9", indexed: true, exp: []entity.Cocktail{}},
+		{name: "ID invalid", filter: "id", value: "foo", indexed: true, err: &FilterErr{}},
+		{name: "Name", filter: "name", value: "FIZZ", indexed: true, exp: []entity.Cocktail{foo}},
+		{name: "Glass", filter: "glass", value: "shot", indexed: true, exp: []entity.Cocktail{foo}},
+		{name: "Not indexed", filter: "alcoholic", value: "non alcoholic", exp: []entity.Cocktail{bar}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailIndexRepo()
+			mRepo.On("ReadAll").Return([]entity.Cocktail{foo, bar}, nil)
+			mRepo.On("ReadByID", 2).Return(bar, true, nil)
+			mRepo.On("ReadByID", 9).Return(entity.Cocktail{}, false, nil)
+			mRepo.On("ReadIndexed", ct.NameIndex).Return(map[string][]entity.Cocktail{"foo fizz": {foo}, "bar": {bar}}, nil)
+			mRepo.On("ReadIndexed", ct.GlassIndex).Return(map[string][]entity.Cocktail{"shot glass": {foo}, "cocktail glass": {bar}}, nil)
+			svc := NewCocktail(mRepo, nil)
+
+			out, err := svc.GetFiltered(tt.filter, tt.value)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.IsType(t, tt.err, err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp, out)
+			if tt.indexed {
+				mRepo.AssertNotCalled(t, "ReadAll")
+			}
+		})
+	}
+}
+
+func TestCocktail_GetIndexed(t *testing.T) {
+	mRepo := mocks.NewCocktailIndexRepo()
+	mRepo.On("ReadByID", 1).Return(testVersionedCocktails()[0], true, nil)
+	mRepo.On("ReadByID", 9).Return(entity.Cocktail{}, false, nil)
+	svc := NewCocktail(mRepo, nil)
+
+	out, err := svc.Get(1)
+	require.Nil(t, err)
+	assert.Equal(t, testVersionedCocktails()[0], out)
+
+	_, err = svc.Get(9)
+	assert.ErrorIs(t, err, ErrCocktailNotFound)
+	mRepo.AssertNotCalled(t, "ReadAll")
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+)
+
+const compactedStatus = "journal compacted"
+
+// CocktailRecordWriter is the abstraction of the Cocktail repositories writing a single record at once, such as the
+// journaled ones. When the CocktailRepo implements it, the single-record writes use it rather than ReplaceDBFunc.
+// The records are written provided they are at the given version, zero meaning they do not exist, and a
+// ct.VersionConflictErr is returned otherwise.
+// Compact folds the single-record writes into the database, and returns how many were folded.
+type CocktailRecordWriter interface {
+	PutRecord(rec entity.Cocktail, version int, author entity.Author) error
+	PurgeRecord(id, version int, author entity.Author) error
+	Compact() (int, error)
+}
+
+// putRecord writes the given record, provided the record of its ID is still at the given version.
+// The record alone is written if the repository implements CocktailRecordWriter; otherwise the database is replaced.
+// Either way, the version is checked by the repository along with the write, so a record written in between by
+// another process is not overwritten; a VersionErr is returned instead.
+func (s Cocktail) putRecord(rec entity.Cocktail, version int, author entity.Author) error {
+	if w, ok := s.repo.(CocktailRecordWriter); ok {
+		return versionErr(w.PutRecord(rec, version, author))
+	}
+	err := s.repo.ReplaceDBFunc("", author, func(recs []entity.Cocktail) ([]entity.Cocktail, error) {
+		index, err := recordAt(recs, rec.ID, version)
+		if err != nil {
+			return nil, err
+		}
+		recs[index] = rec
+		return recs, nil
+	})
+	return versionErr(err)
+}
+
+// purgeRecord removes the record of the given ID, provided it is still at the given version. See putRecord.
+func (s Cocktail) purgeRecord(id, version int, author entity.Author) error {
+	if w, ok := s.repo.(CocktailRecordWriter); ok {
+		return versionErr(w.PurgeRecord(id, version, author))
+	}
+	err := s.repo.ReplaceDBFunc("", author, func(recs []entity.Cocktail) ([]entity.Cocktail, error) {
+		index, err := recordAt(recs, id, version)
+		if err != nil {
+			return nil, err
+		}
+		return append(recs[:index], recs[index+1:]...), nil
+	})
+	return versionErr(err)
+}
+
+// recordAt returns the index of the record of the given ID, provided it is at the given version.
+// Returns a ct.VersionConflictErr otherwise.
+func recordAt(recs []entity.Cocktail, id, version int) (int, error) {
+	index, found := findCocktail(id, recs)
+	current := 0
+	if found {
+		current = recs[index].Version
+	}
+	if current != version {
+		return 0, &ct.VersionConflictErr{ID: id, Version: version, Current: current}
+	}
+	return index, nil
+}
+
+// versionErr returns a VersionErr if the given write error is a ct.VersionConflictErr, or the error otherwise.
+func versionErr(err error) error {
+	var conflict *ct.VersionConflictErr
+	if errors.As(err, &conflict) {
+		return &VersionErr{Current: conflict.Current, Err: fmt.Errorf("%w: %s", ErrVersionMismatch, conflict)}
+	}
+	return err
+}
+
+// Compact folds the single-record writes of the repository into the database, and returns the compaction summary.
+// Nothing is folded if the repository does not implement CocktailRecordWriter.
+func (s Cocktail) Compact(ctx context.Context) (ct.CompactSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	summary := ct.CompactSummary{Status: compactedStatus, StartTime: start}
+	if w, ok := s.repo.(CocktailRecordWriter); ok {
+		n, err := w.Compact()
+		if err != nil {
+			return ct.CompactSummary{}, err
+		}
+		summary.FoldedEntries = n
+	}
+	summary.EndTime = time.Now()
+	summary.Duration = summary.EndTime.Sub(start).String()
+
+	principal, _ := ct.PrincipalFrom(ctx)
+	logger.Log().Info().Int("entries", summary.FoldedEntries).Str("actor", principal.Name).Msg("Compact: journal compacted")
+	return summary, nil
+}
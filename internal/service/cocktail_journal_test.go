@@ -0,0 +1,170 @@
+package service
+
+import (
+	"testing"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCocktail_RecordWriter(t *testing.T) {
+	values := entity.Cocktail{Name: "Baz", Ingredients: []entity.Ingredient{{Name: "lime"}}, Instructions: "Pour"}
+	tests := []struct {
+		name    string
+		write   func(svc Cocktail) error
+		exp     entity.Cocktail
+		version int
+		purge   int
+	}{
+		{
+			name: "Update",
+			write: func(svc Cocktail) error {
+				_, err := svc.Update(testCtx, 1, 1, values)
+				return err
+			},
+			exp: func() entity.Cocktail {
+				rec := values
+				rec.ID = 1
+				rec.CreatedAt = testVersionedCocktails()[0].CreatedAt
+				rec.UpdatedAt = dateTimeNow()
+				rec.Version = 2
+				return rec
+			}(),
+			version: 1,
+		},
+		{
+			name: "Delete",
+			write: func(svc Cocktail) error {
+				return svc.Delete(testCtx, 1, 1)
+			},
+			exp: func() entity.Cocktail {
+				rec := testVersionedCocktails()[0]
+				deletedAt := dateTimeNow()
+				rec.UpdatedAt = deletedAt
+				rec.DeletedAt = &deletedAt
+				rec.Version = 2
+				return rec
+			}(),
+			version: 1,
+		},
+		{
+			name: "Restore",
+			write: func(svc Cocktail) error {
+				_, err := svc.Restore(testCtx, 3)
+				return err
+			},
+			exp: func() entity.Cocktail {
+				rec := testTombstone()
+				rec.UpdatedAt = dateTimeNow()
+				rec.DeletedAt = nil
+				rec.Version = 3
+				return rec
+			}(),
+			version: 2,
+		},
+		{
+			name: "Purge",
+			write: func(svc Cocktail) error {
+				return svc.Purge(testCtx, 3)
+			},
+			purge:   3,
+			version: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRecordWriterRepo()
+			mRepo.On("ReadAllWithDeleted").Return(append(testVersionedCocktails(), testTombstone()), nil)
+			mRepo.On("PutRecord", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			mRepo.On("PurgeRecord", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			svc := NewCocktail(mRepo, nil)
+
+			require.Nil(t, tt.write(svc))
+			mRepo.AssertNotCalled(t, "ReplaceDB", mock.Anything, mock.Anything)
+			if tt.purge != 0 {
+				mRepo.AssertCalled(t, "PurgeRecord", tt.purge, tt.version, testEditor)
+				return
+			}
+			mRepo.AssertCalled(t, "PutRecord", tt.exp, tt.version, testEditor)
+		})
+	}
+}
+
+func TestCocktail_RecordWriterConflict(t *testing.T) {
+	conflict := &ct.VersionConflictErr{ID: 1, Version: 1, Current: 3}
+	tests := []struct {
+		name   string
+		writer bool
+	}{
+		{name: "Record writer", writer: true},
+		{name: "Replaced database"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var repo CocktailRepo
+			if tt.writer {
+				mRepo := mocks.NewCocktailRecordWriterRepo()
+				mRepo.On("ReadAllWithDeleted").Return(testVersionedCocktails(), nil)
+				mRepo.On("PutRecord", mock.Anything, mock.Anything, mock.Anything).Return(conflict)
+				repo = mRepo
+			} else {
+				// Written by another process between the two reads
+				written := testVersionedCocktails()
+				written[0].Version = 3
+				mRepo := mocks.NewCocktailRepo()
+				mRepo.On("ReadAllWithDeleted").Return(testVersionedCocktails(), nil).Once()
+				mRepo.On("ReadAllWithDeleted").Return(written, nil)
+				repo = mRepo
+			}
+			svc := NewCocktail(repo, nil)
+
+			_, err := svc.Update(testCtx, 1, AnyVersion, entity.Cocktail{Name: "Baz", Ingredients: []entity.Ingredient{{Name: "lime"}}, Instructions: "Pour"})
+			var versionErr *VersionErr
+			require.ErrorAs(t, err, &versionErr)
+			assert.Equal(t, 3, versionErr.Current)
+			assert.ErrorIs(t, err, ErrVersionMismatch)
+		})
+	}
+}
+
+func TestCocktail_Compact(t *testing.T) {
+	tests := []struct {
+		name   string
+		writer bool
+		folded int
+		err    error
+	}{
+		{name: "Compacted", writer: true, folded: 4},
+		{name: "Compact error", writer: true, err: testRepoErr},
+		{name: "Not journaled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var repo CocktailRepo = mocks.NewCocktailRepo()
+			if tt.writer {
+				mRepo := mocks.NewCocktailRecordWriterRepo()
+				mRepo.On("Compact").Return(tt.folded, tt.err)
+				repo = mRepo
+			}
+			svc := NewCocktail(repo, nil)
+
+			out, err := svc.Compact(testCtx)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, compactedStatus, out.Status)
+			assert.Equal(t, tt.folded, out.FoldedEntries)
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+)
+
+// The query token kinds.
+const (
+	eofTkn queryTknKind = iota
+	wordTkn
+	stringTkn
+	colonTkn
+	lParenTkn
+	rParenTkn
+	andTkn
+	orTkn
+	notTkn
+)
+
+// queryTknKind represents the kind of lexical token of a filter query.
+type queryTknKind int
+
+// queryTkn is a lexical token of a filter query.
+// pos is the 1-based position of the token's first character in the query.
+type queryTkn struct {
+	kind queryTknKind
+	text string
+	pos  int
+}
+
+// lexQuery splits the given filter query into tokens, ending with an eofTkn.
+// Words are delimited by whitespaces, colons and parentheses; quoted strings may hold any of them.
+// The AND, OR and NOT operators are case-insensitive.
+func lexQuery(q string) ([]queryTkn, error) {
+	runes := []rune(q)
+	tokens := make([]queryTkn, 0)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == ':':
+			tokens = append(tokens, queryTkn{kind: colonTkn, text: ":", pos: i + 1})
+			i++
+		case r == '(':
+			tokens = append(tokens, queryTkn{kind: lParenTkn, text: "(", pos: i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryTkn{kind: rParenTkn, text: ")", pos: i + 1})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, &QueryErr{Pos: i + 1, Err: ErrQueryUnclosedQuote}
+			}
+			tokens = append(tokens, queryTkn{kind: stringTkn, text: string(runes[i+1 : end]), pos: i + 1})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`:()"`, runes[end]) {
+				end++
+			}
+			word := string(runes[i:end])
+			kind := wordTkn
+			switch strings.ToUpper(word) {
+			case "AND":
+				kind = andTkn
+			case "OR":
+				kind = orTkn
+			case "NOT":
+				kind = notTkn
+			}
+			tokens = append(tokens, queryTkn{kind: kind, text: word, pos: i + 1})
+			i = end
+		}
+	}
+	return append(tokens, queryTkn{kind: eofTkn, pos: len(runes) + 1}), nil
+}
+
+// queryNode is a node of the abstract syntax tree of a filter query.
+type queryNode interface {
+	// eval reports whether the entity.Cocktail record satisfies the node.
+	eval(rec entity.Cocktail) bool
+}
+
+// andNode is satisfied when both sides are.
+type andNode struct {
+	left, right queryNode
+}
+
+func (n andNode) eval(rec entity.Cocktail) bool {
+	return n.left.eval(rec) && n.right.eval(rec)
+}
+
+// orNode is satisfied when any side is.
+type orNode struct {
+	left, right queryNode
+}
+
+func (n orNode) eval(rec entity.Cocktail) bool {
+	return n.left.eval(rec) || n.right.eval(rec)
+}
+
+// notNode negates the wrapped node.
+type notNode struct {
+	node queryNode
+}
+
+func (n notNode) eval(rec entity.Cocktail) bool {
+	return !n.node.eval(rec)
+}
+
+// termNode is a single field:value filter, evaluated with the filter's matcher.
+type termNode struct {
+	fltr  cocktailFilter
+	value string
+	id    int
+}
+
+func (n termNode) eval(rec entity.Cocktail) bool {
+	if n.fltr == idFltr {
+		return matchID(rec, n.id)
+	}
+	return cocktailMatchers[n.fltr](rec, n.value)
+}
+
+// queryParser is a recursive descent parser of filter queries with the following grammar:
+//
+//	query   = or EOF
+//	or      = and { "OR" and }
+//	and     = not { ["AND"] not }
+//	not     = "NOT" not | primary
+//	primary = "(" or ")" | term
+//	term    = field ":" ( word | string )
+//
+// Terms next to each other without an operator are joined by AND.
+// The parentheses and NOT operators can be nested up to maxQueryDepth levels.
+type queryParser struct {
+	tokens []queryTkn
+	pos    int
+	depth  int
+}
+
+// maxQueryDepth is the maximum nesting level of the parentheses and NOT operators of a query,
+// so a crafted query can't exhaust the stack of the parser.
+const maxQueryDepth = 64
+
+// parseQuery returns the abstract syntax tree of the given filter query.
+// e.g. `ingredient:vodka AND (glass:martini OR category:shot) AND NOT alcoholic:non`
+func parseQuery(q string) (queryNode, error) {
+	if strings.TrimSpace(q) == "" {
+		return nil, &QueryErr{Pos: 1, Err: ErrQueryEmpty}
+	}
+	tokens, err := lexQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tkn := p.peek(); tkn.kind != eofTkn {
+		return nil, &QueryErr{Pos: tkn.pos, Err: ErrQueryUnexpectedToken}
+	}
+	return node, nil
+}
+
+// peek returns the current token without consuming it.
+func (p *queryParser) peek() queryTkn {
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the current token.
+func (p *queryParser) next() queryTkn {
+	tkn := p.tokens[p.pos]
+	if tkn.kind != eofTkn {
+		p.pos++
+	}
+	return tkn
+}
+
+// nest enters a nesting level opened by the given token, failing if it's deeper than maxQueryDepth.
+// The returned func leaves the level.
+func (p *queryParser) nest(tkn queryTkn) (func(), error) {
+	if p.depth >= maxQueryDepth {
+		return nil, &QueryErr{Pos: tkn.pos, Err: ErrQueryTooDeep}
+	}
+	p.depth++
+	return func() { p.depth-- }, nil
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == orTkn {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case andTkn:
+			p.next()
+		case wordTkn, notTkn, lParenTkn:
+		default:
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left: left, right: right}
+	}
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.peek().kind != notTkn {
+		return p.parsePrimary()
+	}
+	leave, err := p.nest(p.next())
+	if err != nil {
+		return nil, err
+	}
+	defer leave()
+	node, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return notNode{node: node}, nil
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	tkn := p.next()
+	switch tkn.kind {
+	case lParenTkn:
+		leave, err := p.nest(tkn)
+		if err != nil {
+			return nil, err
+		}
+		defer leave()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != rParenTkn {
+			return nil, &QueryErr{Pos: tkn.pos, Err: ErrQueryUnclosedParen}
+		}
+		p.next()
+		return node, nil
+	case wordTkn:
+		return p.parseTerm(tkn)
+	case eofTkn:
+		return nil, &QueryErr{Pos: tkn.pos, Err: ErrQueryUnexpectedEnd}
+	default:
+		return nil, &QueryErr{Pos: tkn.pos, Err: ErrQueryUnexpectedToken}
+	}
+}
+
+// parseTerm parses the rest of a term whose field is the given token.
+func (p *queryParser) parseTerm(field queryTkn) (queryNode, error) {
+	fltr := newCocktailFltr(field.text)
+	if fltr == invalidFltr {
+		return nil, &QueryErr{Pos: field.pos, Err: ErrFltrInvalid}
+	}
+	if tkn := p.next(); tkn.kind != colonTkn {
+		return nil, &QueryErr{Pos: tkn.pos, Err: ErrQueryTermInvalid}
+	}
+
+	tkn := p.next()
+	switch tkn.kind {
+	case wordTkn, stringTkn, andTkn, orTkn, notTkn:
+	case eofTkn:
+		return nil, &QueryErr{Pos: tkn.pos, Err: ErrQueryUnexpectedEnd}
+	default:
+		return nil, &QueryErr{Pos: tkn.pos, Err: ErrQueryTermInvalid}
+	}
+	if tkn.text == "" {
+		return nil, &QueryErr{Pos: tkn.pos, Err: ErrFltrValueEmpty}
+	}
+
+	node := termNode{fltr: fltr, value: tkn.text}
+	if fltr == idFltr {
+		id, err := strconv.Atoi(tkn.text)
+		if err != nil {
+			return nil, &QueryErr{Pos: tkn.pos, Err: err}
+		}
+		node.id = id
+	}
+	return node, nil
+}
+
+// cocktailsByQuery returns the given entity.Cocktail records satisfying the query's syntax tree.
+func cocktailsByQuery(node queryNode, recs []entity.Cocktail) []entity.Cocktail {
+	cocktails := make([]entity.Cocktail, 0)
+	for _, rec := range recs {
+		if node.eval(rec) {
+			cocktails = append(cocktails, rec)
+		}
+	}
+	return cocktails
+}
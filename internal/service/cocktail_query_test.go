@@ -0,0 +1,180 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		pos   int
+		err   error
+	}{
+		{
+			name:  "Empty",
+			query: "   ",
+			pos:   1,
+			err:   ErrQueryEmpty,
+		},
+		{
+			name:  "Invalid filter",
+			query: "name:foo AND color:red",
+			pos:   14,
+			err:   ErrFltrInvalid,
+		},
+		{
+			name:  "Missing colon",
+			query: "name foo",
+			pos:   6,
+			err:   ErrQueryTermInvalid,
+		},
+		{
+			name:  "Missing value",
+			query: "name:",
+			pos:   6,
+			err:   ErrQueryUnexpectedEnd,
+		},
+		{
+			name:  "Empty quoted value",
+			query: `name:""`,
+			pos:   6,
+			err:   ErrFltrValueEmpty,
+		},
+		{
+			name:  "Unclosed quote",
+			query: `glass:"old fashioned`,
+			pos:   7,
+			err:   ErrQueryUnclosedQuote,
+		},
+		{
+			name:  "Unclosed parenthesis",
+			query: "name:foo AND (glass:shot OR glass:martini",
+			pos:   14,
+			err:   ErrQueryUnclosedParen,
+		},
+		{
+			name:  "Dangling operator",
+			query: "name:foo OR",
+			pos:   12,
+			err:   ErrQueryUnexpectedEnd,
+		},
+		{
+			name:  "Unexpected closing parenthesis",
+			query: "name:foo)",
+			pos:   9,
+			err:   ErrQueryUnexpectedToken,
+		},
+		{
+			name:  "Nested too deep",
+			query: strings.Repeat("(", 65) + "name:foo" + strings.Repeat(")", 65),
+			pos:   65,
+			err:   ErrQueryTooDeep,
+		},
+		{
+			name:  "NOT nested too deep",
+			query: strings.Repeat("NOT ", 65) + "name:foo",
+			pos:   257,
+			err:   ErrQueryTooDeep,
+		},
+		{
+			name:  "Nested at max depth",
+			query: strings.Repeat("NOT (", 32) + "name:foo" + strings.Repeat(")", 32),
+			err:   nil,
+		},
+		{
+			name:  "Bad ID",
+			query: "id:foo",
+			pos:   4,
+			err:   strconv.ErrSyntax,
+		},
+		{
+			name:  "Valid",
+			query: `ingredient:vodka AND (glass:martini OR category:shot) AND NOT alcoholic:non`,
+			err:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := parseQuery(tt.query)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.Nil(t, out)
+				assert.ErrorIs(t, err, tt.err)
+				var qErr *QueryErr
+				require.ErrorAs(t, err, &qErr)
+				assert.Equal(t, tt.pos, qErr.Pos)
+				return
+			}
+			require.Nil(t, err)
+			assert.NotNil(t, out)
+		})
+	}
+}
+
+func TestCocktailsByQuery(t *testing.T) {
+	recs := []entity.Cocktail{
+		{ID: 1, Name: "Vodka Martini", Alcoholic: "Alcoholic", Category: "Cocktail", Glass: "Martini glass", Ingredients: []entity.Ingredient{{Name: "Vodka"}, {Name: "Dry Vermouth"}}},
+		{ID: 2, Name: "Kamikaze", Alcoholic: "Alcoholic", Category: "Shot", Glass: "Shot glass", Ingredients: []entity.Ingredient{{Name: "Vodka"}, {Name: "Lime juice"}}},
+		{ID: 3, Name: "Virgin Mary", Alcoholic: "Non alcoholic", Category: "Shot", Glass: "Shot glass", Ingredients: []entity.Ingredient{{Name: "Vodka flavouring"}}},
+		{ID: 4, Name: "Old Fashioned", Alcoholic: "Alcoholic", Category: "Cocktail", Glass: "Old-fashioned glass", Ingredients: []entity.Ingredient{{Name: "Bourbon"}}},
+	}
+	tests := []struct {
+		name  string
+		query string
+		exp   []int
+	}{
+		{
+			name:  "Single term",
+			query: "category:shot",
+			exp:   []int{2, 3},
+		},
+		{
+			name:  "Boolean combination",
+			query: "ingredient:vodka AND (glass:martini OR category:shot) AND NOT alcoholic:non",
+			exp:   []int{1, 2},
+		},
+		{
+			name:  "Implicit AND",
+			query: "ingredient:vodka category:shot",
+			exp:   []int{2, 3},
+		},
+		{
+			name:  "AND binds tighter than OR",
+			query: "id:4 OR ingredient:vodka AND glass:martini",
+			exp:   []int{1, 4},
+		},
+		{
+			name:  "Case-insensitive operators and quoted values",
+			query: `not glass:"shot glass" and not name:old`,
+			exp:   []int{1},
+		},
+		{
+			name:  "No matches",
+			query: "name:margarita",
+			exp:   []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := parseQuery(tt.query)
+			require.Nil(t, err)
+
+			out := cocktailsByQuery(node, recs)
+			ids := make([]int, 0, len(out))
+			for _, rec := range out {
+				ids = append(ids, rec.ID)
+			}
+			assert.Equal(t, tt.exp, ids)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+)
+
+// AnyVersion matches the current version of any record, so the writes given it skip the version check.
+const AnyVersion = -1
+
+// Get returns the entity.Cocktail record of the given ID, looked up by its index if the repository implements
+// CocktailIndex. Returns a NotFoundErr if the record does not exist.
+func (s Cocktail) Get(id int) (entity.Cocktail, error) {
+	if idx, ok := s.repo.(CocktailIndex); ok {
+		rec, found, err := idx.ReadByID(id)
+		if err != nil {
+			return entity.Cocktail{}, err
+		}
+		if !found {
+			return entity.Cocktail{}, &NotFoundErr{fmt.Errorf("%w: %d", ErrCocktailNotFound, id)}
+		}
+		return rec, nil
+	}
+
+	recs, err := s.repo.ReadAll()
+	if err != nil {
+		return entity.Cocktail{}, err
+	}
+	index, found := findCocktail(id, recs)
+	if !found {
+		return entity.Cocktail{}, &NotFoundErr{fmt.Errorf("%w: %d", ErrCocktailNotFound, id)}
+	}
+	return recs[index], nil
+}
+
+// Update replaces the record of the given ID with the given values, provided the record is at the given version.
+// The record keeps its ID, creation date and origin, its update date is set to now and its version incremented.
+// Once written, the updated record is published and returned. The revision is authored by the principal of the context.
+// Returns an ArgsErr if a required value is missing, a NotFoundErr if the record does not exist, and a VersionErr
+// if the record is not at the given version, which means it was written since that version was read. Given
+// AnyVersion, a VersionErr is still returned if the record is written by another process while being updated.
+func (s Cocktail) Update(ctx context.Context, id, version int, c entity.Cocktail) (entity.Cocktail, error) {
+	if err := validateCocktail(c); err != nil {
+		return entity.Cocktail{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	recs, index, err := s.readVersion(id, version)
+	if err != nil {
+		return entity.Cocktail{}, err
+	}
+
+	c.ID = id
+	c.CreatedAt = recs[index].CreatedAt
+	c.Origin = recs[index].Origin
+	c.OrphanedAt = recs[index].OrphanedAt
+	c.UpdatedAt = dateTimeNow()
+	c.Version = recs[index].Version + 1
+	if err := s.putRecord(c, recs[index].Version, newAuthor(ctx, entity.SourceEdit)); err != nil {
+		return entity.Cocktail{}, err
+	}
+
+	if s.events != nil {
+		s.events.Publish(CocktailUpdatedEvent, c)
+	}
+	return c, nil
+}
+
+// Delete deletes the record of the given ID, provided the record is at the given version.
+// The record is kept as a tombstone, dated now and at the next version, so it can be restored until purged.
+// Once deleted, the deleted record is published. The revision is authored by the principal of the context.
+// Returns a NotFoundErr if the record does not exist, and a VersionErr if the record is not at the given version.
+func (s Cocktail) Delete(ctx context.Context, id, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	recs, index, err := s.readVersion(id, version)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := dateTimeNow()
+	deleted := recs[index]
+	deleted.UpdatedAt = deletedAt
+	deleted.DeletedAt = &deletedAt
+	deleted.Version++
+	if err := s.putRecord(deleted, recs[index].Version, newAuthor(ctx, entity.SourceEdit)); err != nil {
+		return err
+	}
+
+	if s.events != nil {
+		s.events.Publish(CocktailDeletedEvent, deleted)
+	}
+	return nil
+}
+
+// readVersion returns all the database records, tombstones included, along with the index of the record of the given ID.
+// Returns a NotFoundErr if the record does not exist or is deleted, and a VersionErr if it is not at the given version.
+func (s Cocktail) readVersion(id, version int) ([]entity.Cocktail, int, error) {
+	recs, err := s.repo.ReadAllWithDeleted()
+	if err != nil {
+		return nil, 0, err
+	}
+	index, found := findCocktail(id, recs)
+	if !found || recs[index].Deleted() {
+		return nil, 0, &NotFoundErr{fmt.Errorf("%w: %d", ErrCocktailNotFound, id)}
+	}
+	if current := recs[index].Version; version != AnyVersion && version != current {
+		return nil, 0, &VersionErr{Current: current, Err: fmt.Errorf("%w: record %d at version %d", ErrVersionMismatch, id, version)}
+	}
+	return recs, index, nil
+}
+
+// validateCocktail checks the given record holds the values required by the database: the name, the
+// ingredients and the instructions. Returns an ArgsErr holding a field error per missing value.
+func validateCocktail(c entity.Cocktail) error {
+	errs := make([]error, 0)
+	if c.Name == "" {
+		errs = append(errs, &ct.FieldErr{Field: "name", Err: ErrFieldRequired})
+	}
+	if len(c.Ingredients) == 0 {
+		errs = append(errs, &ct.FieldErr{Field: "ingredients", Err: ErrFieldRequired})
+	}
+	if c.Instructions == "" {
+		errs = append(errs, &ct.FieldErr{Field: "instructions", Err: ErrFieldRequired})
+	}
+	if len(errs) > 0 {
+		return &ArgsErr{errors.Join(errs...)}
+	}
+	return nil
+}
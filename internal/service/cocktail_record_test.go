@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	// testCtx is the context of the test writes, made on behalf of the "test" principal.
+	testCtx = ct.WithPrincipal(context.Background(), ct.Principal{Name: "test", Role: ct.EditorRole})
+	// testEditor is the author of the test writes.
+	testEditor = entity.Author{Actor: "test", Source: entity.SourceEdit}
+)
+
+// testVersionedCocktails returns new records at different versions, created a day ago.
+func testVersionedCocktails() []entity.Cocktail {
+	created := dateTimeNow().Add(-24 * time.Hour)
+	return []entity.Cocktail{
+		{ID: 1, Name: "Foo", Ingredients: []entity.Ingredient{{Name: "soda"}}, Instructions: "Shake", CreatedAt: created, UpdatedAt: created, Version: 1},
+		{ID: 2, Name: "Bar", Ingredients: []entity.Ingredient{{Name: "water"}}, Instructions: "Stir", CreatedAt: created, UpdatedAt: created, Version: 3},
+	}
+}
+
+// testTombstone returns a record deleted an hour ago.
+func testTombstone() entity.Cocktail {
+	created := dateTimeNow().Add(-24 * time.Hour)
+	deleted := dateTimeNow().Add(-1 * time.Hour)
+	return entity.Cocktail{ID: 3, Name: "Baz", Ingredients: []entity.Ingredient{{Name: "lime"}}, Instructions: "Pour",
+		CreatedAt: created, UpdatedAt: deleted, Version: 2, DeletedAt: &deleted}
+}
+
+func TestCocktail_Get(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      int
+		repoErr error
+		err     error
+	}{
+		{name: "Repository error", id: 1, repoErr: testRepoErr, err: testRepoErr},
+		{name: "Not found", id: 9, err: ErrCocktailNotFound},
+		{name: "Found", id: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadAll").Return(testVersionedCocktails(), tt.repoErr)
+			svc := NewCocktail(mRepo, nil)
+
+			out, err := svc.Get(tt.id)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, testVersionedCocktails()[1], out)
+		})
+	}
+}
+
+func TestCocktail_Update(t *testing.T) {
+	values := entity.Cocktail{ID: 7, Name: "Baz", Ingredients: []entity.Ingredient{{Name: "lime"}}, Instructions: "Pour", Version: 9}
+	tests := []struct {
+		name       string
+		id         int
+		version    int
+		values     entity.Cocktail
+		replaceErr error
+		err        error
+	}{
+		{name: "Missing values", id: 2, version: 3, values: entity.Cocktail{Name: "Baz"}, err: ErrFieldRequired},
+		{name: "Not found", id: 9, version: 3, values: values, err: ErrCocktailNotFound},
+		{name: "Deleted", id: 3, version: 2, values: values, err: ErrCocktailNotFound},
+		{name: "Version mismatch", id: 2, version: 2, values: values, err: ErrVersionMismatch},
+		{name: "Replace error", id: 2, version: 3, values: values, replaceErr: testRepoErr, err: testRepoErr},
+		{name: "Updated", id: 2, version: 3, values: values},
+		{name: "Updated at any version", id: 2, version: AnyVersion, values: values},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadAllWithDeleted").Return(append(testVersionedCocktails(), testTombstone()), nil)
+			mRepo.On("ReplaceDB", mock.Anything, mock.Anything).Return(tt.replaceErr)
+			mEvents := mocks.NewEventPublisher()
+			mEvents.On("Publish", mock.Anything, mock.Anything).Return()
+			svc := NewCocktail(mRepo, mEvents)
+
+			out, err := svc.Update(testCtx, tt.id, tt.version, tt.values)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				mEvents.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+				return
+			}
+			require.Nil(t, err)
+			prev := testVersionedCocktails()[1]
+			exp := tt.values
+			exp.ID = prev.ID
+			exp.CreatedAt = prev.CreatedAt
+			exp.UpdatedAt = dateTimeNow()
+			exp.Version = prev.Version + 1
+			assert.Equal(t, exp, out)
+			mRepo.AssertCalled(t, "ReplaceDB", []entity.Cocktail{testVersionedCocktails()[0], exp, testTombstone()}, testEditor)
+			mEvents.AssertCalled(t, "Publish", CocktailUpdatedEvent, exp)
+		})
+	}
+}
+
+func TestCocktail_Update_VersionErr(t *testing.T) {
+	mRepo := mocks.NewCocktailRepo()
+	mRepo.On("ReadAllWithDeleted").Return(testVersionedCocktails(), nil)
+	svc := NewCocktail(mRepo, nil)
+
+	_, err := svc.Update(context.Background(), 2, 1, testVersionedCocktails()[1])
+	var versionErr *VersionErr
+	require.ErrorAs(t, err, &versionErr)
+	assert.Equal(t, 3, versionErr.Current)
+}
+
+func TestCocktail_Delete(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         int
+		version    int
+		replaceErr error
+		err        error
+	}{
+		{name: "Not found", id: 9, version: 1, err: ErrCocktailNotFound},
+		{name: "Already deleted", id: 3, version: 2, err: ErrCocktailNotFound},
+		{name: "Version mismatch", id: 1, version: 3, err: ErrVersionMismatch},
+		{name: "Replace error", id: 1, version: 1, replaceErr: testRepoErr, err: testRepoErr},
+		{name: "Deleted", id: 1, version: 1},
+		{name: "Deleted at any version", id: 1, version: AnyVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadAllWithDeleted").Return(append(testVersionedCocktails(), testTombstone()), nil)
+			mRepo.On("ReplaceDB", mock.Anything, mock.Anything).Return(tt.replaceErr)
+			mEvents := mocks.NewEventPublisher()
+			mEvents.On("Publish", mock.Anything, mock.Anything).Return()
+			svc := NewCocktail(mRepo, mEvents)
+
+			err := svc.Delete(testCtx, tt.id, tt.version)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				mEvents.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+				return
+			}
+			require.Nil(t, err)
+			deletedAt := dateTimeNow()
+			deleted := testVersionedCocktails()[0]
+			deleted.UpdatedAt = deletedAt
+			deleted.DeletedAt = &deletedAt
+			deleted.Version = 2
+			mRepo.AssertCalled(t, "ReplaceDB", []entity.Cocktail{deleted, testVersionedCocktails()[1], testTombstone()}, testEditor)
+			mEvents.AssertCalled(t, "Publish", CocktailDeletedEvent, deleted)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+)
+
+// handleRemoved applies the removed policy to the database records removed from the data API: the upstream
+// records, not deleted, missing in the fetched ones. The flagged and deleted records are written in place, dated now
+// and at the next version. Returns the IDs of the removed records, along with the flagged and deleted ones; the
+// records flagged already are reported but not flagged again.
+// The records of the skipped IDs, fetched but failing to parse, are still upstream, so they are not removed.
+// An empty fetch removes nothing, as the data API is more likely failing than emptied.
+func (s Cocktail) handleRemoved(dataSet, fetched []entity.Cocktail, skipped []int) (ids []int, flagged, deleted []entity.Cocktail) {
+	if len(fetched) == 0 {
+		logger.Log().Warn().Msg("handleRemoved: no records fetched, removed records not checked")
+		return nil, nil, nil
+	}
+
+	found := make(map[int]bool, len(fetched)+len(skipped))
+	for _, rec := range fetched {
+		found[rec.ID] = true
+	}
+	for _, id := range skipped {
+		found[id] = true
+	}
+	now := dateTimeNow()
+	for i, rec := range dataSet {
+		if found[rec.ID] || !rec.Upstream() || rec.Deleted() {
+			continue
+		}
+		ids = append(ids, rec.ID)
+		switch {
+		case s.removed == ct.RemovedFlag && !rec.Orphaned():
+			rec.OrphanedAt = &now
+			rec.UpdatedAt = now
+			rec.Version++
+			flagged = append(flagged, rec)
+		case s.removed == ct.RemovedDelete:
+			rec.DeletedAt = &now
+			rec.UpdatedAt = now
+			rec.Version++
+			deleted = append(deleted, rec)
+		default:
+			continue
+		}
+		dataSet[i] = rec
+	}
+	if len(ids) > 0 {
+		logger.Log().Info().Ints("ids", ids).Str("policy", string(s.removed)).Msg("handleRemoved: records removed upstream")
+	}
+	return ids, flagged, deleted
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCocktail_WithRemovedPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		exp    ct.RemovedPolicy
+		err    error
+	}{
+		{name: "Ignore", policy: "ignore", exp: ct.RemovedIgnore},
+		{name: "Flag", policy: "flag", exp: ct.RemovedFlag},
+		{name: "Delete", policy: "delete", exp: ct.RemovedDelete},
+		{name: "Invalid", policy: "drop", err: ErrRemovedPolicyInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := NewCocktail(mocks.NewCocktailRepo(), nil).WithRemovedPolicy(tt.policy)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp, out.removed)
+		})
+	}
+}
+
+func TestCocktail_UpdateDBRemoved(t *testing.T) {
+	orphanedAt := dateTimeNow().Add(-1 * time.Hour)
+	local := []entity.Cocktail{
+		{ID: 1, Name: "foo", Origin: entity.OriginUpstream, Version: 1},
+		{ID: 2, Name: "bar", Origin: entity.OriginUpstream, Version: 1},
+		{ID: 3, Name: "baz", Origin: entity.OriginLocal, Version: 1},
+	}
+	fetched := []entity.Cocktail{{ID: 1, Name: "foo", Origin: entity.OriginUpstream, Version: 1}}
+	tests := []struct {
+		name      string
+		policy    string
+		readResp  []entity.Cocktail
+		fetchResp []entity.Cocktail
+		skipped   []int
+		createArg []entity.Cocktail
+		event     string
+		exp       ct.DBOpsSummary
+	}{
+		{
+			name:      "Ignored",
+			policy:    "ignore",
+			readResp:  local,
+			fetchResp: fetched,
+			exp: ct.DBOpsSummary{
+				Status: noChangesDBStatus, TotalRecs: 3,
+				RemovedRecs: 1, RemovedIDs: []int{2}, RemovedPolicy: ct.RemovedIgnore,
+			},
+		},
+		{
+			name:      "Flagged",
+			policy:    "flag",
+			readResp:  local,
+			fetchResp: fetched,
+			createArg: []entity.Cocktail{
+				local[0],
+				{ID: 2, Name: "bar", Origin: entity.OriginUpstream, UpdatedAt: dateTimeNow(), Version: 2, OrphanedAt: ptrTime(dateTimeNow())},
+				local[2],
+			},
+			event: CocktailUpdatedEvent,
+			exp: ct.DBOpsSummary{
+				Status: successfulUpdateDBStatus, ModifiedRecs: 1, TotalOps: 1, TotalRecs: 3,
+				RemovedRecs: 1, RemovedIDs: []int{2}, RemovedPolicy: ct.RemovedFlag,
+			},
+		},
+		{
+			name:   "Flagged already",
+			policy: "flag",
+			readResp: []entity.Cocktail{
+				local[0],
+				{ID: 2, Name: "bar", Origin: entity.OriginUpstream, Version: 2, OrphanedAt: &orphanedAt},
+			},
+			fetchResp: fetched,
+			exp: ct.DBOpsSummary{
+				Status: noChangesDBStatus, TotalRecs: 2,
+				RemovedRecs: 1, RemovedIDs: []int{2}, RemovedPolicy: ct.RemovedFlag,
+			},
+		},
+		{
+			name:      "Deleted",
+			policy:    "delete",
+			readResp:  local,
+			fetchResp: fetched,
+			createArg: []entity.Cocktail{
+				local[0],
+				{ID: 2, Name: "bar", Origin: entity.OriginUpstream, UpdatedAt: dateTimeNow(), Version: 2, DeletedAt: ptrTime(dateTimeNow())},
+				local[2],
+			},
+			event: CocktailDeletedEvent,
+			exp: ct.DBOpsSummary{
+				Status: successfulUpdateDBStatus, DeletedRecs: 1, TotalOps: 1, TotalRecs: 3,
+				RemovedRecs: 1, RemovedIDs: []int{2}, RemovedPolicy: ct.RemovedDelete,
+			},
+		},
+		{
+			name:   "Orphan found again",
+			policy: "flag",
+			readResp: []entity.Cocktail{
+				{ID: 1, Name: "foo", Origin: entity.OriginUpstream, Version: 2, OrphanedAt: &orphanedAt},
+			},
+			fetchResp: fetched,
+			createArg: []entity.Cocktail{
+				{ID: 1, Name: "foo", Origin: entity.OriginUpstream, UpdatedAt: dateTimeNow(), Version: 3},
+			},
+			event: CocktailUpdatedEvent,
+			exp: ct.DBOpsSummary{
+				Status: successfulUpdateDBStatus, ModifiedRecs: 1, TotalOps: 1, TotalRecs: 1,
+				RemovedPolicy: ct.RemovedFlag,
+			},
+		},
+		{
+			name:      "Skipped upstream",
+			policy:    "delete",
+			readResp:  local,
+			fetchResp: fetched,
+			skipped:   []int{2},
+			exp: ct.DBOpsSummary{
+				Status: noChangesDBStatus, TotalRecs: 3, RemovedPolicy: ct.RemovedDelete,
+			},
+		},
+		{
+			name:     "Empty fetch",
+			policy:   "delete",
+			readResp: local,
+			exp: ct.DBOpsSummary{
+				Status: noChangesDBStatus, TotalRecs: 3, RemovedPolicy: ct.RemovedDelete,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadAllWithDeleted").Return(copyCocktails(tt.readResp), nil)
+			mRepo.On("Fetch").Return(tt.fetchResp, tt.skipped, nil)
+			mRepo.On("ReplaceDB", mock.Anything, mock.Anything).Return(nil)
+			mRepo.On("Snapshot", mock.Anything).Return(nil)
+			mEvents := mocks.NewEventPublisher()
+			mEvents.On("Publish", mock.Anything, mock.Anything).Return()
+			svc, err := NewCocktail(mRepo, mEvents).WithRemovedPolicy(tt.policy)
+			require.Nil(t, err)
+
+			out, err := svc.UpdateDB(context.Background(), false)
+			require.Nil(t, err)
+			out.TxID, out.StartTime, out.EndTime, out.Duration = "", time.Time{}, time.Time{}, ""
+			assert.Equal(t, tt.exp, out)
+			if tt.createArg == nil {
+				mRepo.AssertNotCalled(t, "ReplaceDB", mock.Anything, mock.Anything)
+				return
+			}
+			mRepo.AssertCalled(t, "ReplaceDB", tt.createArg, entity.Author{Source: entity.SourceSync})
+			mEvents.AssertCalled(t, "Publish", tt.event, mock.Anything)
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
+
+func copyCocktails(recs []entity.Cocktail) []entity.Cocktail {
+	return append([]entity.Cocktail(nil), recs...)
+}
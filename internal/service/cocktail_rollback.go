@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+)
+
+// Rollback restores the database to its content before the sync transaction of the given ID, and returns the
+// database operations summary. Every write made since is undone, not just the ones of the sync.
+// The restored records get their version incremented past the current one, so the edits based on the
+// rolled back content fail their version check, and the records removed since are created again.
+// The rollback is a sync transaction itself: the database is snapshotted first under a new transaction ID,
+// returned in the summary, so the rollback can be rolled back too. The current records are read and the restored
+// ones written at once by the repository, so no write made in between is left out of the diff.
+// Once written, an event is published for every restored record, followed by a rolled back event holding the summary.
+// Returns a NotFoundErr if the sync transaction has no snapshot.
+func (s Cocktail) Rollback(ctx context.Context, txID string) (ct.DBOpsSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot, found, err := s.repo.ReadSnapshot(txID)
+	if err != nil {
+		return ct.DBOpsSummary{}, err
+	}
+	if !found {
+		return ct.DBOpsSummary{}, &NotFoundErr{fmt.Errorf("%w: %s", ErrSyncTxNotFound, txID)}
+	}
+
+	start := time.Now().UTC()
+	rollbackTxID := newTxID(start)
+	var restored, created, modified, deleted []entity.Cocktail
+	err = s.repo.ReplaceDBFunc(rollbackTxID, newAuthor(ctx, entity.SourceRollback), func(current []entity.Cocktail) ([]entity.Cocktail, error) {
+		restored, created, modified, deleted = diffRollback(current, snapshot)
+		if len(created)+len(modified)+len(deleted) == 0 {
+			return nil, nil
+		}
+		return restored, nil
+	})
+	if err != nil {
+		return ct.DBOpsSummary{}, err
+	}
+	totalOps := len(created) + len(modified) + len(deleted)
+	status := noChangesDBStatus
+	if totalOps > 0 {
+		status = successfulRollbackStatus
+	} else {
+		rollbackTxID = ""
+	}
+
+	end := time.Now().UTC()
+	summary := ct.DBOpsSummary{
+		TxID:         rollbackTxID,
+		Status:       status,
+		StartTime:    start,
+		EndTime:      end,
+		Duration:     end.Sub(start).String(),
+		NewRecs:      len(created),
+		ModifiedRecs: len(modified),
+		DeletedRecs:  len(deleted),
+		TotalOps:     totalOps,
+		TotalRecs:    len(restored),
+	}
+
+	if s.events != nil {
+		for _, rec := range created {
+			s.events.Publish(CocktailCreatedEvent, rec)
+		}
+		for _, rec := range modified {
+			s.events.Publish(CocktailUpdatedEvent, rec)
+		}
+		for _, rec := range deleted {
+			s.events.Publish(CocktailDeletedEvent, rec)
+		}
+		s.events.Publish(SyncRolledBackEvent, summary)
+	}
+	return summary, nil
+}
+
+// diffRollback returns the snapshot records to restore over the current ones, along with the records created
+// again, the records modified back and the current records deleted. The snapshot records equal to the current
+// ones are kept as they are; the rest are written now, at the version following the current one.
+// The tombstones are compared too, so the records deleted or restored since are restored or deleted back.
+func diffRollback(current, snapshot []entity.Cocktail) (restored, created, modified, deleted []entity.Cocktail) {
+	currentByID := make(map[int]entity.Cocktail, len(current))
+	for _, rec := range current {
+		currentByID[rec.ID] = rec
+	}
+
+	restored = make([]entity.Cocktail, 0, len(snapshot))
+	created = make([]entity.Cocktail, 0)
+	modified = make([]entity.Cocktail, 0)
+	kept := make(map[int]bool, len(snapshot))
+	for _, rec := range snapshot {
+		kept[rec.ID] = true
+		cur, found := currentByID[rec.ID]
+		switch {
+		case !found:
+			rec.UpdatedAt = dateTimeNow()
+			rec.Version++
+			created = append(created, rec)
+		case !cocktailsEqual(rec, cur) || !rec.SrcDate.Equal(cur.SrcDate) || rec.Deleted() != cur.Deleted():
+			rec.UpdatedAt = dateTimeNow()
+			rec.Version = cur.Version + 1
+			modified = append(modified, rec)
+		default:
+			rec = cur
+		}
+		restored = append(restored, rec)
+	}
+
+	deleted = make([]entity.Cocktail, 0)
+	for _, rec := range current {
+		if !kept[rec.ID] {
+			deleted = append(deleted, rec)
+		}
+	}
+	return restored, created, modified, deleted
+}
+
+// newTxID returns a new sync transaction ID, made of the given start time and a random suffix, so the IDs
+// sort in start order. e.g. "20231001T123015Z-1a2b3c4d"
+func newTxID(start time.Time) string {
+	return start.UTC().Format("20060102T150405Z") + "-" + randomHex(4)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"testing"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCocktail_Rollback(t *testing.T) {
+	const txID = "20231001T123015Z-1a2b3c4d"
+	snapshot := testVersionedCocktails()
+	// Since the sync, Foo was rewritten, Bar deleted and Baz created.
+	fooSynced := snapshot[0]
+	fooSynced.Instructions = "Shake hard"
+	fooSynced.Version = 2
+	baz := entity.Cocktail{ID: 3, Name: "Baz", Ingredients: []entity.Ingredient{{Name: "lime"}}, Instructions: "Pour", Version: 1}
+	current := []entity.Cocktail{fooSynced, baz}
+
+	fooRestored := snapshot[0]
+	fooRestored.UpdatedAt = dateTimeNow()
+	fooRestored.Version = 3
+	barRestored := snapshot[1]
+	barRestored.UpdatedAt = dateTimeNow()
+	barRestored.Version = 4
+
+	tests := []struct {
+		name       string
+		current    []entity.Cocktail
+		found      bool
+		replaceErr error
+		exp        ct.DBOpsSummary
+		restored   []entity.Cocktail
+		err        error
+	}{
+		{name: "Unknown transaction", current: current, err: ErrSyncTxNotFound},
+		{name: "Replace error", current: current, found: true, replaceErr: testRepoErr, err: testRepoErr},
+		{
+			name:     "Rolled back",
+			current:  current,
+			found:    true,
+			exp:      ct.DBOpsSummary{Status: successfulRollbackStatus, NewRecs: 1, ModifiedRecs: 1, DeletedRecs: 1, TotalOps: 3, TotalRecs: 2},
+			restored: []entity.Cocktail{fooRestored, barRestored},
+		},
+		{
+			name:    "No changes since",
+			current: testVersionedCocktails(),
+			found:   true,
+			exp:     ct.DBOpsSummary{Status: noChangesDBStatus, TotalRecs: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadSnapshot", txID).Return(snapshot, tt.found, nil)
+			mRepo.On("ReadAllWithDeleted").Return(tt.current, nil)
+			mRepo.On("Snapshot", mock.Anything).Return(nil)
+			mRepo.On("ReplaceDB", mock.Anything, mock.Anything).Return(tt.replaceErr)
+			mEvents := mocks.NewEventPublisher()
+			mEvents.On("Publish", mock.Anything, mock.Anything).Return()
+			svc := NewCocktail(mRepo, mEvents)
+
+			out, err := svc.Rollback(testCtx, txID)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				mEvents.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp.Status, out.Status)
+			assert.Equal(t, tt.exp.NewRecs, out.NewRecs)
+			assert.Equal(t, tt.exp.ModifiedRecs, out.ModifiedRecs)
+			assert.Equal(t, tt.exp.DeletedRecs, out.DeletedRecs)
+			assert.Equal(t, tt.exp.TotalOps, out.TotalOps)
+			assert.Equal(t, tt.exp.TotalRecs, out.TotalRecs)
+			mEvents.AssertCalled(t, "Publish", SyncRolledBackEvent, out)
+			if tt.exp.TotalOps == 0 {
+				assert.Empty(t, out.TxID)
+				mRepo.AssertNotCalled(t, "ReplaceDB", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NotEqual(t, txID, out.TxID)
+			mRepo.AssertCalled(t, "Snapshot", out.TxID)
+			mRepo.AssertCalled(t, "ReplaceDB", tt.restored, entity.Author{Actor: "test", Source: entity.SourceRollback})
+			mEvents.AssertCalled(t, "Publish", CocktailDeletedEvent, baz)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+)
+
+// defaultTopIngredients is the number of most common ingredients reported by default.
+const defaultTopIngredients = 10
+
+// facetCounter counts the records holding each value of a property.
+// Values are grouped by their normalized form, and reported with their most common spelling.
+type facetCounter struct {
+	counts    map[string]int
+	spellings map[string]map[string]int
+	normalize func(string) string
+}
+
+// newFacetCounter returns a new empty facetCounter, normalizing the values with normalizeFuzzy.
+func newFacetCounter() *facetCounter {
+	return &facetCounter{
+		counts:    make(map[string]int),
+		spellings: make(map[string]map[string]int),
+		normalize: normalizeFuzzy,
+	}
+}
+
+// newTagCounter returns a new empty facetCounter of tags, normalizing them as the tag filter does, so the facets
+// group the tags it matches alike. See normalizeTag.
+func newTagCounter() *facetCounter {
+	counter := newFacetCounter()
+	counter.normalize = normalizeTag
+	return counter
+}
+
+// add counts the given value once. Empty values are ignored.
+// The whitespaces of the spelling are collapsed, but its case is kept.
+func (f *facetCounter) add(value string) {
+	value = strings.Join(strings.Fields(value), " ")
+	key := f.normalize(value)
+	if key == "" {
+		return
+	}
+	f.counts[key]++
+	if f.spellings[key] == nil {
+		f.spellings[key] = make(map[string]int)
+	}
+	f.spellings[key][value]++
+}
+
+// canonical returns the most common spelling of the given normalized value.
+// Ties are broken by the lexicographically smallest spelling.
+func (f *facetCounter) canonical(key string) string {
+	canonical, most := "", 0
+	for spelling, n := range f.spellings[key] {
+		if n > most || (n == most && spelling < canonical) {
+			canonical, most = spelling, n
+		}
+	}
+	return canonical
+}
+
+// facets returns the counted values sorted by count in descending order, then by value.
+// If top is greater than zero, only the top most common values are returned.
+func (f *facetCounter) facets(top int) []ct.FacetCount {
+	facets := make([]ct.FacetCount, 0, len(f.counts))
+	for key, count := range f.counts {
+		facets = append(facets, ct.FacetCount{Value: f.canonical(key), Count: count})
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Value < facets[j].Value
+	})
+	if top > 0 && len(facets) > top {
+		facets = facets[:top]
+	}
+	return facets
+}
+
+// cocktailStats returns the aggregate statistics of the given records.
+// top is the number of most common ingredients to report.
+func cocktailStats(recs []entity.Cocktail, top int) ct.CocktailStats {
+	categories := newFacetCounter()
+	glasses := newFacetCounter()
+	alcoholic := newFacetCounter()
+	iba := newFacetCounter()
+	tags := newTagCounter()
+	ingredients := newFacetCounter()
+	distribution := make(map[int]int)
+	totalIngredients := 0
+
+	for _, rec := range recs {
+		categories.add(rec.Category)
+		glasses.add(rec.Glass)
+		alcoholic.add(rec.Alcoholic)
+		iba.add(rec.IBA)
+
+		addDistinct(tags, rec.Tags)
+		addDistinct(ingredients, ingredientNames(rec))
+		distribution[len(rec.Ingredients)]++
+		totalIngredients += len(rec.Ingredients)
+	}
+
+	stats := ct.CocktailStats{
+		TotalRecs:               len(recs),
+		Categories:              categories.facets(0),
+		Glasses:                 glasses.facets(0),
+		Alcoholic:               alcoholic.facets(0),
+		IBA:                     iba.facets(0),
+		Tags:                    tags.facets(0),
+		TopIngredients:          ingredients.facets(top),
+		IngredientsDistribution: make([]ct.IngredientsCount, 0, len(distribution)),
+	}
+	for n, count := range distribution {
+		stats.IngredientsDistribution = append(stats.IngredientsDistribution, ct.IngredientsCount{Ingredients: n, Count: count})
+	}
+	sort.Slice(stats.IngredientsDistribution, func(i, j int) bool {
+		return stats.IngredientsDistribution[i].Ingredients < stats.IngredientsDistribution[j].Ingredients
+	})
+	if len(recs) > 0 {
+		stats.IngredientsAvg = math.Round(float64(totalIngredients)/float64(len(recs))*100) / 100
+	}
+	return stats
+}
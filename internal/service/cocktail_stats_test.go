@@ -0,0 +1,62 @@
+package service
+
+import (
+	"testing"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCocktailStats(t *testing.T) {
+	recs := []entity.Cocktail{
+		{ID: 1, Category: "Cocktail", Glass: "Martini glass", Alcoholic: "Alcoholic", IBA: "Unforgettables", Tags: []string{"IBA", "Classic"}, Ingredients: []entity.Ingredient{{Name: "Gin"}, {Name: "Dry Vermouth"}}},
+		{ID: 2, Category: "cocktail ", Glass: "Martini Glass", Alcoholic: "Alcoholic", Tags: []string{"Classic"}, Ingredients: []entity.Ingredient{{Name: "Vodka"}, {Name: "Dry vermouth"}}},
+		{ID: 3, Category: "Shot", Glass: "Shot glass", Alcoholic: "Alcoholic", Ingredients: []entity.Ingredient{{Name: "Vodka"}, {Name: "Lime juice"}, {Name: "Triple sec"}}},
+		{ID: 4, Category: "Cocktail", Glass: "Martini glass", Alcoholic: "Non alcoholic", Ingredients: []entity.Ingredient{{Name: "Water"}, {Name: "water"}}},
+	}
+	tests := []struct {
+		name string
+		recs []entity.Cocktail
+		top  int
+		exp  ct.CocktailStats
+	}{
+		{
+			name: "Empty",
+			recs: []entity.Cocktail{},
+			top:  10,
+			exp: ct.CocktailStats{
+				Categories:              []ct.FacetCount{},
+				Glasses:                 []ct.FacetCount{},
+				Alcoholic:               []ct.FacetCount{},
+				IBA:                     []ct.FacetCount{},
+				Tags:                    []ct.FacetCount{},
+				TopIngredients:          []ct.FacetCount{},
+				IngredientsDistribution: []ct.IngredientsCount{},
+			},
+		},
+		{
+			name: "Normalized values",
+			recs: recs,
+			top:  2,
+			exp: ct.CocktailStats{
+				TotalRecs:               4,
+				Categories:              []ct.FacetCount{{Value: "Cocktail", Count: 3}, {Value: "Shot", Count: 1}},
+				Glasses:                 []ct.FacetCount{{Value: "Martini glass", Count: 3}, {Value: "Shot glass", Count: 1}},
+				Alcoholic:               []ct.FacetCount{{Value: "Alcoholic", Count: 3}, {Value: "Non alcoholic", Count: 1}},
+				IBA:                     []ct.FacetCount{{Value: "Unforgettables", Count: 1}},
+				Tags:                    []ct.FacetCount{{Value: "Classic", Count: 2}, {Value: "IBA", Count: 1}},
+				TopIngredients:          []ct.FacetCount{{Value: "Dry Vermouth", Count: 2}, {Value: "Vodka", Count: 2}},
+				IngredientsDistribution: []ct.IngredientsCount{{Ingredients: 2, Count: 3}, {Ingredients: 3, Count: 1}},
+				IngredientsAvg:          2.25,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.exp, cocktailStats(tt.recs, tt.top))
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"sort"
+	"strings"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+)
+
+// The cocktail taxonomy definitions
+const (
+	invalidTaxonomy    cocktailTaxonomy = "invalid"
+	categoryTaxonomy   cocktailTaxonomy = "categories"
+	glassTaxonomy      cocktailTaxonomy = "glasses"
+	ingredientTaxonomy cocktailTaxonomy = "ingredients"
+	tagTaxonomy        cocktailTaxonomy = "tags"
+	alcoholicTaxonomy  cocktailTaxonomy = "alcoholic-types"
+)
+
+// cocktailTaxonomy represents a cocktail property whose distinct values can be listed.
+type cocktailTaxonomy string
+
+func (t cocktailTaxonomy) String() string {
+	return string(t)
+}
+
+// newCocktailTaxonomy returns the cocktailTaxonomy associated to the given taxonomy.
+func newCocktailTaxonomy(taxonomy string) cocktailTaxonomy {
+	switch strings.ToLower(taxonomy) {
+	case categoryTaxonomy.String():
+		return categoryTaxonomy
+	case glassTaxonomy.String():
+		return glassTaxonomy
+	case ingredientTaxonomy.String():
+		return ingredientTaxonomy
+	case tagTaxonomy.String():
+		return tagTaxonomy
+	case alcoholicTaxonomy.String():
+		return alcoholicTaxonomy
+	default:
+		return invalidTaxonomy
+	}
+}
+
+// taxonomyValues returns the distinct values of the taxonomy's property, sorted by their normalized form.
+// Each value is counted once per record.
+func taxonomyValues(taxonomy cocktailTaxonomy, recs []entity.Cocktail) []ct.TaxonomyValue {
+	counter := newFacetCounter()
+	if taxonomy == tagTaxonomy {
+		counter = newTagCounter()
+	}
+	for _, rec := range recs {
+		switch taxonomy {
+		case categoryTaxonomy:
+			counter.add(rec.Category)
+		case glassTaxonomy:
+			counter.add(rec.Glass)
+		case alcoholicTaxonomy:
+			counter.add(rec.Alcoholic)
+		case ingredientTaxonomy:
+			addDistinct(counter, ingredientNames(rec))
+		case tagTaxonomy:
+			addDistinct(counter, rec.Tags)
+		}
+	}
+
+	values := make([]ct.TaxonomyValue, 0, len(counter.counts))
+	for key, count := range counter.counts {
+		canonical := counter.canonical(key)
+		value := ct.TaxonomyValue{Value: canonical, Normalized: key, Count: count}
+		for spelling := range counter.spellings[key] {
+			if spelling != canonical {
+				value.Variants = append(value.Variants, spelling)
+			}
+		}
+		sort.Strings(value.Variants)
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Normalized < values[j].Normalized
+	})
+	return values
+}
+
+// addDistinct counts each of the given values once, regardless of how they are spelled.
+func addDistinct(counter *facetCounter, values []string) {
+	seen := make(map[string]bool)
+	for _, v := range values {
+		if key := counter.normalize(v); !seen[key] {
+			seen[key] = true
+			counter.add(v)
+		}
+	}
+}
+
+// ingredientNames returns the names of the record's ingredients.
+func ingredientNames(rec entity.Cocktail) []string {
+	names := make([]string, 0, len(rec.Ingredients))
+	for _, ingr := range rec.Ingredients {
+		names = append(names, ingr.Name)
+	}
+	return names
+}
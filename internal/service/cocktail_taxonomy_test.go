@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCocktailTaxonomy(t *testing.T) {
+	tests := []struct {
+		name     string
+		taxonomy string
+		exp      cocktailTaxonomy
+	}{
+		{name: "Empty", taxonomy: "", exp: invalidTaxonomy},
+		{name: "Arbitrary", taxonomy: "colors", exp: invalidTaxonomy},
+		{name: "Categories", taxonomy: "Categories", exp: categoryTaxonomy},
+		{name: "Glasses", taxonomy: "glasses", exp: glassTaxonomy},
+		{name: "Ingredients", taxonomy: "ingredients", exp: ingredientTaxonomy},
+		{name: "Tags", taxonomy: "TAGS", exp: tagTaxonomy},
+		{name: "Alcoholic types", taxonomy: "alcoholic-types", exp: alcoholicTaxonomy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.exp, newCocktailTaxonomy(tt.taxonomy))
+		})
+	}
+}
+
+func TestTaxonomyValues(t *testing.T) {
+	recs := []entity.Cocktail{
+		{ID: 1, Glass: "Martini glass", Tags: []string{"IBA", "Classic"}, Ingredients: []entity.Ingredient{{Name: "Gin"}, {Name: "Dry Vermouth"}}},
+		{ID: 2, Glass: "Martini  Glass", Tags: []string{"classic"}, Ingredients: []entity.Ingredient{{Name: "Vodka"}, {Name: "dry vermouth"}}},
+		{ID: 3, Glass: "martini glass", Tags: []string{"Contemporary Classic"}, Ingredients: []entity.Ingredient{{Name: "Vodka"}, {Name: "vodka"}}},
+		{ID: 4, Glass: "Shot glass", Tags: []string{"ContemporaryClassic"}},
+	}
+	tests := []struct {
+		name     string
+		taxonomy cocktailTaxonomy
+		exp      []ct.TaxonomyValue
+	}{
+		{
+			name:     "Glasses",
+			taxonomy: glassTaxonomy,
+			exp: []ct.TaxonomyValue{
+				{Value: "Martini Glass", Normalized: "martini glass", Count: 3, Variants: []string{"Martini glass", "martini glass"}},
+				{Value: "Shot glass", Normalized: "shot glass", Count: 1},
+			},
+		},
+		{
+			name:     "Ingredients",
+			taxonomy: ingredientTaxonomy,
+			exp: []ct.TaxonomyValue{
+				{Value: "Dry Vermouth", Normalized: "dry vermouth", Count: 2, Variants: []string{"dry vermouth"}},
+				{Value: "Gin", Normalized: "gin", Count: 1},
+				{Value: "Vodka", Normalized: "vodka", Count: 2},
+			},
+		},
+		{
+			name:     "Tags",
+			taxonomy: tagTaxonomy,
+			exp: []ct.TaxonomyValue{
+				{Value: "Classic", Normalized: "classic", Count: 2, Variants: []string{"classic"}},
+				{Value: "Contemporary Classic", Normalized: "contemporaryclassic", Count: 2, Variants: []string{"ContemporaryClassic"}},
+				{Value: "IBA", Normalized: "iba", Count: 1},
+			},
+		},
+		{
+			name:     "Categories empty",
+			taxonomy: categoryTaxonomy,
+			exp:      []ct.TaxonomyValue{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.exp, taxonomyValues(tt.taxonomy, recs))
+		})
+	}
+}
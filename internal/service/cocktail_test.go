@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"strconv"
 	"testing"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/service/mocks"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -18,7 +20,7 @@ var _ CocktailRepo = &mocks.CocktailRepo{}
 func TestNewCocktail(t *testing.T) {
 	repo := mocks.NewCocktailRepo()
 	require.NotNil(t, repo)
-	out := NewCocktail(repo)
+	out := NewCocktail(repo, nil)
 	assert.IsType(t, Cocktail{}, out)
 }
 
@@ -169,7 +171,7 @@ func TestCocktail_GetFiltered(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mRepo := mocks.NewCocktailRepo()
 			mRepo.On("ReadAll").Return(tt.repo.resp, tt.repo.err)
-			svc := NewCocktail(mRepo)
+			svc := NewCocktail(mRepo, nil)
 			require.NotEqual(t, Cocktail{}, svc)
 
 			out, err := svc.GetFiltered(tt.args.filter, tt.args.value)
@@ -187,6 +189,337 @@ func TestCocktail_GetFiltered(t *testing.T) {
 	}
 }
 
+func TestCocktail_Query(t *testing.T) {
+	type repo struct {
+		resp []entity.Cocktail
+		err  error
+	}
+	tests := []struct {
+		name  string
+		query string
+		exp   []entity.Cocktail
+		err   error
+		repo  repo
+	}{
+		{
+			name:  "Repository error",
+			query: "name:foo",
+			exp:   nil,
+			err:   testRepoErr,
+			repo:  repo{resp: nil, err: testRepoErr},
+		},
+		{
+			name:  "Syntax error",
+			query: "name:foo AND",
+			exp:   nil,
+			err:   ErrQueryUnexpectedEnd,
+			repo:  repo{},
+		},
+		{
+			name:  "Valid",
+			query: "ingredient:soda AND NOT category:foo",
+			exp: []entity.Cocktail{
+				{ID: 3, Name: "Baz", Alcoholic: "Alcoholic", Category: "Some Category", Glass: "Cocktail glass", Ingredients: []entity.Ingredient{{Name: "soda", Measure: "100ml"}}},
+			},
+			err:  nil,
+			repo: repo{resp: testCocktailsAll, err: nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadAll").Return(tt.repo.resp, tt.repo.err)
+			svc := NewCocktail(mRepo, nil)
+
+			out, err := svc.Query(tt.query)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.Nil(t, out)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp, out)
+		})
+	}
+}
+
+func TestCocktail_GetStats(t *testing.T) {
+	type repo struct {
+		resp []entity.Cocktail
+		err  error
+	}
+	tests := []struct {
+		name  string
+		query string
+		exp   ct.CocktailStats
+		err   error
+		repo  repo
+	}{
+		{
+			name:  "Repository error",
+			query: "",
+			err:   testRepoErr,
+			repo:  repo{resp: nil, err: testRepoErr},
+		},
+		{
+			name:  "Syntax error",
+			query: "glass:",
+			err:   ErrQueryUnexpectedEnd,
+			repo:  repo{},
+		},
+		{
+			name:  "Faceted",
+			query: "glass:shot",
+			exp: ct.CocktailStats{
+				TotalRecs:               2,
+				Categories:              []ct.FacetCount{{Value: "Foo Category", Count: 1}, {Value: "Some Category", Count: 1}},
+				Glasses:                 []ct.FacetCount{{Value: "Shot glass", Count: 2}},
+				Alcoholic:               []ct.FacetCount{{Value: "Alcoholic", Count: 1}, {Value: "Non alcoholic", Count: 1}},
+				IBA:                     []ct.FacetCount{},
+				Tags:                    []ct.FacetCount{},
+				TopIngredients:          []ct.FacetCount{{Value: "soda", Count: 1}, {Value: "water", Count: 1}},
+				IngredientsDistribution: []ct.IngredientsCount{{Ingredients: 1, Count: 2}},
+				IngredientsAvg:          1,
+			},
+			repo: repo{resp: testCocktailsAll, err: nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadAll").Return(tt.repo.resp, tt.repo.err)
+			svc := NewCocktail(mRepo, nil)
+
+			out, err := svc.GetStats(tt.query, 0)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.Equal(t, ct.CocktailStats{}, out)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp, out)
+		})
+	}
+}
+
+func TestCocktail_GetTaxonomy(t *testing.T) {
+	type repo struct {
+		resp []entity.Cocktail
+		err  error
+	}
+	tests := []struct {
+		name     string
+		taxonomy string
+		exp      []ct.TaxonomyValue
+		err      error
+		repo     repo
+	}{
+		{
+			name:     "Invalid taxonomy",
+			taxonomy: "colors",
+			err:      ErrInvalidTaxonomy,
+			repo:     repo{},
+		},
+		{
+			name:     "Repository error",
+			taxonomy: "glasses",
+			err:      testRepoErr,
+			repo:     repo{resp: nil, err: testRepoErr},
+		},
+		{
+			name:     "Categories",
+			taxonomy: "categories",
+			exp: []ct.TaxonomyValue{
+				{Value: "Foo Category", Normalized: "foo category", Count: 1},
+				{Value: "Some Category", Normalized: "some category", Count: 2},
+			},
+			repo: repo{resp: testCocktailsAll, err: nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadAll").Return(tt.repo.resp, tt.repo.err)
+			svc := NewCocktail(mRepo, nil)
+
+			out, err := svc.GetTaxonomy(tt.taxonomy)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.Nil(t, out)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp, out)
+		})
+	}
+}
+
+func TestCocktail_GetChanges(t *testing.T) {
+	type args struct {
+		cursor string
+		limit  int
+	}
+	type repo struct {
+		since int64
+		limit int
+		resp  []entity.Change
+		err   error
+	}
+	changes := []entity.Change{
+		{Seq: 4, Type: entity.ChangeCreated, ID: 1},
+		{Seq: 5, Type: entity.ChangeUpdated, ID: 1},
+		{Seq: 6, Type: entity.ChangeDeleted, ID: 2},
+	}
+	tests := []struct {
+		name string
+		args args
+		exp  ct.ChangeFeed
+		err  error
+		repo repo
+	}{
+		{
+			name: "Invalid cursor",
+			args: args{cursor: "foo"},
+			err:  ErrInvalidChangeCursor,
+		},
+		{
+			name: "Negative cursor",
+			args: args{cursor: "-1"},
+			err:  ErrInvalidChangeCursor,
+		},
+		{
+			name: "Repository error",
+			args: args{cursor: ""},
+			err:  testRepoErr,
+			repo: repo{since: 0, limit: defaultChangesLimit + 1, resp: nil, err: testRepoErr},
+		},
+		{
+			name: "No changes",
+			args: args{cursor: "6", limit: 5000},
+			exp:  ct.ChangeFeed{Changes: []entity.Change{}, NextCursor: "6"},
+			repo: repo{since: 6, limit: maxChangesLimit + 1, resp: []entity.Change{}},
+		},
+		{
+			name: "Last page",
+			args: args{cursor: "3", limit: 3},
+			exp:  ct.ChangeFeed{Changes: changes, NextCursor: "6"},
+			repo: repo{since: 3, limit: 4, resp: changes},
+		},
+		{
+			name: "More changes",
+			args: args{cursor: "3", limit: 2},
+			exp:  ct.ChangeFeed{Changes: changes[:2], NextCursor: "5", HasMore: true},
+			repo: repo{since: 3, limit: 3, resp: changes},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadChanges", tt.repo.since, tt.repo.limit).Return(tt.repo.resp, tt.repo.err)
+			svc := NewCocktail(mRepo, nil)
+
+			out, err := svc.GetChanges(tt.args.cursor, tt.args.limit)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp, out)
+		})
+	}
+}
+
+func TestCocktail_GetFilteredWithSuggestions(t *testing.T) {
+	tests := []struct {
+		name        string
+		filter      string
+		value       string
+		repoErr     error
+		exp         []entity.Cocktail
+		suggestions []ct.Suggestion
+		err         error
+	}{
+		{
+			name:        "Matches",
+			filter:      nameFltr.String(),
+			value:       "foo",
+			exp:         []entity.Cocktail{testCocktailsAll[0]},
+			suggestions: []ct.Suggestion{},
+		},
+		{
+			name:        "Suggestions",
+			filter:      ingredientFltr.String(),
+			value:       "sdoa",
+			exp:         []entity.Cocktail{},
+			suggestions: []ct.Suggestion{{Value: "soda", Score: 0.75, Matches: 2}},
+		},
+		{
+			name:        "Glass suggestions",
+			filter:      glassFltr.String(),
+			value:       "shoot",
+			exp:         []entity.Cocktail{},
+			suggestions: []ct.Suggestion{{Value: "Shot glass", Score: 0.8, Matches: 2}},
+		},
+		{
+			name:        "No similar values",
+			filter:      nameFltr.String(),
+			value:       "margarita",
+			exp:         []entity.Cocktail{},
+			suggestions: []ct.Suggestion{},
+		},
+		{
+			name:        "Not supported filter",
+			filter:      alcoholicFltr.String(),
+			value:       "non",
+			exp:         []entity.Cocktail{},
+			suggestions: []ct.Suggestion{},
+		},
+		{
+			name:   "Invalid filter",
+			filter: "foo",
+			value:  "fooo",
+			err:    ErrFltrInvalid,
+		},
+		{
+			name:    "Repository error",
+			filter:  nameFltr.String(),
+			value:   "fooo",
+			repoErr: testRepoErr,
+			err:     testRepoErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadAll").Return(testCocktailsAll, tt.repoErr)
+			svc := NewCocktail(mRepo, nil)
+
+			out, suggestions, err := svc.GetFilteredWithSuggestions(tt.filter, tt.value)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.Nil(t, out)
+				assert.Nil(t, suggestions)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp, out)
+			assert.Equal(t, tt.suggestions, suggestions)
+			// The suggestions are drawn from the records read by the filter
+			mRepo.AssertNumberOfCalls(t, "ReadAll", 1)
+		})
+	}
+}
+
 func TestCocktail_GetAll(t *testing.T) {
 	type repo struct {
 		resp []entity.Cocktail
@@ -231,7 +564,7 @@ func TestCocktail_GetAll(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mRepo := mocks.NewCocktailRepo()
 			mRepo.On("ReadAll").Return(tt.repo.resp, tt.repo.err)
-			svc := NewCocktail(mRepo)
+			svc := NewCocktail(mRepo, nil)
 			require.NotNil(t, svc)
 
 			out, err := svc.GetAll()
@@ -249,6 +582,20 @@ func TestCocktail_GetAll(t *testing.T) {
 	}
 }
 
+func TestCocktail_StreamAll(t *testing.T) {
+	mRepo := mocks.NewCocktailRepo()
+	mRepo.On("Stream", mock.Anything).Return(testCocktailsAll, nil)
+	svc := NewCocktail(mRepo, nil)
+
+	out := make([]entity.Cocktail, 0)
+	err := svc.StreamAll(context.Background(), func(cocktail entity.Cocktail) error {
+		out = append(out, cocktail)
+		return nil
+	})
+	require.Nil(t, err)
+	assert.Equal(t, testCocktailsAll, out)
+}
+
 func TestCocktail_GetCC(t *testing.T) {
 	type repoArgs struct {
 		nType   ct.NumberType
@@ -329,17 +676,17 @@ func TestCocktail_GetCC(t *testing.T) {
 			name: "Valid",
 			args: args{nType: "even", jobs: "8", jWorker: "2"},
 			exp: []entity.Cocktail{
-				{ID: 17222, Name: "A1", Alcoholic: "Alcoholic", Category: "Cocktail", Ingredients: []entity.Ingredient{{Name: "Gin", Measure: "1 3/4 shot "}, {Name: "Grand Marnier", Measure: "1 Shot "}, {Name: "Lemon Juice", Measure: "1/4 Shot"}, {Name: "Grenadine", Measure: "1/8 Shot"}}, Instructions: "Pour all ingredients into a cocktail shaker, mix and serve over ice into a chilled glass.", Glass: "Cocktail glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/2x8thr1504816928.jpg", Video: "", SrcDate: time.Date(2017, time.September, 7, 21, 42, 9, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
-				{ID: 14610, Name: "ACID", Alcoholic: "Alcoholic", Category: "Shot", Ingredients: []entity.Ingredient{{Name: "151 proof rum", Measure: "1 oz Bacardi "}, {Name: "Wild Turkey", Measure: "1 oz "}}, Instructions: "Poor in the 151 first followed by the 101 served with a Coke or Dr Pepper chaser.", Glass: "Shot glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/xuxpxt1479209317.jpg", Video: "", SrcDate: time.Date(2016, time.November, 15, 11, 28, 37, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
-				{ID: 13938, Name: "AT&T", Alcoholic: "Alcoholic", Category: "Ordinary Drink", Ingredients: []entity.Ingredient{{Name: "Absolut Vodka", Measure: "1 oz "}, {Name: "Gin", Measure: "1 oz "}, {Name: "Tonic water", Measure: "4 oz "}}, Instructions: "Pour Vodka and Gin over ice, add Tonic and Stir", Glass: "Highball Glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/rhhwmp1493067619.jpg", Video: "", SrcDate: time.Date(2017, time.April, 24, 22, 0, 19, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
+				{ID: 17222, Name: "A1", Alcoholic: "Alcoholic", Category: "Cocktail", Ingredients: []entity.Ingredient{{Name: "Gin", Measure: "1 3/4 shot "}, {Name: "Grand Marnier", Measure: "1 Shot "}, {Name: "Lemon Juice", Measure: "1/4 Shot"}, {Name: "Grenadine", Measure: "1/8 Shot"}}, Instructions: "Pour all ingredients into a cocktail shaker, mix and serve over ice into a chilled glass.", Glass: "Cocktail glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: nil, Thumb: "https://www.thecocktaildb.com/images/media/drink/2x8thr1504816928.jpg", Video: "", SrcDate: time.Date(2017, time.September, 7, 21, 42, 9, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
+				{ID: 14610, Name: "ACID", Alcoholic: "Alcoholic", Category: "Shot", Ingredients: []entity.Ingredient{{Name: "151 proof rum", Measure: "1 oz Bacardi "}, {Name: "Wild Turkey", Measure: "1 oz "}}, Instructions: "Poor in the 151 first followed by the 101 served with a Coke or Dr Pepper chaser.", Glass: "Shot glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: nil, Thumb: "https://www.thecocktaildb.com/images/media/drink/xuxpxt1479209317.jpg", Video: "", SrcDate: time.Date(2016, time.November, 15, 11, 28, 37, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
+				{ID: 13938, Name: "AT&T", Alcoholic: "Alcoholic", Category: "Ordinary Drink", Ingredients: []entity.Ingredient{{Name: "Absolut Vodka", Measure: "1 oz "}, {Name: "Gin", Measure: "1 oz "}, {Name: "Tonic water", Measure: "4 oz "}}, Instructions: "Pour Vodka and Gin over ice, add Tonic and Stir", Glass: "Highball Glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: nil, Thumb: "https://www.thecocktaildb.com/images/media/drink/rhhwmp1493067619.jpg", Video: "", SrcDate: time.Date(2017, time.April, 24, 22, 0, 19, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
 			},
 			err: nil,
 			repo: repo{
 				args: repoArgs{nType: ct.EvenNum, jobs: 8, jWorker: 2},
 				resp: []entity.Cocktail{
-					{ID: 17222, Name: "A1", Alcoholic: "Alcoholic", Category: "Cocktail", Ingredients: []entity.Ingredient{{Name: "Gin", Measure: "1 3/4 shot "}, {Name: "Grand Marnier", Measure: "1 Shot "}, {Name: "Lemon Juice", Measure: "1/4 Shot"}, {Name: "Grenadine", Measure: "1/8 Shot"}}, Instructions: "Pour all ingredients into a cocktail shaker, mix and serve over ice into a chilled glass.", Glass: "Cocktail glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/2x8thr1504816928.jpg", Video: "", SrcDate: time.Date(2017, time.September, 7, 21, 42, 9, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
-					{ID: 14610, Name: "ACID", Alcoholic: "Alcoholic", Category: "Shot", Ingredients: []entity.Ingredient{{Name: "151 proof rum", Measure: "1 oz Bacardi "}, {Name: "Wild Turkey", Measure: "1 oz "}}, Instructions: "Poor in the 151 first followed by the 101 served with a Coke or Dr Pepper chaser.", Glass: "Shot glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/xuxpxt1479209317.jpg", Video: "", SrcDate: time.Date(2016, time.November, 15, 11, 28, 37, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
-					{ID: 13938, Name: "AT&T", Alcoholic: "Alcoholic", Category: "Ordinary Drink", Ingredients: []entity.Ingredient{{Name: "Absolut Vodka", Measure: "1 oz "}, {Name: "Gin", Measure: "1 oz "}, {Name: "Tonic water", Measure: "4 oz "}}, Instructions: "Pour Vodka and Gin over ice, add Tonic and Stir", Glass: "Highball Glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: "", Thumb: "https://www.thecocktaildb.com/images/media/drink/rhhwmp1493067619.jpg", Video: "", SrcDate: time.Date(2017, time.April, 24, 22, 0, 19, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
+					{ID: 17222, Name: "A1", Alcoholic: "Alcoholic", Category: "Cocktail", Ingredients: []entity.Ingredient{{Name: "Gin", Measure: "1 3/4 shot "}, {Name: "Grand Marnier", Measure: "1 Shot "}, {Name: "Lemon Juice", Measure: "1/4 Shot"}, {Name: "Grenadine", Measure: "1/8 Shot"}}, Instructions: "Pour all ingredients into a cocktail shaker, mix and serve over ice into a chilled glass.", Glass: "Cocktail glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: nil, Thumb: "https://www.thecocktaildb.com/images/media/drink/2x8thr1504816928.jpg", Video: "", SrcDate: time.Date(2017, time.September, 7, 21, 42, 9, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
+					{ID: 14610, Name: "ACID", Alcoholic: "Alcoholic", Category: "Shot", Ingredients: []entity.Ingredient{{Name: "151 proof rum", Measure: "1 oz Bacardi "}, {Name: "Wild Turkey", Measure: "1 oz "}}, Instructions: "Poor in the 151 first followed by the 101 served with a Coke or Dr Pepper chaser.", Glass: "Shot glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: nil, Thumb: "https://www.thecocktaildb.com/images/media/drink/xuxpxt1479209317.jpg", Video: "", SrcDate: time.Date(2016, time.November, 15, 11, 28, 37, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
+					{ID: 13938, Name: "AT&T", Alcoholic: "Alcoholic", Category: "Ordinary Drink", Ingredients: []entity.Ingredient{{Name: "Absolut Vodka", Measure: "1 oz "}, {Name: "Gin", Measure: "1 oz "}, {Name: "Tonic water", Measure: "4 oz "}}, Instructions: "Pour Vodka and Gin over ice, add Tonic and Stir", Glass: "Highball Glass", IBA: "", ImgAttribution: "", ImgSrc: "", Tags: nil, Thumb: "https://www.thecocktaildb.com/images/media/drink/rhhwmp1493067619.jpg", Video: "", SrcDate: time.Date(2017, time.April, 24, 22, 0, 19, 0, time.UTC), CreatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC), UpdatedAt: time.Date(2023, time.October, 1, 0, 33, 47, 0, time.UTC)},
 				},
 				err: nil,
 			},
@@ -351,7 +698,7 @@ func TestCocktail_GetCC(t *testing.T) {
 			mRepo := mocks.NewCocktailRepo()
 			mRepo.On("ReadCC", tt.repo.args.nType, tt.repo.args.jobs, tt.repo.args.jWorker).
 				Return(tt.repo.resp, tt.repo.err)
-			svc := NewCocktail(mRepo)
+			svc := NewCocktail(mRepo, nil)
 			require.NotNil(t, svc)
 
 			out, err := svc.GetCC(tt.args.nType, tt.args.jobs, tt.args.jWorker)
@@ -378,11 +725,13 @@ func TestCocktail_UpdateDB(t *testing.T) {
 		readResp  []entity.Cocktail
 		readErr   error
 	}
+	deletedAt := dateTimeNow().Add(-1 * time.Hour)
 	tests := []struct {
-		name string
-		repo repo
-		exp  ct.DBOpsSummary
-		err  error
+		name           string
+		repo           repo
+		restoreDeleted bool
+		exp            ct.DBOpsSummary
+		err            error
 	}{
 		{
 			name: "Read All error",
@@ -413,9 +762,9 @@ func TestCocktail_UpdateDB(t *testing.T) {
 				fetchResp: testCocktailsAll,
 				fetchErr:  nil,
 				createArg: []entity.Cocktail{
-					{ID: 1, Name: "Foo", Alcoholic: "Alcoholic", Category: "Foo Category", Glass: "Shot glass", Ingredients: []entity.Ingredient{{Name: "soda", Measure: "80ml"}}, CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow()},
-					{ID: 2, Name: "Bar", Alcoholic: "Non alcoholic", Category: "Some Category", Glass: "Shot glass", Ingredients: []entity.Ingredient{{Name: "water", Measure: "50ml"}}, CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow()},
-					{ID: 3, Name: "Baz", Alcoholic: "Alcoholic", Category: "Some Category", Glass: "Cocktail glass", Ingredients: []entity.Ingredient{{Name: "soda", Measure: "100ml"}}, CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow()},
+					{ID: 1, Name: "Foo", Alcoholic: "Alcoholic", Category: "Foo Category", Glass: "Shot glass", Ingredients: []entity.Ingredient{{Name: "soda", Measure: "80ml"}}, CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow(), Version: 1},
+					{ID: 2, Name: "Bar", Alcoholic: "Non alcoholic", Category: "Some Category", Glass: "Shot glass", Ingredients: []entity.Ingredient{{Name: "water", Measure: "50ml"}}, CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow(), Version: 1},
+					{ID: 3, Name: "Baz", Alcoholic: "Alcoholic", Category: "Some Category", Glass: "Cocktail glass", Ingredients: []entity.Ingredient{{Name: "soda", Measure: "100ml"}}, CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow(), Version: 1},
 				},
 				createErr: testRepoErr,
 			},
@@ -458,7 +807,7 @@ func TestCocktail_UpdateDB(t *testing.T) {
 				fetchErr: nil,
 				createArg: []entity.Cocktail{
 					{ID: 1, Name: "foo"},
-					{ID: 2, Name: "bar", Category: "other-category", UpdatedAt: dateTimeNow()},
+					{ID: 2, Name: "bar", Category: "other-category", UpdatedAt: dateTimeNow(), Version: 1},
 					{ID: 3, Name: "baz"},
 				},
 				createErr: nil,
@@ -476,7 +825,7 @@ func TestCocktail_UpdateDB(t *testing.T) {
 			name: "One updated",
 			repo: repo{
 				readResp: []entity.Cocktail{
-					{ID: 1, Name: "foo", SrcDate: dateTimeNow()},
+					{ID: 1, Name: "foo", SrcDate: dateTimeNow(), Version: 3},
 					{ID: 2, Name: "bar"},
 					{ID: 3, Name: "baz"},
 				},
@@ -488,7 +837,7 @@ func TestCocktail_UpdateDB(t *testing.T) {
 				},
 				fetchErr: nil,
 				createArg: []entity.Cocktail{
-					{ID: 1, Name: "foo", Category: "fooCategory", SrcDate: dateTimeNow().Add(1 * time.Hour), UpdatedAt: dateTimeNow()},
+					{ID: 1, Name: "foo", Category: "fooCategory", SrcDate: dateTimeNow().Add(1 * time.Hour), UpdatedAt: dateTimeNow(), Version: 4},
 					{ID: 2, Name: "bar"},
 					{ID: 3, Name: "baz"},
 				},
@@ -518,8 +867,8 @@ func TestCocktail_UpdateDB(t *testing.T) {
 				fetchErr: nil,
 				createArg: []entity.Cocktail{
 					{ID: 1, Name: "foo"},
-					{ID: 2, Name: "bar", CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow()},
-					{ID: 3, Name: "baz", CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow()},
+					{ID: 2, Name: "bar", CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow(), Version: 1},
+					{ID: 3, Name: "baz", CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow(), Version: 1},
 				},
 				createErr: nil,
 			},
@@ -532,6 +881,43 @@ func TestCocktail_UpdateDB(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			name: "Deleted left deleted",
+			repo: repo{
+				readResp: []entity.Cocktail{
+					{ID: 1, Name: "foo", Version: 2, DeletedAt: &deletedAt},
+				},
+				fetchResp: []entity.Cocktail{
+					{ID: 1, Name: "foo", Category: "fooCategory"},
+				},
+				createArg: []entity.Cocktail{},
+			},
+			exp: ct.DBOpsSummary{
+				Status:    noChangesDBStatus,
+				TotalRecs: 1,
+			},
+		},
+		{
+			name: "Deleted restored",
+			repo: repo{
+				readResp: []entity.Cocktail{
+					{ID: 1, Name: "foo", CreatedAt: deletedAt, Version: 2, DeletedAt: &deletedAt},
+				},
+				fetchResp: []entity.Cocktail{
+					{ID: 1, Name: "foo", Category: "fooCategory"},
+				},
+				createArg: []entity.Cocktail{
+					{ID: 1, Name: "foo", Category: "fooCategory", CreatedAt: deletedAt, UpdatedAt: dateTimeNow(), Version: 3},
+				},
+			},
+			restoreDeleted: true,
+			exp: ct.DBOpsSummary{
+				Status:       successfulUpdateDBStatus,
+				ModifiedRecs: 1,
+				TotalOps:     1,
+				TotalRecs:    1,
+			},
+		},
 		{
 			name: "All new",
 			repo: repo{
@@ -544,9 +930,9 @@ func TestCocktail_UpdateDB(t *testing.T) {
 				},
 				fetchErr: nil,
 				createArg: []entity.Cocktail{
-					{ID: 1, Name: "foo", CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow()},
-					{ID: 2, Name: "bar", CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow()},
-					{ID: 3, Name: "baz", CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow()},
+					{ID: 1, Name: "foo", CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow(), Version: 1},
+					{ID: 2, Name: "bar", CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow(), Version: 1},
+					{ID: 3, Name: "baz", CreatedAt: dateTimeNow(), UpdatedAt: dateTimeNow(), Version: 1},
 				},
 				createErr: nil,
 			},
@@ -564,25 +950,38 @@ func TestCocktail_UpdateDB(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mRepo := mocks.NewCocktailRepo()
-			mRepo.On("ReadAll").Return(tt.repo.readResp, tt.repo.readErr)
-			mRepo.On("ReplaceDB", tt.repo.createArg).Return(tt.repo.createErr)
-			mRepo.On("Fetch").Return(tt.repo.fetchResp, tt.repo.fetchErr)
-			svc := NewCocktail(mRepo)
+			mRepo.On("ReadAllWithDeleted").Return(tt.repo.readResp, tt.repo.readErr)
+			mRepo.On("ReplaceDB", tt.repo.createArg, entity.Author{Source: entity.SourceSync}).Return(tt.repo.createErr)
+			mRepo.On("Fetch").Return(tt.repo.fetchResp, nil, tt.repo.fetchErr)
+			mRepo.On("Snapshot", mock.Anything).Return(nil)
+			mEvents := mocks.NewEventPublisher()
+			mEvents.On("Publish", mock.Anything, mock.Anything).Return()
+			svc := NewCocktail(mRepo, mEvents)
 			require.NotNil(t, svc)
 
-			out, err := svc.UpdateDB()
+			out, err := svc.UpdateDB(context.Background(), tt.restoreDeleted)
 			if tt.err != nil {
 				require.NotNil(t, err)
 				assert.Equal(t, ct.DBOpsSummary{}, out)
 				assert.ErrorIs(t, err, tt.err)
+				mEvents.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
 				return
 			}
 			require.Nil(t, err)
+			mEvents.AssertNumberOfCalls(t, "Publish", tt.exp.TotalOps+1)
+			mEvents.AssertCalled(t, "Publish", SyncCompletedEvent, out)
 			assert.Equal(t, tt.exp.Status, out.Status)
 			assert.Equal(t, tt.exp.NewRecs, out.NewRecs)
 			assert.Equal(t, tt.exp.ModifiedRecs, out.ModifiedRecs)
 			assert.Equal(t, tt.exp.TotalOps, out.TotalOps)
 			assert.Equal(t, tt.exp.TotalRecs, out.TotalRecs)
+			if tt.exp.TotalOps > 0 {
+				assert.Regexp(t, `^\d{8}T\d{6}Z-[0-9a-f]{8}$`, out.TxID)
+				mRepo.AssertCalled(t, "Snapshot", out.TxID)
+			} else {
+				assert.Empty(t, out.TxID)
+				mRepo.AssertNotCalled(t, "Snapshot", mock.Anything)
+			}
 		})
 	}
 }
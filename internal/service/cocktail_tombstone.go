@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+)
+
+// GetAllWithDeleted returns all the entity.Cocktail records of the database, including the deleted ones.
+func (s Cocktail) GetAllWithDeleted() ([]entity.Cocktail, error) {
+	return s.repo.ReadAllWithDeleted()
+}
+
+// GetWithDeleted returns the entity.Cocktail record of the given ID, even if it is deleted.
+// Returns a NotFoundErr if the record does not exist, or was purged.
+func (s Cocktail) GetWithDeleted(id int) (entity.Cocktail, error) {
+	recs, err := s.repo.ReadAllWithDeleted()
+	if err != nil {
+		return entity.Cocktail{}, err
+	}
+	index, found := findCocktail(id, recs)
+	if !found {
+		return entity.Cocktail{}, &NotFoundErr{fmt.Errorf("%w: %d", ErrCocktailNotFound, id)}
+	}
+	return recs[index], nil
+}
+
+// Restore restores the deleted record of the given ID, and returns it.
+// The record gets its values back, its update date set to now and its version incremented.
+// Once written, the restored record is published. The revision is authored by the principal of the context.
+// Returns a NotFoundErr if the record does not exist, a StateErr if it is not deleted, and a VersionErr if it is
+// written by another process while being restored.
+func (s Cocktail) Restore(ctx context.Context, id int) (entity.Cocktail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	recs, index, err := s.readTombstone(id)
+	if err != nil {
+		return entity.Cocktail{}, err
+	}
+
+	restored := recs[index]
+	restored.UpdatedAt = dateTimeNow()
+	restored.DeletedAt = nil
+	restored.Version++
+	if err := s.putRecord(restored, recs[index].Version, newAuthor(ctx, entity.SourceEdit)); err != nil {
+		return entity.Cocktail{}, err
+	}
+
+	if s.events != nil {
+		s.events.Publish(CocktailRestoredEvent, restored)
+	}
+	return restored, nil
+}
+
+// Purge removes the deleted record of the given ID from the database for good, so it can no longer be restored.
+// The revision is authored by the principal of the context; no event is published, as the record was deleted already.
+// Returns a NotFoundErr if the record does not exist, a StateErr if it is not deleted, and a VersionErr if it is
+// written by another process while being purged.
+func (s Cocktail) Purge(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	recs, index, err := s.readTombstone(id)
+	if err != nil {
+		return err
+	}
+
+	return s.purgeRecord(id, recs[index].Version, newAuthor(ctx, entity.SourceEdit))
+}
+
+// readTombstone returns all the database records, tombstones included, along with the index of the deleted record
+// of the given ID. Returns a NotFoundErr if the record does not exist, and a StateErr if it is not deleted.
+func (s Cocktail) readTombstone(id int) ([]entity.Cocktail, int, error) {
+	recs, err := s.repo.ReadAllWithDeleted()
+	if err != nil {
+		return nil, 0, err
+	}
+	index, found := findCocktail(id, recs)
+	if !found {
+		return nil, 0, &NotFoundErr{fmt.Errorf("%w: %d", ErrCocktailNotFound, id)}
+	}
+	if !recs[index].Deleted() {
+		return nil, 0, &StateErr{fmt.Errorf("%w: %d", ErrCocktailNotDeleted, id)}
+	}
+	return recs, index, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCocktail_GetWithDeleted(t *testing.T) {
+	tests := []struct {
+		name string
+		id   int
+		exp  entity.Cocktail
+		err  error
+	}{
+		{name: "Not found", id: 9, err: ErrCocktailNotFound},
+		{name: "Live record", id: 2, exp: testVersionedCocktails()[1]},
+		{name: "Deleted record", id: 3, exp: testTombstone()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadAllWithDeleted").Return(append(testVersionedCocktails(), testTombstone()), nil)
+			svc := NewCocktail(mRepo, nil)
+
+			out, err := svc.GetWithDeleted(tt.id)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp, out)
+		})
+	}
+}
+
+func TestCocktail_Restore(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         int
+		replaceErr error
+		err        error
+	}{
+		{name: "Not found", id: 9, err: ErrCocktailNotFound},
+		{name: "Not deleted", id: 1, err: ErrCocktailNotDeleted},
+		{name: "Replace error", id: 3, replaceErr: testRepoErr, err: testRepoErr},
+		{name: "Restored", id: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadAllWithDeleted").Return(append(testVersionedCocktails(), testTombstone()), nil)
+			mRepo.On("ReplaceDB", mock.Anything, mock.Anything).Return(tt.replaceErr)
+			mEvents := mocks.NewEventPublisher()
+			mEvents.On("Publish", mock.Anything, mock.Anything).Return()
+			svc := NewCocktail(mRepo, mEvents)
+
+			out, err := svc.Restore(testCtx, tt.id)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				mEvents.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+				return
+			}
+			require.Nil(t, err)
+			exp := testTombstone()
+			exp.UpdatedAt = dateTimeNow()
+			exp.DeletedAt = nil
+			exp.Version = 3
+			assert.Equal(t, exp, out)
+			mRepo.AssertCalled(t, "ReplaceDB", append(testVersionedCocktails(), exp), testEditor)
+			mEvents.AssertCalled(t, "Publish", CocktailRestoredEvent, exp)
+		})
+	}
+}
+
+func TestCocktail_Purge(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         int
+		replaceErr error
+		err        error
+	}{
+		{name: "Not found", id: 9, err: ErrCocktailNotFound},
+		{name: "Not deleted", id: 2, err: ErrCocktailNotDeleted},
+		{name: "Replace error", id: 3, replaceErr: testRepoErr, err: testRepoErr},
+		{name: "Purged", id: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewCocktailRepo()
+			mRepo.On("ReadAllWithDeleted").Return(append(testVersionedCocktails(), testTombstone()), nil)
+			mRepo.On("ReplaceDB", mock.Anything, mock.Anything).Return(tt.replaceErr)
+			svc := NewCocktail(mRepo, nil)
+
+			err := svc.Purge(testCtx, tt.id)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			mRepo.AssertCalled(t, "ReplaceDB", testVersionedCocktails(), testEditor)
+		})
+	}
+}
@@ -2,8 +2,11 @@ package service
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
 )
 
@@ -16,9 +19,21 @@ const (
 	categoryFltr   cocktailFilter = "category"
 	ingredientFltr cocktailFilter = "ingredient"
 	glassFltr      cocktailFilter = "glass"
+	tagFltr        cocktailFilter = "tag"
 
 	noChangesDBStatus        = "no changes"
 	successfulUpdateDBStatus = "database updated successfully"
+	successfulRollbackStatus = "database rolled back successfully"
+
+	// minSuggestionScore is the lowest similarity score, from 0 to 1, a value needs to be suggested.
+	minSuggestionScore = 0.6
+	// maxSuggestions is the maximum number of "did you mean" suggestions returned.
+	maxSuggestions = 5
+
+	// defaultChangesLimit is the number of changes returned by default in a change feed page.
+	defaultChangesLimit = 100
+	// maxChangesLimit is the highest number of changes a change feed page can hold.
+	maxChangesLimit = 1000
 )
 
 var _ fmt.Stringer = cocktailFilter("")
@@ -45,6 +60,8 @@ func newCocktailFltr(filter string) cocktailFilter {
 		return ingredientFltr
 	case glassFltr.String():
 		return glassFltr
+	case tagFltr.String():
+		return tagFltr
 	default:
 		return invalidFltr
 	}
@@ -54,7 +71,7 @@ func newCocktailFltr(filter string) cocktailFilter {
 func cocktailsById(id int, recs []entity.Cocktail) []entity.Cocktail {
 	cocktails := make([]entity.Cocktail, 0)
 	for _, rec := range recs {
-		if id == rec.ID {
+		if matchID(rec, id) {
 			cocktails = append(cocktails, rec)
 		}
 	}
@@ -63,86 +80,147 @@ func cocktailsById(id int, recs []entity.Cocktail) []entity.Cocktail {
 
 // cocktailsByName returns the given entity.Cocktail records filtered by the Name property.
 func cocktailsByName(name string, recs []entity.Cocktail) []entity.Cocktail {
-	cocktails := make([]entity.Cocktail, 0)
-	if name == "" {
-		return cocktails
-	}
-	for _, rec := range recs {
-		if strings.Contains(
-			strings.ToLower(rec.Name),
-			strings.ToLower(name),
-		) {
-			cocktails = append(cocktails, rec)
-		}
-	}
-	return cocktails
+	return cocktailsBy(matchName, name, recs)
 }
 
 // cocktailsByAlcoholic returns the given entity.Cocktail records filtered by the Alcoholic property.
 func cocktailsByAlcoholic(name string, recs []entity.Cocktail) []entity.Cocktail {
-	cocktails := make([]entity.Cocktail, 0)
-	if name == "" {
-		return cocktails
-	}
-	for _, rec := range recs {
-		if strings.EqualFold(rec.Alcoholic, name) {
-			cocktails = append(cocktails, rec)
-		}
-	}
-	return cocktails
+	return cocktailsBy(matchAlcoholic, name, recs)
 }
 
 // cocktailsByCategory returns the given entity.Cocktail records filtered by the Category property.
 func cocktailsByCategory(name string, recs []entity.Cocktail) []entity.Cocktail {
+	return cocktailsBy(matchCategory, name, recs)
+}
+
+// cocktailsByIngredient returns the entity.Cocktail records filtered by any ingredient included in the Ingredients list.
+func cocktailsByIngredient(name string, recs []entity.Cocktail) []entity.Cocktail {
+	return cocktailsBy(matchIngredient, name, recs)
+}
+
+// cocktailsByGlass returns the given entity.Cocktail records filtered by the Glass property.
+func cocktailsByGlass(name string, recs []entity.Cocktail) []entity.Cocktail {
+	return cocktailsBy(matchGlass, name, recs)
+}
+
+// cocktailsByTag returns the given entity.Cocktail records filtered by the Tags list. See matchTag.
+func cocktailsByTag(tags string, recs []entity.Cocktail) []entity.Cocktail {
+	return cocktailsBy(matchTag, tags, recs)
+}
+
+// cocktailMatcher reports whether the entity.Cocktail record matches the given filter value.
+type cocktailMatcher func(rec entity.Cocktail, value string) bool
+
+// cocktailMatchers are the text matchers of the query filters, keyed by filter type. Unlike GetFiltered, the query
+// matches the Alcoholic property by prefix. See matchAlcoholicPrefix.
+var cocktailMatchers = map[cocktailFilter]cocktailMatcher{
+	nameFltr:       matchName,
+	alcoholicFltr:  matchAlcoholicPrefix,
+	categoryFltr:   matchCategory,
+	ingredientFltr: matchIngredient,
+	glassFltr:      matchGlass,
+	tagFltr:        matchTag,
+}
+
+// cocktailsBy returns the given entity.Cocktail records matching the value.
+// An empty value matches no record.
+func cocktailsBy(match cocktailMatcher, value string, recs []entity.Cocktail) []entity.Cocktail {
 	cocktails := make([]entity.Cocktail, 0)
-	if name == "" {
+	if value == "" {
 		return cocktails
 	}
 	for _, rec := range recs {
-		if strings.Contains(
-			strings.ToLower(rec.Category),
-			strings.ToLower(name),
-		) {
+		if match(rec, value) {
 			cocktails = append(cocktails, rec)
 		}
 	}
 	return cocktails
 }
 
-// cocktailsByIngredient returns the entity.Cocktail records filtered by any ingredient included in the Ingredients list.
-func cocktailsByIngredient(name string, recs []entity.Cocktail) []entity.Cocktail {
-	cocktails := make([]entity.Cocktail, 0)
-	if name == "" {
-		return cocktails
-	}
-	for _, rec := range recs {
-		for _, ingr := range rec.Ingredients {
-			if strings.Contains(
-				strings.ToLower(ingr.Name),
-				strings.ToLower(name),
-			) {
-				cocktails = append(cocktails, rec)
-			}
+// matchID reports whether the record has the given ID.
+func matchID(rec entity.Cocktail, id int) bool {
+	return rec.ID == id
+}
+
+// matchName reports whether the record's Name contains the given name, case-insensitively.
+func matchName(rec entity.Cocktail, name string) bool {
+	return containsFold(rec.Name, name)
+}
+
+// matchAlcoholic reports whether the record's Alcoholic property equals the given name, case-insensitively.
+func matchAlcoholic(rec entity.Cocktail, name string) bool {
+	return strings.EqualFold(rec.Alcoholic, name)
+}
+
+// matchAlcoholicPrefix reports whether the record's Alcoholic property starts with the given name,
+// case-insensitively. e.g. "non" matches "Non alcoholic", but "alcoholic" does not.
+func matchAlcoholicPrefix(rec entity.Cocktail, name string) bool {
+	return strings.HasPrefix(strings.ToLower(rec.Alcoholic), strings.ToLower(name))
+}
+
+// matchCategory reports whether the record's Category contains the given name, case-insensitively.
+func matchCategory(rec entity.Cocktail, name string) bool {
+	return containsFold(rec.Category, name)
+}
+
+// matchIngredient reports whether any of the record's Ingredients contains the given name, case-insensitively.
+func matchIngredient(rec entity.Cocktail, name string) bool {
+	for _, ingr := range rec.Ingredients {
+		if containsFold(ingr.Name, name) {
+			return true
 		}
 	}
-	return cocktails
+	return false
 }
 
-// cocktailsByGlass returns the given entity.Cocktail records filtered by the Glass property.
-func cocktailsByGlass(name string, recs []entity.Cocktail) []entity.Cocktail {
-	cocktails := make([]entity.Cocktail, 0)
-	if name == "" {
-		return cocktails
+// matchGlass reports whether the record's Glass contains the given name, case-insensitively.
+func matchGlass(rec entity.Cocktail, name string) bool {
+	return containsFold(rec.Glass, name)
+}
+
+// matchTag reports whether the record's Tags hold the given tags, compared by their normalized form.
+// A comma separated list matches any of the tags, e.g. "IBA,Classic"; a "+" separated list matches
+// all of them, e.g. "IBA+Classic".
+func matchTag(rec entity.Cocktail, tags string) bool {
+	all := strings.Contains(tags, "+")
+	sep := ","
+	if all {
+		sep = "+"
 	}
-	for _, rec := range recs {
-		if strings.Contains(
-			strings.ToLower(rec.Glass),
-			strings.ToLower(name),
-		) {
-			cocktails = append(cocktails, rec)
+
+	recTags := make(map[string]bool, len(rec.Tags))
+	for _, tag := range rec.Tags {
+		recTags[normalizeTag(tag)] = true
+	}
+	matches, total := 0, 0
+	for _, tag := range strings.Split(tags, sep) {
+		tag = normalizeTag(tag)
+		if tag == "" {
+			continue
+		}
+		total++
+		if recTags[tag] {
+			matches++
 		}
 	}
-	return cocktails
+	if all {
+		return total > 0 && matches == total
+	}
+	return matches > 0
+}
+
+// normalizeTag returns the tag in lower case without whitespaces, so "Contemporary Classic"
+// and "contemporaryclassic" are the same tag.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), ""))
+}
+
+// containsFold reports whether substr is within s, case-insensitively.
+func containsFold(s, substr string) bool {
+	return strings.Contains(
+		strings.ToLower(s),
+		strings.ToLower(substr),
+	)
 }
 
 // findCocktail checks if the cocktail record exists in the given records list.
@@ -186,9 +264,14 @@ func cocktailsEqual(c1, c2 entity.Cocktail) bool {
 	if c1.ImgSrc != c2.ImgSrc {
 		return false
 	}
-	if c1.Tags != c2.Tags {
+	if len(c1.Tags) != len(c2.Tags) {
 		return false
 	}
+	for i := range c1.Tags {
+		if c1.Tags[i] != c2.Tags[i] {
+			return false
+		}
+	}
 	if c1.Thumb != c2.Thumb {
 		return false
 	}
@@ -213,3 +296,163 @@ func cocktailsEqual(c1, c2 entity.Cocktail) bool {
 
 	return true
 }
+
+// fuzzyValues returns the distinct values of the property associated to the given filter,
+// along with the number of records holding each one of them.
+// Only the name, category, ingredient and glass filters support typo-tolerant matching.
+func fuzzyValues(fltr cocktailFilter, recs []entity.Cocktail) map[string]int {
+	values := make(map[string]int)
+	for _, rec := range recs {
+		switch fltr {
+		case nameFltr:
+			values[rec.Name]++
+		case categoryFltr:
+			values[rec.Category]++
+		case glassFltr:
+			values[rec.Glass]++
+		case ingredientFltr:
+			seen := make(map[string]bool)
+			for _, ingr := range rec.Ingredients {
+				if !seen[ingr.Name] {
+					seen[ingr.Name] = true
+					values[ingr.Name]++
+				}
+			}
+		}
+	}
+	delete(values, "")
+	return values
+}
+
+// suggestValues returns the values most similar to the given one, ranked by score, matches and value.
+// Values scoring below minSuggestionScore are discarded, and at most maxSuggestions are returned.
+func suggestValues(value string, values map[string]int) []ct.Suggestion {
+	suggestions := make([]ct.Suggestion, 0)
+	for v, matches := range values {
+		score := fuzzyScore(value, v)
+		if score < minSuggestionScore {
+			continue
+		}
+		suggestions = append(suggestions, ct.Suggestion{
+			Value:   v,
+			Score:   math.Round(score*100) / 100,
+			Matches: matches,
+		})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		if suggestions[i].Matches != suggestions[j].Matches {
+			return suggestions[i].Matches > suggestions[j].Matches
+		}
+		return suggestions[i].Value < suggestions[j].Value
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
+
+// fuzzyScore returns the similarity, from 0 to 1, between the searched value and a candidate.
+// The candidate is compared as a whole and word by word, so a value similar to part of
+// the candidate scores as high as one similar to the entire candidate. e.g. "margerita" vs "Strawberry Margarita"
+// The highest of the edit distance and trigram similarities is returned.
+func fuzzyScore(value, candidate string) float64 {
+	value = normalizeFuzzy(value)
+	candidate = normalizeFuzzy(candidate)
+	if value == "" || candidate == "" {
+		return 0
+	}
+
+	score := math.Max(editSimilarity(value, candidate), trigramSimilarity(value, candidate))
+	words := strings.Fields(candidate)
+	size := len(strings.Fields(value))
+	for i := 0; i+size <= len(words); i++ {
+		window := strings.Join(words[i:i+size], " ")
+		score = math.Max(score, editSimilarity(value, window))
+	}
+	return score
+}
+
+// normalizeFuzzy lower-cases the given value and collapses its whitespaces.
+func normalizeFuzzy(value string) string {
+	return strings.Join(strings.Fields(strings.ToLower(value)), " ")
+}
+
+// editSimilarity returns the edit distance between a and b normalized to a score from 0 to 1.
+func editSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance returns the optimal string alignment distance between a and b.
+// It is the Levenshtein distance where the transposition of two adjacent runes counts as a single edit. e.g. "vodak" vs "vodka"
+func editDistance(a, b []rune) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := 0; j <= len(b); j++ {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d := minInt(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d = minInt(d, rows[i-2][j-2]+1)
+			}
+			rows[i][j] = d
+		}
+	}
+	return rows[len(a)][len(b)]
+}
+
+// trigramSimilarity returns the Jaccard index of the trigram sets of a and b.
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams returns the set of three-rune sequences of the given value padded with spaces.
+func trigrams(value string) map[string]bool {
+	runes := []rune("  " + value + " ")
+	set := make(map[string]bool)
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = true
+	}
+	return set
+}
+
+// minInt returns the smallest of the given numbers.
+func minInt(first int, rest ...int) int {
+	m := first
+	for _, n := range rest {
+		if n < m {
+			m = n
+		}
+	}
+	return m
+}
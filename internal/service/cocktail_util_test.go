@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
 
 	"github.com/stretchr/testify/assert"
@@ -65,6 +66,11 @@ func TestNewCocktailFilter(t *testing.T) {
 			filter: "Glass",
 			exp:    glassFltr,
 		},
+		{
+			name:   "Tag",
+			filter: "TAG",
+			exp:    tagFltr,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -75,6 +81,135 @@ func TestNewCocktailFilter(t *testing.T) {
 	}
 }
 
+func TestMatchTag(t *testing.T) {
+	rec := entity.Cocktail{ID: 1, Tags: []string{"IBA", "Contemporary Classic"}}
+	tests := []struct {
+		name string
+		tags string
+		exp  bool
+	}{
+		{
+			name: "Single tag",
+			tags: "iba",
+			exp:  true,
+		},
+		{
+			name: "Normalized whitespaces",
+			tags: " contemporaryclassic ",
+			exp:  true,
+		},
+		{
+			name: "Partial tag",
+			tags: "classic",
+			exp:  false,
+		},
+		{
+			name: "Any tag",
+			tags: "Shot,IBA",
+			exp:  true,
+		},
+		{
+			name: "Any tag, no matches",
+			tags: "Shot,Sour",
+			exp:  false,
+		},
+		{
+			name: "All tags",
+			tags: "IBA+Contemporary Classic",
+			exp:  true,
+		},
+		{
+			name: "All tags, missing one",
+			tags: "IBA+Shot",
+			exp:  false,
+		},
+		{
+			name: "Empty tags",
+			tags: " , ",
+			exp:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.exp, matchTag(rec, tt.tags))
+		})
+	}
+}
+
+func TestMatchAlcoholic(t *testing.T) {
+	rec := entity.Cocktail{ID: 1, Alcoholic: "Non alcoholic"}
+	tests := []struct {
+		name      string
+		value     string
+		exp       bool
+		expPrefix bool
+	}{
+		{
+			name:      "Equal",
+			value:     "non ALCOHOLIC",
+			exp:       true,
+			expPrefix: true,
+		},
+		{
+			name:      "Prefix",
+			value:     "non",
+			exp:       false,
+			expPrefix: true,
+		},
+		{
+			name:      "Suffix",
+			value:     "alcoholic",
+			exp:       false,
+			expPrefix: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.exp, matchAlcoholic(rec, tt.value))
+			assert.Equal(t, tt.expPrefix, matchAlcoholicPrefix(rec, tt.value))
+		})
+	}
+}
+
+func TestCocktailsByIngredient(t *testing.T) {
+	recs := []entity.Cocktail{
+		{ID: 1, Name: "Foo", Ingredients: []entity.Ingredient{{Name: "Light rum"}, {Name: "Dark rum"}}},
+		{ID: 2, Name: "Bar", Ingredients: []entity.Ingredient{{Name: "Vodka"}}},
+		{ID: 3, Name: "Baz", Ingredients: []entity.Ingredient{{Name: "Rum"}, {Name: "Lime juice"}}},
+	}
+	tests := []struct {
+		name  string
+		value string
+		exp   []entity.Cocktail
+	}{
+		{
+			name:  "Matching several ingredients once",
+			value: "RUM",
+			exp:   []entity.Cocktail{recs[0], recs[2]},
+		},
+		{
+			name:  "Single ingredient",
+			value: "lime",
+			exp:   []entity.Cocktail{recs[2]},
+		},
+		{
+			name:  "No matches",
+			value: "gin",
+			exp:   []entity.Cocktail{},
+		},
+		{
+			name:  "Empty value",
+			value: "",
+			exp:   []entity.Cocktail{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.exp, cocktailsByIngredient(tt.value, recs))
+		})
+	}
+}
+
 func TestFindCocktail(t *testing.T) {
 	type args struct {
 		id   int
@@ -140,3 +275,62 @@ func TestFindCocktail(t *testing.T) {
 		})
 	}
 }
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		candidate string
+		exp       float64
+	}{
+		{
+			name:      "Empty",
+			value:     "",
+			candidate: "Margarita",
+			exp:       0,
+		},
+		{
+			name:      "Equal",
+			value:     "MARGARITA",
+			candidate: "margarita",
+			exp:       1,
+		},
+		{
+			name:      "Substitution",
+			value:     "margerita",
+			candidate: "Margarita",
+			exp:       1 - 1.0/9,
+		},
+		{
+			name:      "Transposition",
+			value:     "vodak",
+			candidate: "Vodka",
+			exp:       0.8,
+		},
+		{
+			name:      "Partial words",
+			value:     "margerita",
+			candidate: "Strawberry  Margarita",
+			exp:       1 - 1.0/9,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.exp, fuzzyScore(tt.value, tt.candidate), 0.001)
+		})
+	}
+}
+
+func TestSuggestValues(t *testing.T) {
+	values := map[string]int{
+		"Margarita":            3,
+		"Strawberry Margarita": 1,
+		"Martini":              5,
+		"Mojito":               2,
+	}
+	out := suggestValues("margerita", values)
+	assert.Equal(t, []ct.Suggestion{
+		{Value: "Margarita", Score: 0.89, Matches: 3},
+		{Value: "Strawberry Margarita", Score: 0.89, Matches: 1},
+	}, out)
+}
@@ -3,6 +3,19 @@ package service
 import (
 	"errors"
 	"fmt"
+	"net/http"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+)
+
+// The stable codes of the service errors, responded by the API.
+const (
+	QueryErrCode    = "service.query"
+	FilterErrCode   = "service.filter"
+	ArgsErrCode     = "service.arguments"
+	NotFoundErrCode = "service.not_found"
+	VersionErrCode  = "service.version_conflict"
+	StateErrCode    = "service.state_conflict"
 )
 
 var (
@@ -10,11 +23,47 @@ var (
 	ErrFltrValueEmpty = errors.New("filter value empty")
 	ErrFltrInvalid    = errors.New("invalid filter")
 
+	ErrQueryEmpty           = errors.New("query empty")
+	ErrQueryUnexpectedToken = errors.New("unexpected token")
+	ErrQueryUnexpectedEnd   = errors.New("unexpected end of query")
+	ErrQueryUnclosedParen   = errors.New("missing closing parenthesis")
+	ErrQueryUnclosedQuote   = errors.New("missing closing quote")
+	ErrQueryTermInvalid     = errors.New("invalid term, expected field:value")
+	ErrQueryTooDeep         = errors.New("query nested too deep")
+
 	ErrInvalidNumType   = errors.New("invalid number type")
 	ErrZeroValue        = errors.New("zero value is not allowed")
 	ErrJobsWorkerHigher = errors.New("jobs per worker higher than maximum jobs")
+	ErrInvalidTaxonomy  = errors.New("invalid taxonomy")
+
+	ErrInvalidChangeCursor = errors.New("invalid change cursor")
+
+	ErrCocktailNotFound   = errors.New("cocktail not found")
+	ErrVersionMismatch    = errors.New("version mismatch")
+	ErrFieldRequired      = errors.New("field required")
+	ErrCocktailNotDeleted = errors.New("cocktail not deleted")
+
+	ErrSyncTxNotFound       = errors.New("sync transaction not found")
+	ErrRemovedPolicyInvalid = errors.New("invalid removed policy, must be ignore, flag or delete")
+
+	ErrWebhookURLInvalid   = errors.New("invalid webhook url, must be an absolute http or https url")
+	ErrWebhookEventInvalid = errors.New("invalid webhook event")
+	ErrWebhookNotFound     = errors.New("webhook not found")
 )
 
+// RegisterProblems contributes the service errors to the given problems catalog.
+// The query syntax errors are wrapped by the filter errors, so they are registered first.
+func RegisterProblems(reg *ct.ProblemRegistry) {
+	reg.Register(
+		ct.ProblemType{Code: QueryErrCode, Title: "Invalid filter query", Status: http.StatusUnprocessableEntity, Match: ct.MatchAs[*QueryErr]()},
+		ct.ProblemType{Code: FilterErrCode, Title: "Invalid filter", Status: http.StatusUnprocessableEntity, Match: ct.MatchAs[*FilterErr]()},
+		ct.ProblemType{Code: ArgsErrCode, Title: "Invalid arguments", Status: http.StatusUnprocessableEntity, Match: ct.MatchAs[*ArgsErr]()},
+		ct.ProblemType{Code: NotFoundErrCode, Title: "Resource not found", Status: http.StatusNotFound, Match: ct.MatchAs[*NotFoundErr]()},
+		ct.ProblemType{Code: VersionErrCode, Title: "Version mismatch", Status: http.StatusPreconditionFailed, Match: ct.MatchAs[*VersionErr]()},
+		ct.ProblemType{Code: StateErrCode, Title: "Resource state conflict", Status: http.StatusConflict, Match: ct.MatchAs[*StateErr]()},
+	)
+}
+
 // FilterErr covers all errors related to Filters and wraps the error that caused it.
 type FilterErr struct {
 	Err error
@@ -28,6 +77,21 @@ func (e FilterErr) Unwrap() error {
 	return e.Err
 }
 
+// QueryErr covers the syntax errors of a filter query and wraps the error that caused it.
+// Pos is the 1-based position of the offending character in the query.
+type QueryErr struct {
+	Pos int
+	Err error
+}
+
+func (e QueryErr) Error() string {
+	return fmt.Sprintf("query: %s at position %d", e.Err, e.Pos)
+}
+
+func (e QueryErr) Unwrap() error {
+	return e.Err
+}
+
 // ArgsErr covers all errors related to the given arguments and wraps the error that caused it.
 type ArgsErr struct {
 	Err error
@@ -40,3 +104,45 @@ func (e ArgsErr) Error() string {
 func (e ArgsErr) Unwrap() error {
 	return e.Err
 }
+
+// NotFoundErr covers all errors related to missing resources and wraps the error that caused it.
+type NotFoundErr struct {
+	Err error
+}
+
+func (e NotFoundErr) Error() string {
+	return fmt.Sprintf("service not found: %s", e.Err)
+}
+
+func (e NotFoundErr) Unwrap() error {
+	return e.Err
+}
+
+// VersionErr covers the writes of a record whose version is not the expected one, and wraps the error that caused it.
+// Current is the current version of the record.
+type VersionErr struct {
+	Current int
+	Err     error
+}
+
+func (e VersionErr) Error() string {
+	return fmt.Sprintf("service version: %s, the current version is %d", e.Err, e.Current)
+}
+
+func (e VersionErr) Unwrap() error {
+	return e.Err
+}
+
+// StateErr covers the operations not allowed by the current state of a resource, and wraps the error that caused it.
+// e.g. restoring a record that is not deleted
+type StateErr struct {
+	Err error
+}
+
+func (e StateErr) Error() string {
+	return fmt.Sprintf("service state: %s", e.Err)
+}
+
+func (e StateErr) Unwrap() error {
+	return e.Err
+}
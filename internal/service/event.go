@@ -0,0 +1,118 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+)
+
+// The event types published by the services.
+const (
+	CocktailCreatedEvent  = "cocktail.created"
+	CocktailUpdatedEvent  = "cocktail.updated"
+	CocktailDeletedEvent  = "cocktail.deleted"
+	CocktailRestoredEvent = "cocktail.restored"
+	SyncCompletedEvent    = "sync.completed"
+	SyncRolledBackEvent   = "sync.rolled_back"
+
+	// defaultEventBufferSize is the number of the latest events kept by default to resume the subscriptions.
+	defaultEventBufferSize = 1000
+	// subscriberBufferSize is the number of events a subscriber can fall behind before it gets dropped.
+	subscriberBufferSize = 64
+)
+
+// EventPublisher is the abstraction of the events publishing dependency.
+type EventPublisher interface {
+	Publish(eventType string, data any)
+}
+
+var _ EventPublisher = &EventBroker{}
+
+// EventBroker delivers the published events to its subscribers, in-process.
+// The latest events are kept in a ring buffer, so subscribers can resume from the last event they received.
+// Subscribers falling behind are dropped, rather than slowing down the publishers; they can subscribe again
+// from their last event.
+type EventBroker struct {
+	mu          sync.Mutex
+	lastID      int64
+	buffer      []ct.Event
+	next        int
+	full        bool
+	subscribers map[chan ct.Event]struct{}
+}
+
+// NewEventBroker returns a new EventBroker keeping the given number of the latest events.
+// Defaults to 1000 events if size is not positive.
+func NewEventBroker(size int) *EventBroker {
+	if size <= 0 {
+		size = defaultEventBufferSize
+	}
+	return &EventBroker{
+		buffer:      make([]ct.Event, size),
+		subscribers: make(map[chan ct.Event]struct{}),
+	}
+}
+
+// Publish numbers a new event of the given type and data, and delivers it to the subscribers.
+func (b *EventBroker) Publish(eventType string, data any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := ct.Event{ID: b.lastID, Type: eventType, Time: time.Now().UTC(), Data: data}
+	b.buffer[b.next] = event
+	b.next = (b.next + 1) % len(b.buffer)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			logger.Log().Warn().Int64("event_id", event.ID).Msg("EventBroker: subscriber fell behind, dropped")
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe registers a new subscriber and returns the buffered events published after lastID, along with
+// the channel delivering the following events. A zero lastID replays no events.
+// If lastID is unknown, e.g. it comes from before a restart, all the buffered events are replayed.
+// The channel is closed if the subscriber falls behind. cancel must be called to unsubscribe.
+func (b *EventBroker) Subscribe(lastID int64) (replay []ct.Event, events <-chan ct.Event, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	replay = make([]ct.Event, 0)
+	if lastID > 0 {
+		for _, event := range b.buffered() {
+			if event.ID > lastID || lastID > b.lastID {
+				replay = append(replay, event)
+			}
+		}
+	}
+
+	ch := make(chan ct.Event, subscriberBufferSize)
+	b.subscribers[ch] = struct{}{}
+	cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return replay, ch, cancel
+}
+
+// buffered returns the buffered events from the oldest one.
+func (b *EventBroker) buffered() []ct.Event {
+	if !b.full {
+		return b.buffer[:b.next]
+	}
+	return append(append([]ct.Event(nil), b.buffer[b.next:]...), b.buffer[:b.next]...)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"testing"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ EventPublisher = &mocks.EventPublisher{}
+
+func eventIDs(events []ct.Event) []int64 {
+	ids := make([]int64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestEventBroker_Subscribe(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		published int
+		lastID    int64
+		exp       []int64
+	}{
+		{
+			name:      "No last event",
+			size:      5,
+			published: 3,
+			lastID:    0,
+			exp:       []int64{},
+		},
+		{
+			name:      "Resume",
+			size:      5,
+			published: 4,
+			lastID:    2,
+			exp:       []int64{3, 4},
+		},
+		{
+			name:      "Resume after the buffer wrapped",
+			size:      3,
+			published: 7,
+			lastID:    5,
+			exp:       []int64{6, 7},
+		},
+		{
+			name:      "Last event older than the buffer",
+			size:      3,
+			published: 7,
+			lastID:    1,
+			exp:       []int64{5, 6, 7},
+		},
+		{
+			name:      "Unknown last event",
+			size:      3,
+			published: 2,
+			lastID:    10,
+			exp:       []int64{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := NewEventBroker(tt.size)
+			for i := 0; i < tt.published; i++ {
+				broker.Publish(CocktailCreatedEvent, i)
+			}
+
+			replay, _, cancel := broker.Subscribe(tt.lastID)
+			defer cancel()
+			assert.Equal(t, tt.exp, eventIDs(replay))
+		})
+	}
+}
+
+func TestEventBroker_Publish(t *testing.T) {
+	broker := NewEventBroker(0)
+	_, events, cancel := broker.Subscribe(0)
+	_, slowEvents, slowCancel := broker.Subscribe(0)
+	defer slowCancel()
+
+	// Delivered
+	broker.Publish(SyncCompletedEvent, ct.DBOpsSummary{TotalOps: 1})
+	event := <-events
+	assert.Equal(t, int64(1), event.ID)
+	assert.Equal(t, SyncCompletedEvent, event.Type)
+	assert.Equal(t, ct.DBOpsSummary{TotalOps: 1}, event.Data)
+
+	// The slow subscriber is dropped once it falls behind
+	for i := 0; i < subscriberBufferSize; i++ {
+		broker.Publish(CocktailUpdatedEvent, i)
+		<-events
+	}
+	n := 0
+	for range slowEvents {
+		n++
+	}
+	assert.Equal(t, subscriberBufferSize, n)
+
+	// Unsubscribed
+	cancel()
+	_, ok := <-events
+	require.False(t, ok)
+	broker.Publish(CocktailCreatedEvent, nil)
+}
@@ -1,6 +1,9 @@
 package mocks
 
 import (
+	"context"
+	"time"
+
 	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
 
@@ -18,6 +21,24 @@ func (o *CocktailRepo) ReadAll() ([]entity.Cocktail, error) {
 	return args.Get(0).([]entity.Cocktail), args.Error(1)
 }
 
+// ReadAllWithDeleted provides a mock function with given fields:
+func (o *CocktailRepo) ReadAllWithDeleted() ([]entity.Cocktail, error) {
+	args := o.Called()
+	return args.Get(0).([]entity.Cocktail), args.Error(1)
+}
+
+// Stream provides a mock function with given fields:
+// The records returned by the mock are passed to fn one by one, before returning the mock error.
+func (o *CocktailRepo) Stream(ctx context.Context, fn func(entity.Cocktail) error) error {
+	args := o.Called(ctx)
+	for _, rec := range args.Get(0).([]entity.Cocktail) {
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
 // ReadCC provides a mock function with given fields:
 func (o *CocktailRepo) ReadCC(nType ct.NumberType, maxJobs, jWorker int) ([]entity.Cocktail, error) {
 	args := o.Called(nType, maxJobs, jWorker)
@@ -25,18 +46,139 @@ func (o *CocktailRepo) ReadCC(nType ct.NumberType, maxJobs, jWorker int) ([]enti
 }
 
 // ReplaceDB provides a mock function with given fields:
-func (o *CocktailRepo) ReplaceDB(recs []entity.Cocktail) error {
-	args := o.Called(recs)
+func (o *CocktailRepo) ReplaceDB(recs []entity.Cocktail, author entity.Author) error {
+	args := o.Called(recs, author)
 	return args.Error(0)
 }
 
+// ReplaceDBFunc provides a mock function composed of the ReadAllWithDeleted, Snapshot and ReplaceDB mock functions:
+// fn is given a copy of the records read, and the records it returns are snapshotted under txID, unless it is empty,
+// and replaced, as the repository does.
+func (o *CocktailRepo) ReplaceDBFunc(txID string, author entity.Author, fn func([]entity.Cocktail) ([]entity.Cocktail, error)) error {
+	recs, err := o.ReadAllWithDeleted()
+	if err != nil {
+		return err
+	}
+	recs, err = fn(append([]entity.Cocktail(nil), recs...))
+	if err != nil || recs == nil {
+		return err
+	}
+	if txID != "" {
+		if err := o.Snapshot(txID); err != nil {
+			return err
+		}
+	}
+	return o.ReplaceDB(recs, author)
+}
+
+// ReadHistory provides a mock function with given fields:
+func (o *CocktailRepo) ReadHistory(id int) ([]entity.Revision, error) {
+	args := o.Called(id)
+	return args.Get(0).([]entity.Revision), args.Error(1)
+}
+
+// ReadAsOf provides a mock function with given fields:
+func (o *CocktailRepo) ReadAsOf(t time.Time) ([]entity.Cocktail, error) {
+	args := o.Called(t)
+	return args.Get(0).([]entity.Cocktail), args.Error(1)
+}
+
+// ReadChanges provides a mock function with given fields:
+func (o *CocktailRepo) ReadChanges(since int64, limit int) ([]entity.Change, error) {
+	args := o.Called(since, limit)
+	return args.Get(0).([]entity.Change), args.Error(1)
+}
+
+// Snapshot provides a mock function with given fields:
+func (o *CocktailRepo) Snapshot(txID string) error {
+	args := o.Called(txID)
+	return args.Error(0)
+}
+
+// ReadSnapshot provides a mock function with given fields:
+func (o *CocktailRepo) ReadSnapshot(txID string) ([]entity.Cocktail, bool, error) {
+	args := o.Called(txID)
+	return args.Get(0).([]entity.Cocktail), args.Bool(1), args.Error(2)
+}
+
 // Fetch provides a mock function with given fields:
-func (o *CocktailRepo) Fetch() ([]entity.Cocktail, error) {
+func (o *CocktailRepo) Fetch() ([]entity.Cocktail, []int, error) {
 	args := o.Called()
-	return args.Get(0).([]entity.Cocktail), args.Error(1)
+	skipped, _ := args.Get(1).([]int)
+	return args.Get(0).([]entity.Cocktail), skipped, args.Error(2)
+}
+
+// Checksum provides a mock function with given fields:
+func (o *CocktailRepo) Checksum() (string, error) {
+	args := o.Called()
+	return args.String(0), args.Error(1)
+}
+
+// LastModified provides a mock function with given fields:
+func (o *CocktailRepo) LastModified() (time.Time, error) {
+	args := o.Called()
+	return args.Get(0).(time.Time), args.Error(1)
 }
 
 // NewCocktailRepo creates a new instance of the CocktailRepo of type Mock.
 func NewCocktailRepo() *CocktailRepo {
 	return &CocktailRepo{}
 }
+
+// CocktailIndexRepo is a mock type for the CocktailRepo dependency implementing the CocktailIndex abstraction
+type CocktailIndexRepo struct {
+	CocktailRepo
+}
+
+// ReadByID provides a mock function with given fields: id
+func (o *CocktailIndexRepo) ReadByID(id int) (entity.Cocktail, bool, error) {
+	args := o.Called(id)
+	return args.Get(0).(entity.Cocktail), args.Bool(1), args.Error(2)
+}
+
+// ReadIndexed provides a mock function with given fields: field, match
+// The keys returned by the mock are matched, and the records of the matching ones returned, before the mock error.
+func (o *CocktailIndexRepo) ReadIndexed(field ct.IndexField, match func(key string) bool) ([]entity.Cocktail, error) {
+	args := o.Called(field)
+	cocktails := make([]entity.Cocktail, 0)
+	for key, recs := range args.Get(0).(map[string][]entity.Cocktail) {
+		if match(key) {
+			cocktails = append(cocktails, recs...)
+		}
+	}
+	return cocktails, args.Error(1)
+}
+
+// NewCocktailIndexRepo creates a new instance of the CocktailIndexRepo of type Mock.
+func NewCocktailIndexRepo() *CocktailIndexRepo {
+	return &CocktailIndexRepo{}
+}
+
+// CocktailRecordWriterRepo is a mock type for the CocktailRepo dependency implementing the CocktailRecordWriter
+// abstraction
+type CocktailRecordWriterRepo struct {
+	CocktailRepo
+}
+
+// PutRecord provides a mock function with given fields: rec, version, author
+func (o *CocktailRecordWriterRepo) PutRecord(rec entity.Cocktail, version int, author entity.Author) error {
+	args := o.Called(rec, version, author)
+	return args.Error(0)
+}
+
+// PurgeRecord provides a mock function with given fields: id, version, author
+func (o *CocktailRecordWriterRepo) PurgeRecord(id, version int, author entity.Author) error {
+	args := o.Called(id, version, author)
+	return args.Error(0)
+}
+
+// Compact provides a mock function with given fields:
+func (o *CocktailRecordWriterRepo) Compact() (int, error) {
+	args := o.Called()
+	return args.Int(0), args.Error(1)
+}
+
+// NewCocktailRecordWriterRepo creates a new instance of the CocktailRecordWriterRepo of type Mock.
+func NewCocktailRecordWriterRepo() *CocktailRecordWriterRepo {
+	return &CocktailRecordWriterRepo{}
+}
@@ -0,0 +1,20 @@
+package mocks
+
+import (
+	"github.com/stretchr/testify/mock"
+)
+
+// EventPublisher is a mock type for the EventPublisher dependency
+type EventPublisher struct {
+	mock.Mock
+}
+
+// Publish provides a mock function with given fields:
+func (o *EventPublisher) Publish(eventType string, data any) {
+	o.Called(eventType, data)
+}
+
+// NewEventPublisher creates a new instance of the EventPublisher of type Mock.
+func NewEventPublisher() *EventPublisher {
+	return &EventPublisher{}
+}
@@ -0,0 +1,62 @@
+package mocks
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// WebhookRepo is a mock type for the WebhookRepo dependency
+type WebhookRepo struct {
+	mock.Mock
+}
+
+// ReadAll provides a mock function with given fields:
+func (o *WebhookRepo) ReadAll() ([]entity.Webhook, error) {
+	args := o.Called()
+	return args.Get(0).([]entity.Webhook), args.Error(1)
+}
+
+// ReplaceAll provides a mock function with given fields:
+func (o *WebhookRepo) ReplaceAll(webhooks []entity.Webhook) error {
+	args := o.Called(webhooks)
+	return args.Error(0)
+}
+
+// AppendDelivery provides a mock function with given fields:
+func (o *WebhookRepo) AppendDelivery(delivery entity.Delivery) error {
+	args := o.Called(delivery)
+	return args.Error(0)
+}
+
+// ReadDeliveries provides a mock function with given fields:
+func (o *WebhookRepo) ReadDeliveries(webhookID string) ([]entity.Delivery, error) {
+	args := o.Called(webhookID)
+	return args.Get(0).([]entity.Delivery), args.Error(1)
+}
+
+// AppendDeadLetter provides a mock function with given fields:
+func (o *WebhookRepo) AppendDeadLetter(letter entity.DeadLetter) error {
+	args := o.Called(letter)
+	return args.Error(0)
+}
+
+// ReadDeadLetters provides a mock function with given fields:
+func (o *WebhookRepo) ReadDeadLetters(webhookID string) ([]entity.DeadLetter, error) {
+	args := o.Called(webhookID)
+	return args.Get(0).([]entity.DeadLetter), args.Error(1)
+}
+
+// Post provides a mock function with given fields:
+func (o *WebhookRepo) Post(ctx context.Context, url string, header http.Header, body []byte) (int, error) {
+	args := o.Called(ctx, url, header, body)
+	return args.Int(0), args.Error(1)
+}
+
+// NewWebhookRepo creates a new instance of the WebhookRepo of type Mock.
+func NewWebhookRepo() *WebhookRepo {
+	return &WebhookRepo{}
+}
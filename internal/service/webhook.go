@@ -0,0 +1,451 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+)
+
+const (
+	// The headers of the webhook notifications.
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+
+	// defaultWebhookAttempts is the number of times a notification is sent before it is dead-lettered.
+	defaultWebhookAttempts = 5
+	// defaultWebhookBackoff is the time waited before the first retry, which doubles after every attempt.
+	defaultWebhookBackoff = time.Second
+	// defaultWebhookQueueSize is the number of notifications a webhook can fall behind before the following ones
+	// are dead-lettered.
+	defaultWebhookQueueSize = 1000
+	// defaultDeliveriesLimit is the number of deliveries returned by default.
+	defaultDeliveriesLimit = 50
+)
+
+// webhookEvents are the event types the webhooks can be notified of.
+var webhookEvents = map[string]bool{
+	CocktailCreatedEvent:  true,
+	CocktailUpdatedEvent:  true,
+	CocktailDeletedEvent:  true,
+	CocktailRestoredEvent: true,
+	SyncCompletedEvent:    true,
+	SyncRolledBackEvent:   true,
+}
+
+// Webhook registers the webhooks and notifies them of the database events.
+// Every webhook is notified by its own worker, from its own queue, so a slow or failing endpoint delays its own
+// notifications only.
+type Webhook struct {
+	repo      WebhookRepo
+	mu        *sync.Mutex
+	attempts  int
+	backoff   time.Duration
+	queueSize int
+}
+
+// WebhookRepo is the abstraction of the Webhook repository dependency.
+type WebhookRepo interface {
+	ReadAll() ([]entity.Webhook, error)
+	ReplaceAll(webhooks []entity.Webhook) error
+	AppendDelivery(delivery entity.Delivery) error
+	ReadDeliveries(webhookID string) ([]entity.Delivery, error)
+	AppendDeadLetter(letter entity.DeadLetter) error
+	ReadDeadLetters(webhookID string) ([]entity.DeadLetter, error)
+	Post(ctx context.Context, url string, header http.Header, body []byte) (int, error)
+}
+
+// EventSubscriber is the abstraction of the events subscription dependency.
+type EventSubscriber interface {
+	Subscribe(lastID int64) (replay []ct.Event, events <-chan ct.Event, cancel func())
+}
+
+// NewWebhook returns a new Webhook service implementation.
+func NewWebhook(repo WebhookRepo) Webhook {
+	return Webhook{
+		repo:      repo,
+		mu:        &sync.Mutex{},
+		attempts:  defaultWebhookAttempts,
+		backoff:   defaultWebhookBackoff,
+		queueSize: defaultWebhookQueueSize,
+	}
+}
+
+// Register registers a new webhook notified of the given event types; empty events notifies all of them.
+// The URL must be an absolute http or https URL. If the secret is empty, a random one is generated.
+// The returned entity.Webhook is the only one holding the secret.
+func (s Webhook) Register(rawURL, secret string, events []string) (entity.Webhook, error) {
+	uri, err := url.ParseRequestURI(rawURL)
+	if err != nil || (uri.Scheme != "http" && uri.Scheme != "https") || uri.Host == "" {
+		return entity.Webhook{}, &ArgsErr{&ct.FieldErr{Field: "url", Err: fmt.Errorf("%w: %q", ErrWebhookURLInvalid, rawURL)}}
+	}
+	for _, event := range events {
+		if !webhookEvents[event] {
+			return entity.Webhook{}, &ArgsErr{&ct.FieldErr{Field: "events", Err: fmt.Errorf("%w: %q", ErrWebhookEventInvalid, event)}}
+		}
+	}
+	if secret == "" {
+		secret = randomHex(32)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webhooks, err := s.repo.ReadAll()
+	if err != nil {
+		return entity.Webhook{}, err
+	}
+	webhook := entity.Webhook{
+		ID:        randomHex(8),
+		URL:       uri.String(),
+		Secret:    secret,
+		Events:    append([]string{}, events...),
+		CreatedAt: dateTimeNow(),
+	}
+	if err := s.repo.ReplaceAll(append(webhooks, webhook)); err != nil {
+		return entity.Webhook{}, err
+	}
+	return webhook, nil
+}
+
+// GetAll returns all the registered webhooks, without their secrets.
+func (s Webhook) GetAll() ([]entity.Webhook, error) {
+	webhooks, err := s.repo.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+// Delete unregisters the given webhook. Its delivery history and dead letters are kept.
+func (s Webhook) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webhooks, err := s.repo.ReadAll()
+	if err != nil {
+		return err
+	}
+	index, found := findWebhook(id, webhooks)
+	if !found {
+		return &NotFoundErr{fmt.Errorf("%w: %q", ErrWebhookNotFound, id)}
+	}
+	return s.repo.ReplaceAll(append(webhooks[:index], webhooks[index+1:]...))
+}
+
+// GetDeliveries returns the latest deliveries of the given webhook, from the most recent one.
+// limit is the maximum number of deliveries returned; defaults to 50 if it is not positive.
+func (s Webhook) GetDeliveries(id string, limit int) ([]entity.Delivery, error) {
+	if err := s.checkWebhook(id); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.repo.ReadDeliveries(id)
+	if err != nil {
+		return nil, err
+	}
+	return latest(deliveries, limit), nil
+}
+
+// GetDeadLetters returns the latest notifications of the given webhook that could not be delivered,
+// from the most recent one, along with their payloads.
+// limit is the maximum number of dead letters returned; defaults to 50 if it is not positive.
+func (s Webhook) GetDeadLetters(id string, limit int) ([]entity.DeadLetter, error) {
+	if err := s.checkWebhook(id); err != nil {
+		return nil, err
+	}
+	letters, err := s.repo.ReadDeadLetters(id)
+	if err != nil {
+		return nil, err
+	}
+	return latest(letters, limit), nil
+}
+
+// checkWebhook returns a NotFoundErr if the given webhook is not registered.
+func (s Webhook) checkWebhook(id string) error {
+	webhooks, err := s.repo.ReadAll()
+	if err != nil {
+		return err
+	}
+	if _, found := findWebhook(id, webhooks); !found {
+		return &NotFoundErr{fmt.Errorf("%w: %q", ErrWebhookNotFound, id)}
+	}
+	return nil
+}
+
+// Run notifies the registered webhooks of the subscribed events until the context is done, and waits for the
+// notifications in progress to end. The events are queued to the webhooks registered for them, each one delivering
+// its notifications in event order, so the retries of a webhook do not hold up the events or the other webhooks.
+// If the subscription falls behind while notifying, it subscribes again from the last notified event.
+func (s Webhook) Run(ctx context.Context, subscriber EventSubscriber) {
+	d := s.newDispatcher(ctx)
+	defer d.close()
+
+	var lastID int64
+	for ctx.Err() == nil {
+		replay, events, cancel := subscriber.Subscribe(lastID)
+		for _, event := range replay {
+			d.notify(event)
+			lastID = event.ID
+		}
+		func() {
+			defer cancel()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event, ok := <-events:
+					if !ok {
+						logger.Log().Warn().Int64("last_event_id", lastID).Msg("Run: subscription closed, subscribing again")
+						return
+					}
+					d.notify(event)
+					lastID = event.ID
+				}
+			}
+		}()
+	}
+}
+
+// dispatcher queues the events to the workers of the webhooks registered for them. It is owned by Run.
+type dispatcher struct {
+	svc    Webhook
+	ctx    context.Context
+	queues map[string]*webhookQueue
+	wg     sync.WaitGroup
+}
+
+// webhookQueue holds the notifications of a webhook, delivered one at a time by its worker until it is canceled.
+type webhookQueue struct {
+	jobs   chan notification
+	cancel context.CancelFunc
+}
+
+// notification is the event to deliver to a webhook, along with its payload.
+type notification struct {
+	webhook entity.Webhook
+	event   ct.Event
+	payload []byte
+}
+
+// newDispatcher returns a new dispatcher whose workers run until the given context is done.
+func (s Webhook) newDispatcher(ctx context.Context) *dispatcher {
+	return &dispatcher{svc: s, ctx: ctx, queues: make(map[string]*webhookQueue)}
+}
+
+// notify queues the given event to the webhooks registered for it, starting their workers if needed, and stops the
+// workers of the unregistered webhooks. A notification not fitting in the queue of its webhook is dead-lettered.
+func (d *dispatcher) notify(event ct.Event) {
+	webhooks, err := d.svc.repo.ReadAll()
+	if err != nil {
+		logger.Log().Error().Err(err).Int64("event_id", event.ID).Msg("notify: reading webhooks failed, event skipped")
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logger.Log().Error().Err(err).Int64("event_id", event.ID).Msg("notify: encoding event failed, event skipped")
+		return
+	}
+
+	registered := make(map[string]bool, len(webhooks))
+	for _, webhook := range webhooks {
+		registered[webhook.ID] = true
+	}
+	for id, queue := range d.queues {
+		if !registered[id] {
+			queue.stop()
+			delete(d.queues, id)
+		}
+	}
+
+	for _, webhook := range webhooks {
+		if !webhookNotifies(webhook, event.Type) {
+			continue
+		}
+		queue, found := d.queues[webhook.ID]
+		if !found {
+			queue = d.start()
+			d.queues[webhook.ID] = queue
+		}
+		select {
+		case queue.jobs <- notification{webhook: webhook, event: event, payload: payload}:
+		default:
+			d.svc.record(webhook, entity.Delivery{
+				ID:        randomHex(8),
+				WebhookID: webhook.ID,
+				EventID:   event.ID,
+				EventType: event.Type,
+				Status:    entity.DeliveryFailed,
+				Error:     "webhook queue full, notification dropped",
+			}, payload)
+		}
+	}
+}
+
+// start starts a new worker, delivering the notifications of its queue until the queue is stopped.
+// The notifications left once the worker is canceled fail right away, so they are dead-lettered.
+func (d *dispatcher) start() *webhookQueue {
+	ctx, cancel := context.WithCancel(d.ctx)
+	queue := &webhookQueue{jobs: make(chan notification, d.svc.queueSize), cancel: cancel}
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		for n := range queue.jobs {
+			d.svc.deliver(ctx, n.webhook, n.event, n.payload)
+		}
+	}()
+	return queue
+}
+
+// stop cancels the worker of the queue, and closes it.
+func (q *webhookQueue) stop() {
+	q.cancel()
+	close(q.jobs)
+}
+
+// close stops all the workers, and waits for them to end.
+func (d *dispatcher) close() {
+	for id, queue := range d.queues {
+		queue.stop()
+		delete(d.queues, id)
+	}
+	d.wg.Wait()
+}
+
+// deliver sends the payload of the event to the webhook, retrying with an exponential backoff until it succeeds,
+// the attempts run out or the context is done. The delivery is recorded in the delivery history,
+// and the failed ones in the dead-letter log as well.
+func (s Webhook) deliver(ctx context.Context, webhook entity.Webhook, event ct.Event, payload []byte) entity.Delivery {
+	delivery := entity.Delivery{
+		ID:        randomHex(8),
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Status:    entity.DeliveryFailed,
+	}
+
+	backoff := s.backoff
+	for delivery.Attempts < s.attempts {
+		if delivery.Attempts > 0 {
+			select {
+			case <-ctx.Done():
+				delivery.Error = ctx.Err().Error()
+				return s.record(webhook, delivery, payload)
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		delivery.Attempts++
+
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		header := http.Header{}
+		header.Set(WebhookSignatureHeader, signPayload(webhook.Secret, timestamp, payload))
+		header.Set(WebhookTimestampHeader, timestamp)
+		header.Set(WebhookEventHeader, event.Type)
+		header.Set(WebhookDeliveryHeader, delivery.ID)
+
+		code, err := s.repo.Post(ctx, webhook.URL, header, payload)
+		delivery.Code = code
+		switch {
+		case err != nil:
+			delivery.Error = err.Error()
+		case code < 200 || code > 299:
+			delivery.Error = fmt.Sprintf("unexpected response status code %d", code)
+		default:
+			delivery.Status = entity.DeliverySucceeded
+			delivery.Error = ""
+			return s.record(webhook, delivery, payload)
+		}
+		logger.Log().Debug().Str("webhook", webhook.ID).Int64("event_id", event.ID).Int("attempt", delivery.Attempts).
+			Str("error", delivery.Error).Msg("deliver: attempt failed")
+	}
+	return s.record(webhook, delivery, payload)
+}
+
+// record appends the delivery to the delivery history and, if it failed, to the dead-letter log.
+func (s Webhook) record(webhook entity.Webhook, delivery entity.Delivery, payload []byte) entity.Delivery {
+	delivery.Time = time.Now().UTC()
+	if err := s.repo.AppendDelivery(delivery); err != nil {
+		logger.Log().Error().Err(err).Str("delivery", delivery.ID).Msg("record: appending delivery failed")
+	}
+	if delivery.Status == entity.DeliverySucceeded {
+		return delivery
+	}
+
+	logger.Log().Warn().Str("webhook", webhook.ID).Int64("event_id", delivery.EventID).Str("error", delivery.Error).
+		Msg("record: delivery failed, dead-lettered")
+	letter := entity.DeadLetter{Delivery: delivery, URL: webhook.URL, Payload: payload}
+	if err := s.repo.AppendDeadLetter(letter); err != nil {
+		logger.Log().Error().Err(err).Str("delivery", delivery.ID).Msg("record: appending dead letter failed")
+	}
+	return delivery
+}
+
+// signPayload returns the signature of the webhook payload: the hex encoded HMAC-SHA256 of the
+// timestamp and the payload joined by a dot, keyed by the webhook secret, prefixed by "sha256=".
+func signPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookNotifies reports whether the webhook is registered for the given event type.
+func webhookNotifies(webhook entity.Webhook, eventType string) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, event := range webhook.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// findWebhook returns the index of the given webhook in the list, and whether it was found.
+func findWebhook(id string, webhooks []entity.Webhook) (index int, found bool) {
+	for i, webhook := range webhooks {
+		if webhook.ID == id {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// latest returns the last records of the list in reverse order, up to limit.
+// limit defaults to 50 if it is not positive.
+func latest[T any](recs []T, limit int) []T {
+	if limit <= 0 {
+		limit = defaultDeliveriesLimit
+	}
+	out := make([]T, 0, limit)
+	for i := len(recs) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, recs[i])
+	}
+	return out
+}
+
+// randomHex returns a random hex encoded string of n bytes.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("randomHex: reading random bytes failed: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
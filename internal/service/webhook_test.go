@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/entity"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/service/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var _ WebhookRepo = &mocks.WebhookRepo{}
+
+var testWebhooks = []entity.Webhook{
+	{ID: "a1", URL: "https://foo.com/hook", Secret: "foo secret"},
+	{ID: "b2", URL: "https://bar.com/hook", Secret: "bar secret", Events: []string{SyncCompletedEvent}},
+}
+
+func TestWebhook_Register(t *testing.T) {
+	type args struct {
+		url    string
+		secret string
+		events []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		err     error
+		repoErr error
+	}{
+		{
+			name: "Relative URL",
+			args: args{url: "/hook"},
+			err:  ErrWebhookURLInvalid,
+		},
+		{
+			name: "Unsupported scheme",
+			args: args{url: "ftp://foo.com/hook"},
+			err:  ErrWebhookURLInvalid,
+		},
+		{
+			name: "Invalid event",
+			args: args{url: "https://foo.com/hook", events: []string{"cocktail.eaten"}},
+			err:  ErrWebhookEventInvalid,
+		},
+		{
+			name:    "Repository error",
+			args:    args{url: "https://foo.com/hook"},
+			err:     testRepoErr,
+			repoErr: testRepoErr,
+		},
+		{
+			name: "Generated secret",
+			args: args{url: "https://foo.com/hook"},
+		},
+		{
+			name: "Given secret and events",
+			args: args{url: "http://foo.com/hook", secret: "s3cr3t", events: []string{CocktailCreatedEvent, SyncCompletedEvent}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewWebhookRepo()
+			mRepo.On("ReadAll").Return([]entity.Webhook{testWebhooks[0]}, nil)
+			mRepo.On("ReplaceAll", mock.Anything).Return(tt.repoErr)
+			svc := NewWebhook(mRepo)
+
+			out, err := svc.Register(tt.args.url, tt.args.secret, tt.args.events)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.NotEmpty(t, out.ID)
+			assert.Equal(t, tt.args.url, out.URL)
+			assert.NotEmpty(t, out.Secret)
+			if tt.args.secret != "" {
+				assert.Equal(t, tt.args.secret, out.Secret)
+			}
+			assert.Equal(t, append([]string{}, tt.args.events...), out.Events)
+			mRepo.AssertCalled(t, "ReplaceAll", []entity.Webhook{testWebhooks[0], out})
+		})
+	}
+}
+
+func TestWebhook_GetAll(t *testing.T) {
+	mRepo := mocks.NewWebhookRepo()
+	mRepo.On("ReadAll").Return(append([]entity.Webhook{}, testWebhooks...), nil)
+	svc := NewWebhook(mRepo)
+
+	out, err := svc.GetAll()
+	require.Nil(t, err)
+	require.Len(t, out, 2)
+	for _, webhook := range out {
+		assert.Empty(t, webhook.Secret)
+	}
+}
+
+func TestWebhook_Delete(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		exp  []entity.Webhook
+		err  error
+	}{
+		{
+			name: "Not found",
+			id:   "c3",
+			err:  ErrWebhookNotFound,
+		},
+		{
+			name: "Deleted",
+			id:   "a1",
+			exp:  testWebhooks[1:],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewWebhookRepo()
+			mRepo.On("ReadAll").Return(append([]entity.Webhook{}, testWebhooks...), nil)
+			mRepo.On("ReplaceAll", tt.exp).Return(nil)
+			svc := NewWebhook(mRepo)
+
+			err := svc.Delete(tt.id)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.IsType(t, &NotFoundErr{}, err)
+				assert.ErrorIs(t, err, tt.err)
+				mRepo.AssertNotCalled(t, "ReplaceAll", mock.Anything)
+				return
+			}
+			require.Nil(t, err)
+			mRepo.AssertCalled(t, "ReplaceAll", tt.exp)
+		})
+	}
+}
+
+func TestWebhook_GetDeliveries(t *testing.T) {
+	deliveries := []entity.Delivery{{ID: "d1", WebhookID: "a1"}, {ID: "d2", WebhookID: "a1"}, {ID: "d3", WebhookID: "a1"}}
+	tests := []struct {
+		name  string
+		id    string
+		limit int
+		exp   []entity.Delivery
+		err   error
+	}{
+		{
+			name: "Not found",
+			id:   "c3",
+			err:  ErrWebhookNotFound,
+		},
+		{
+			name:  "Latest first",
+			id:    "a1",
+			limit: 2,
+			exp:   []entity.Delivery{deliveries[2], deliveries[1]},
+		},
+		{
+			name:  "Default limit",
+			id:    "a1",
+			limit: 0,
+			exp:   []entity.Delivery{deliveries[2], deliveries[1], deliveries[0]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewWebhookRepo()
+			mRepo.On("ReadAll").Return(testWebhooks, nil)
+			mRepo.On("ReadDeliveries", tt.id).Return(deliveries, nil)
+			svc := NewWebhook(mRepo)
+
+			out, err := svc.GetDeliveries(tt.id, tt.limit)
+			if tt.err != nil {
+				require.NotNil(t, err)
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.exp, out)
+		})
+	}
+}
+
+func TestWebhook_Deliver(t *testing.T) {
+	type post struct {
+		code int
+		err  error
+	}
+	tests := []struct {
+		name     string
+		posts    []post
+		status   entity.DeliveryStatus
+		attempts int
+		code     int
+		err      string
+	}{
+		{
+			name:     "First attempt",
+			posts:    []post{{code: http.StatusNoContent}},
+			status:   entity.DeliverySucceeded,
+			attempts: 1,
+			code:     http.StatusNoContent,
+		},
+		{
+			name:     "After retries",
+			posts:    []post{{err: errors.New("connection refused")}, {code: http.StatusServiceUnavailable}, {code: http.StatusOK}},
+			status:   entity.DeliverySucceeded,
+			attempts: 3,
+			code:     http.StatusOK,
+		},
+		{
+			name:     "Dead-lettered",
+			posts:    []post{{code: http.StatusInternalServerError}, {code: http.StatusInternalServerError}, {code: http.StatusBadGateway}},
+			status:   entity.DeliveryFailed,
+			attempts: 3,
+			code:     http.StatusBadGateway,
+			err:      "unexpected response status code 502",
+		},
+	}
+
+	webhook := testWebhooks[0]
+	event := ct.Event{ID: 7, Type: SyncCompletedEvent}
+	payload := []byte(`{"id":7}`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mRepo := mocks.NewWebhookRepo()
+			for _, p := range tt.posts {
+				mRepo.On("Post", mock.Anything, webhook.URL, mock.Anything, payload).Return(p.code, p.err).Once()
+			}
+			mRepo.On("AppendDelivery", mock.Anything).Return(nil)
+			mRepo.On("AppendDeadLetter", mock.Anything).Return(nil)
+			svc := NewWebhook(mRepo)
+			svc.attempts = 3
+			svc.backoff = time.Millisecond
+
+			out := svc.deliver(context.Background(), webhook, event, payload)
+			assert.Equal(t, tt.status, out.Status)
+			assert.Equal(t, tt.attempts, out.Attempts)
+			assert.Equal(t, tt.code, out.Code)
+			assert.Equal(t, tt.err, out.Error)
+			assert.Equal(t, webhook.ID, out.WebhookID)
+			assert.Equal(t, event.ID, out.EventID)
+			mRepo.AssertCalled(t, "AppendDelivery", out)
+			if tt.status == entity.DeliveryFailed {
+				mRepo.AssertCalled(t, "AppendDeadLetter", entity.DeadLetter{Delivery: out, URL: webhook.URL, Payload: payload})
+			} else {
+				mRepo.AssertNotCalled(t, "AppendDeadLetter", mock.Anything)
+			}
+
+			// Signed request headers
+			header := mRepo.Calls[0].Arguments.Get(2).(http.Header)
+			timestamp := header.Get(WebhookTimestampHeader)
+			assert.Equal(t, signPayload(webhook.Secret, timestamp, payload), header.Get(WebhookSignatureHeader))
+			assert.Equal(t, SyncCompletedEvent, header.Get(WebhookEventHeader))
+			assert.Equal(t, out.ID, header.Get(WebhookDeliveryHeader))
+		})
+	}
+}
+
+func TestWebhook_Notify(t *testing.T) {
+	mRepo := mocks.NewWebhookRepo()
+	mRepo.On("ReadAll").Return(testWebhooks, nil)
+	mRepo.On("Post", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(http.StatusOK, nil)
+	mRepo.On("AppendDelivery", mock.Anything).Return(nil)
+	svc := NewWebhook(mRepo)
+
+	d := svc.newDispatcher(context.Background())
+	d.notify(ct.Event{ID: 1, Type: CocktailCreatedEvent})
+	d.notify(ct.Event{ID: 2, Type: SyncCompletedEvent})
+	d.close()
+	mRepo.AssertNumberOfCalls(t, "Post", 3)
+	mRepo.AssertCalled(t, "Post", mock.Anything, testWebhooks[1].URL, mock.Anything, mock.Anything)
+	assert.Empty(t, d.queues)
+}
+
+func TestWebhook_NotifyQueued(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	mRepo := mocks.NewWebhookRepo()
+	mRepo.On("ReadAll").Return(testWebhooks, nil)
+	mRepo.On("Post", mock.Anything, testWebhooks[0].URL, mock.Anything, mock.Anything).Return(http.StatusOK, nil).
+		Run(func(mock.Arguments) {
+			started <- struct{}{}
+			<-release
+		})
+	notified := make(chan struct{})
+	mRepo.On("Post", mock.Anything, testWebhooks[1].URL, mock.Anything, mock.Anything).Return(http.StatusOK, nil).
+		Run(func(mock.Arguments) { close(notified) })
+	mRepo.On("AppendDelivery", mock.Anything).Return(nil)
+	mRepo.On("AppendDeadLetter", mock.Anything).Return(nil)
+	svc := NewWebhook(mRepo)
+	svc.queueSize = 1
+
+	d := svc.newDispatcher(context.Background())
+	d.notify(ct.Event{ID: 1, Type: SyncCompletedEvent})
+	<-started
+
+	// The other webhook is notified while the first one is stuck
+	select {
+	case <-notified:
+	case <-time.After(time.Second):
+		t.Fatal("the other webhook was not notified")
+	}
+
+	// Queued behind the stuck notification, then dead-lettered once the queue is full
+	d.notify(ct.Event{ID: 2, Type: CocktailCreatedEvent})
+	d.notify(ct.Event{ID: 3, Type: CocktailCreatedEvent})
+	mRepo.AssertCalled(t, "AppendDeadLetter", mock.MatchedBy(func(letter entity.DeadLetter) bool {
+		return letter.WebhookID == testWebhooks[0].ID && letter.EventID == 3 && letter.Attempts == 0
+	}))
+
+	close(release)
+	d.close()
+	mRepo.AssertNumberOfCalls(t, "Post", 3)
+}
+
+func TestSignPayload(t *testing.T) {
+	out := signPayload("s3cr3t", "1700000000", []byte(`{"id":1}`))
+	assert.Equal(t, "sha256=", out[:7])
+	assert.Len(t, out, 7+64)
+	assert.Equal(t, out, signPayload("s3cr3t", "1700000000", []byte(`{"id":1}`)))
+	assert.NotEqual(t, out, signPayload("other", "1700000000", []byte(`{"id":1}`)))
+}
@@ -0,0 +1,192 @@
+package sharedhttp
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/config"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller"
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	// APIKeyHeader is the request header holding the API key.
+	APIKeyHeader = "X-API-Key"
+	// bearerPrefix prefixes the JWT bearer tokens in the Authorization header.
+	bearerPrefix = "Bearer "
+)
+
+// anonymous is the principal of the requests when the authentication is disabled, granted the reader role alone,
+// so the edit and admin routes respond 401 Unauthorized until keys are configured.
+var anonymous = ct.Principal{Name: "anonymous", Role: ct.ReaderRole, Method: ct.AnonymousAuth}
+
+// anonymousAdmin is the principal of the requests when the authentication is disabled and the open admin access
+// is explicitly enabled, for local development. See config.Auth.InsecureOpenAdmin.
+var anonymousAdmin = ct.Principal{Name: "anonymous", Role: ct.AdminRole, Method: ct.AnonymousAuth}
+
+// Auth authenticates the requests by their API key, or their JWT bearer token.
+// The API keys are stored by their SHA-256 hash, so the configuration never holds them in plain text.
+type Auth struct {
+	principals map[string]ct.Principal
+	jwt        JWTVerifier
+	anonymous  ct.Principal
+}
+
+// NewAuth returns a new Auth implementation, loading the API keys from the configured entries and keys file,
+// and the JWT verification keys. Returns an error if any file can not be read, or any entry is malformed.
+func NewAuth(cfg config.Auth) (Auth, error) {
+	auth := Auth{principals: make(map[string]ct.Principal), anonymous: anonymous}
+	for _, entry := range cfg.Keys() {
+		if err := auth.add(strings.Split(entry, ":")); err != nil {
+			return Auth{}, err
+		}
+	}
+	if cfg.KeysFile() != "" {
+		if err := auth.loadFile(cfg.KeysFile()); err != nil {
+			return Auth{}, err
+		}
+	}
+	jwt, err := NewJWTVerifier(cfg.JWT)
+	if err != nil {
+		return Auth{}, err
+	}
+	auth.jwt = jwt
+
+	switch {
+	case !auth.enabled() && cfg.InsecureOpenAdmin():
+		auth.anonymous = anonymousAdmin
+		logger.Log().Warn().Msg("no API keys nor JWT keys configured, and open admin access enabled: " +
+			"every request is granted the admin role, do not use it out of local development")
+	case !auth.enabled():
+		logger.Log().Warn().Msg("no API keys nor JWT keys configured, authentication disabled: " +
+			"the requests are granted the reader role, and the edit and admin routes are rejected")
+	default:
+		logger.Log().Debug().Int("keys", len(auth.principals)).Bool("jwt", jwt.Enabled()).Msg("loaded authentication keys")
+	}
+	return auth, nil
+}
+
+// enabled reports whether any API key or JWT verification key is configured.
+func (a Auth) enabled() bool {
+	return len(a.principals) > 0 || a.jwt.Enabled()
+}
+
+// loadFile adds the "name,role,sha256" records of the given CSV keys file. Lines starting with '#' are comments.
+func (a Auth) loadFile(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("open API keys file: %w", err)
+	}
+	defer func(f *os.File) {
+		if err := f.Close(); err != nil {
+			logger.Log().Error().Err(err).Str("file", file).Msg("close API keys file failed")
+		}
+	}(f)
+
+	reader := csv.NewReader(f)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read API keys file: %w", err)
+		}
+		if err := a.add(record); err != nil {
+			return err
+		}
+	}
+}
+
+// add adds the principal of the given name, role and hex encoded SHA-256 key hash fields.
+func (a Auth) add(fields []string) error {
+	if len(fields) != 3 {
+		return fmt.Errorf("invalid API key entry %q, must be name:role:sha256", strings.Join(fields, ":"))
+	}
+	name, hash := strings.TrimSpace(fields[0]), strings.ToLower(strings.TrimSpace(fields[2]))
+	role := ct.NewRole(strings.TrimSpace(fields[1]))
+	if role == ct.InvalidRole {
+		return fmt.Errorf("invalid API key %q role %q, must be reader, editor or admin", name, fields[1])
+	}
+	if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+		return fmt.Errorf("invalid API key %q hash, must be a hex encoded SHA-256 hash", name)
+	}
+	a.principals[hash] = ct.Principal{Name: name, Role: role, Method: ct.APIKeyAuth}
+	return nil
+}
+
+// Middleware authenticates the requests holding a JWT bearer token or an API key, setting their principal
+// in the request context. Requests with no credentials are left unauthenticated, so only the public routes
+// serve them; requests with invalid credentials are rejected.
+// If no keys are configured, all the requests are authenticated as an anonymous reader, or an anonymous admin if
+// the open admin access is explicitly enabled.
+func (a Auth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.enabled() {
+			next.ServeHTTP(w, r.WithContext(ct.WithPrincipal(r.Context(), a.anonymous)))
+			return
+		}
+
+		principal, found, err := a.authenticate(r)
+		if err != nil {
+			logger.Log().Info().Err(err).
+				Str("request_id", middleware.GetReqID(r.Context())).
+				Str("path", r.URL.Path).
+				Msg("authentication failed")
+			controller.RenderError(w, r, &controller.AuthErr{Err: err})
+			return
+		}
+		if !found {
+			next.ServeHTTP(w, r)
+			return
+		}
+		logger.Log().Info().
+			Str("request_id", middleware.GetReqID(r.Context())).
+			Str("principal", principal.Name).
+			Str("role", principal.Role.String()).
+			Str("auth_method", principal.Method).
+			Str("issuer", principal.Issuer).
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Msg("authenticated request")
+		next.ServeHTTP(w, r.WithContext(ct.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// authenticate returns the principal of the request credentials, if any.
+// The JWT bearer token takes precedence over the API key.
+func (a Auth) authenticate(r *http.Request) (ct.Principal, bool, error) {
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		if !a.jwt.Enabled() || !strings.HasPrefix(authorization, bearerPrefix) {
+			return ct.Principal{}, false, controller.ErrInvalidCredentials
+		}
+		principal, err := a.jwt.Verify(strings.TrimPrefix(authorization, bearerPrefix))
+		if err != nil {
+			return ct.Principal{}, false, fmt.Errorf("%w: %v", controller.ErrInvalidCredentials, err)
+		}
+		return principal, true, nil
+	}
+
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return ct.Principal{}, false, nil
+	}
+	sum := sha256.Sum256([]byte(key))
+	principal, ok := a.principals[hex.EncodeToString(sum[:])]
+	if !ok {
+		return ct.Principal{}, false, controller.ErrInvalidCredentials
+	}
+	return principal, true, nil
+}
@@ -0,0 +1,152 @@
+package sharedhttp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/config"
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hashKey returns the hex encoded SHA-256 hash of the given key.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestNewAuth(t *testing.T) {
+	keysFile := filepath.Join(t.TempDir(), "keys.csv")
+	require.Nil(t, os.WriteFile(keysFile, []byte("# name,role,sha256\nci,editor,"+hashKey("ci-key")+"\n"), 0600))
+
+	tests := []struct {
+		name    string
+		cfg     config.Auth
+		want    map[string]ct.Principal
+		wantErr bool
+	}{
+		{
+			name: "Disabled",
+			cfg:  config.NewAuth("", nil, config.JWT{}),
+			want: map[string]ct.Principal{},
+		},
+		{
+			name: "Keys and keys file",
+			cfg:  config.NewAuth(keysFile, []string{"ops:admin:" + hashKey("ops-key")}, config.JWT{}),
+			want: map[string]ct.Principal{
+				hashKey("ops-key"): {Name: "ops", Role: ct.AdminRole, Method: ct.APIKeyAuth},
+				hashKey("ci-key"):  {Name: "ci", Role: ct.EditorRole, Method: ct.APIKeyAuth},
+			},
+		},
+		{
+			name:    "Malformed entry",
+			cfg:     config.NewAuth("", []string{"ops:admin"}, config.JWT{}),
+			wantErr: true,
+		},
+		{
+			name:    "Invalid role",
+			cfg:     config.NewAuth("", []string{"ops:root:" + hashKey("ops-key")}, config.JWT{}),
+			wantErr: true,
+		},
+		{
+			name:    "Invalid hash",
+			cfg:     config.NewAuth("", []string{"ops:admin:ops-key"}, config.JWT{}),
+			wantErr: true,
+		},
+		{
+			name:    "Keys file not found",
+			cfg:     config.NewAuth(filepath.Join(t.TempDir(), "missing.csv"), nil, config.JWT{}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := NewAuth(tt.cfg)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.want, auth.principals)
+		})
+	}
+}
+
+func TestAuth_Middleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		keys      []string
+		openAdmin bool
+		key       string
+		code      int
+		principal *ct.Principal
+	}{
+		{
+			name:      "Disabled",
+			code:      http.StatusOK,
+			principal: &anonymous,
+		},
+		{
+			name:      "Disabled open admin",
+			openAdmin: true,
+			code:      http.StatusOK,
+			principal: &anonymousAdmin,
+		},
+		{
+			name:      "Enabled open admin",
+			keys:      []string{"ops:admin:" + hashKey("ops-key")},
+			openAdmin: true,
+			code:      http.StatusOK,
+		},
+		{
+			name: "No key",
+			keys: []string{"ops:admin:" + hashKey("ops-key")},
+			code: http.StatusOK,
+		},
+		{
+			name: "Invalid key",
+			keys: []string{"ops:admin:" + hashKey("ops-key")},
+			key:  "foo",
+			code: http.StatusUnauthorized,
+		},
+		{
+			name:      "Valid key",
+			keys:      []string{"ops:admin:" + hashKey("ops-key")},
+			key:       "ops-key",
+			code:      http.StatusOK,
+			principal: &ct.Principal{Name: "ops", Role: ct.AdminRole, Method: ct.APIKeyAuth},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := NewAuth(config.NewAuth("", tt.keys, config.JWT{}).WithInsecureOpenAdmin(tt.openAdmin))
+			require.Nil(t, err)
+
+			var got *ct.Principal
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if p, ok := ct.PrincipalFrom(r.Context()); ok {
+					got = &p
+				}
+			})
+
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.key != "" {
+				r.Header.Set(APIKeyHeader, tt.key)
+			}
+			rr := httptest.NewRecorder()
+			auth.Middleware(next).ServeHTTP(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.principal, got)
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package sharedhttp
 
 import (
+	"net/http"
+	"sort"
+
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/config"
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller"
 	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
@@ -14,11 +17,18 @@ type Chi struct {
 	basePath    string
 	router      *chi.Mux
 	controllers map[string]controller.HTTP
+	middlewares []func(http.Handler) http.Handler
+	limiter     *RateLimiter
 }
 
 // NewChi returns a Chi implementation.
-// It allocates a pre-configured chi.Mux instance.
-func NewChi(cfg config.Application) Chi {
+// It allocates a pre-configured chi.Mux instance, limiting the requests rate of each controller's routes.
+// Returns an error if the rate limits configuration is malformed.
+func NewChi(cfg config.Application, limits config.RateLimit) (Chi, error) {
+	limiter, err := NewRateLimiter(limits)
+	if err != nil {
+		return Chi{}, err
+	}
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
@@ -27,7 +37,8 @@ func NewChi(cfg config.Application) Chi {
 	return Chi{
 		basePath: cfg.BasePath(),
 		router:   r,
-	}
+		limiter:  limiter,
+	}, nil
 }
 
 // Add appends the controller.HTTP given to the controller map list.
@@ -38,16 +49,30 @@ func (m *Chi) Add(name string, ctrl controller.HTTP) {
 	m.controllers[name] = ctrl
 }
 
+// Use appends the given middlewares to the stack of the controller's routes.
+// They must be added before the routes are registered.
+func (m *Chi) Use(middlewares ...func(http.Handler) http.Handler) {
+	m.middlewares = append(m.middlewares, middlewares...)
+}
+
 // RegisterRoutes register the routes defined on the controller map list.
 // All controller's routes are prefixed with the configured base path. e.g. "/api/v0"
+// Every controller's routes are a route group, rate limited by the limits of the controller name.
 func (m *Chi) RegisterRoutes() {
 	if len(m.controllers) == 0 {
 		return
 	}
 	m.router.Route(m.basePath, func(r chi.Router) {
+		r.Use(m.middlewares...)
 		for name, ctrl := range m.controllers {
 			if ctrl != nil {
-				ctrl.SetRoutes(r)
+				name, ctrl := name, ctrl
+				r.Group(func(r chi.Router) {
+					if m.limiter != nil {
+						r.Use(m.limiter.Middleware(name))
+					}
+					ctrl.SetRoutes(r)
+				})
 				logger.Log().Debug().
 					Str("controller", name).
 					Msg("registered http controller")
@@ -56,6 +81,31 @@ func (m *Chi) RegisterRoutes() {
 	})
 }
 
+// Docs returns the description of the routes of the documented controllers, tagged with the controller names.
+// See controller.Documented.
+func (m *Chi) Docs() []controller.RouteDoc {
+	names := make([]string, 0, len(m.controllers))
+	for name := range m.controllers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	docs := make([]controller.RouteDoc, 0)
+	for _, name := range names {
+		documented, ok := m.controllers[name].(controller.Documented)
+		if !ok {
+			continue
+		}
+		for _, doc := range documented.Docs() {
+			if doc.Tag == "" {
+				doc.Tag = name
+			}
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
 // Router returns the configured chi.Mux instance.
 func (m *Chi) Router() *chi.Mux {
 	return m.router
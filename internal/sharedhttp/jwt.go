@@ -0,0 +1,422 @@
+package sharedhttp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers the SHA-256 hash
+	_ "crypto/sha512" // registers the SHA-384 and SHA-512 hashes
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/config"
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+)
+
+var (
+	errTokenMalformed   = errors.New("malformed token")
+	errTokenAlgorithm   = errors.New("unsupported token algorithm")
+	errTokenSignature   = errors.New("invalid token signature")
+	errTokenExpired     = errors.New("token expired")
+	errTokenNotYetValid = errors.New("token not valid yet")
+	errTokenAudience    = errors.New("invalid token audience")
+	errTokenSubject     = errors.New("missing token subject")
+)
+
+// jwtAlgorithm describes how a JWT signing algorithm verifies the signatures.
+type jwtAlgorithm struct {
+	family string // "HMAC", "RSA", "RSA-PSS" or "ECDSA"
+	hash   crypto.Hash
+}
+
+// jwtAlgorithms are the supported JWT signing algorithms.
+var jwtAlgorithms = map[string]jwtAlgorithm{
+	"HS256": {"HMAC", crypto.SHA256},
+	"HS384": {"HMAC", crypto.SHA384},
+	"HS512": {"HMAC", crypto.SHA512},
+	"RS256": {"RSA", crypto.SHA256},
+	"RS384": {"RSA", crypto.SHA384},
+	"RS512": {"RSA", crypto.SHA512},
+	"PS256": {"RSA-PSS", crypto.SHA256},
+	"PS384": {"RSA-PSS", crypto.SHA384},
+	"PS512": {"RSA-PSS", crypto.SHA512},
+	"ES256": {"ECDSA", crypto.SHA256},
+	"ES384": {"ECDSA", crypto.SHA384},
+	"ES512": {"ECDSA", crypto.SHA512},
+}
+
+// jwtKey is a key verifying the token signatures: a []byte HMAC secret, an *rsa.PublicKey or an *ecdsa.PublicKey.
+// Keys with an id only verify the tokens with the same "kid" header.
+type jwtKey struct {
+	id  string
+	key any
+}
+
+// JWTVerifier verifies the JWT bearer tokens with the locally configured keys, and maps them to a ct.Principal.
+type JWTVerifier struct {
+	keys      []jwtKey
+	audience  []string
+	roleClaim string
+	roles     map[string]ct.Role
+	leeway    time.Duration
+	now       func() time.Time
+}
+
+// NewJWTVerifier returns a new JWTVerifier implementation, loading the configured HMAC secrets, public key files
+// and JWKS file. Returns an error if any key can not be loaded, or a role mapping entry is malformed.
+func NewJWTVerifier(cfg config.JWT) (JWTVerifier, error) {
+	v := JWTVerifier{
+		audience:  cfg.Audience(),
+		roleClaim: cfg.RoleClaim(),
+		roles:     make(map[string]ct.Role),
+		leeway:    cfg.Leeway(),
+		now:       time.Now,
+	}
+	for _, secret := range cfg.HMACSecrets() {
+		v.keys = append(v.keys, jwtKey{key: []byte(secret)})
+	}
+	for _, file := range cfg.PublicKeyFiles() {
+		key, err := loadPublicKey(file)
+		if err != nil {
+			return JWTVerifier{}, err
+		}
+		v.keys = append(v.keys, jwtKey{key: key})
+	}
+	if cfg.JWKSFile() != "" {
+		keys, err := loadJWKS(cfg.JWKSFile())
+		if err != nil {
+			return JWTVerifier{}, err
+		}
+		v.keys = append(v.keys, keys...)
+	}
+
+	for _, entry := range cfg.RoleMapping() {
+		claim, role, found := strings.Cut(entry, ":")
+		if !found || ct.NewRole(role) == ct.InvalidRole {
+			return JWTVerifier{}, fmt.Errorf("invalid JWT role mapping %q, must be claim:role", entry)
+		}
+		v.roles[claim] = ct.NewRole(role)
+	}
+	return v, nil
+}
+
+// Enabled reports whether any verification key is configured.
+func (v JWTVerifier) Enabled() bool {
+	return len(v.keys) > 0
+}
+
+// jwtHeader is the JOSE header of a token.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwtClaims are the token claims validated by the verifier. The role claim is read from the raw claims.
+type jwtClaims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+}
+
+// audience is the "aud" claim, either a single string or a list of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	values, err := stringOrList(data)
+	*a = values
+	return err
+}
+
+// Verify verifies the signature and the claims of the given token, and returns its ct.Principal.
+// The "exp" claim is required; "nbf" and "aud" are validated if present, or if an audience is configured.
+// The principal role is the highest role granted by the role claim values; ct.InvalidRole if there is none.
+func (v JWTVerifier) Verify(token string) (ct.Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ct.Principal{}, errTokenMalformed
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return ct.Principal{}, err
+	}
+	alg, ok := jwtAlgorithms[header.Alg]
+	if !ok {
+		return ct.Principal{}, fmt.Errorf("%w: %q", errTokenAlgorithm, header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return ct.Principal{}, errTokenMalformed
+	}
+	if !v.verifySignature(alg, header.Kid, []byte(parts[0]+"."+parts[1]), signature) {
+		return ct.Principal{}, errTokenSignature
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return ct.Principal{}, err
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return ct.Principal{}, err
+	}
+	var raw map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return ct.Principal{}, err
+	}
+	return ct.Principal{
+		Name:   claims.Subject,
+		Role:   v.role(raw[v.roleClaim]),
+		Method: ct.JWTAuth,
+		Issuer: claims.Issuer,
+	}, nil
+}
+
+// verifySignature reports whether any of the keys matching the algorithm and key id verifies the signature.
+func (v JWTVerifier) verifySignature(alg jwtAlgorithm, kid string, signed, signature []byte) bool {
+	h := alg.hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	for _, k := range v.keys {
+		if k.id != "" && kid != "" && k.id != kid {
+			continue
+		}
+		switch key := k.key.(type) {
+		case []byte:
+			if alg.family != "HMAC" {
+				continue
+			}
+			mac := hmac.New(alg.hash.New, key)
+			mac.Write(signed)
+			if hmac.Equal(signature, mac.Sum(nil)) {
+				return true
+			}
+		case *rsa.PublicKey:
+			if alg.family == "RSA" && rsa.VerifyPKCS1v15(key, alg.hash, digest, signature) == nil {
+				return true
+			}
+			if alg.family == "RSA-PSS" && rsa.VerifyPSS(key, alg.hash, digest, signature, nil) == nil {
+				return true
+			}
+		case *ecdsa.PublicKey:
+			size := (key.Curve.Params().BitSize + 7) / 8
+			if alg.family != "ECDSA" || len(signature) != 2*size {
+				continue
+			}
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			if ecdsa.Verify(key, digest, r, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateClaims validates the subject, the validity period and the audience of the token.
+func (v JWTVerifier) validateClaims(claims jwtClaims) error {
+	now := v.now()
+	if claims.Subject == "" {
+		return errTokenSubject
+	}
+	if claims.ExpiresAt == nil || !now.Before(unixTime(*claims.ExpiresAt).Add(v.leeway)) {
+		return errTokenExpired
+	}
+	if claims.NotBefore != nil && now.Add(v.leeway).Before(unixTime(*claims.NotBefore)) {
+		return errTokenNotYetValid
+	}
+	if len(v.audience) == 0 {
+		return nil
+	}
+	for _, aud := range claims.Audience {
+		for _, accepted := range v.audience {
+			if aud == accepted {
+				return nil
+			}
+		}
+	}
+	return errTokenAudience
+}
+
+// role returns the highest role granted by the given role claim, either a single value or a list of them.
+// The values are mapped by the role mapping; unmapped values naming a role grant that role.
+func (v JWTVerifier) role(claim json.RawMessage) ct.Role {
+	role := ct.InvalidRole
+	if claim == nil {
+		return role
+	}
+	values, err := stringOrList(claim)
+	if err != nil {
+		return role
+	}
+	for _, value := range values {
+		granted, ok := v.roles[value]
+		if !ok {
+			granted = ct.NewRole(value)
+		}
+		if granted != ct.InvalidRole && !role.Allows(granted) {
+			role = granted
+		}
+	}
+	return role
+}
+
+// unixTime returns the time of the given NumericDate claim, the seconds since the Unix epoch.
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// decodeSegment decodes the given base64url encoded JSON token segment into v.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errTokenMalformed
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", errTokenMalformed, err)
+	}
+	return nil
+}
+
+// stringOrList decodes a JSON string or list of strings.
+func stringOrList(data []byte) ([]string, error) {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		return []string{value}, nil
+	}
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// loadPublicKey returns the RSA or ECDSA public key of the given PEM file, holding either a PKIX public key
+// or a certificate.
+func loadPublicKey(file string) (any, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read JWT public key file: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid JWT public key file %q, must be PEM encoded", file)
+	}
+
+	var key any
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse JWT certificate %q: %w", file, err)
+		}
+		key = cert.PublicKey
+	default:
+		if key, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+			return nil, fmt.Errorf("parse JWT public key %q: %w", file, err)
+		}
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported JWT public key %q, must be RSA or ECDSA", file)
+	}
+}
+
+// jwk is a JSON Web Key of a JWKS file.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA keys
+	N string `json:"n"`
+	E string `json:"e"`
+	// ECDSA keys
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// HMAC keys
+	K string `json:"k"`
+}
+
+// loadJWKS returns the signature verification keys of the given JWKS file.
+// The keys of other uses, e.g. encryption, are skipped.
+func loadJWKS(file string) ([]jwtKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read JWKS file: %w", err)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse JWKS file %q: %w", file, err)
+	}
+
+	keys := make([]jwtKey, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS file %q key %q: %w", file, k.Kid, err)
+		}
+		keys = append(keys, jwtKey{id: k.Kid, key: key})
+	}
+	return keys, nil
+}
+
+// publicKey returns the key verifying the signatures: a []byte, an *rsa.PublicKey or an *ecdsa.PublicKey.
+func (k jwk) publicKey() (any, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "oct":
+		return decode(k.K)
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
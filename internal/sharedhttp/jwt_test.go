@@ -0,0 +1,343 @@
+package sharedhttp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/config"
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testNow is the verification time of the test tokens.
+var testNow = time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// signToken returns a token of the given header and claims, signed by the given key with the alg header algorithm.
+func signToken(t *testing.T, header, claims map[string]any, key any) string {
+	t.Helper()
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		require.Nil(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(header) + "." + encode(claims)
+
+	alg := jwtAlgorithms[header["alg"].(string)]
+	h := alg.hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	var signature []byte
+	var err error
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(alg.hash.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if alg.family == "RSA-PSS" {
+			signature, err = rsa.SignPSS(rand.Reader, k, alg.hash, digest, nil)
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, k, alg.hash, digest)
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest)
+		size := (k.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+	}
+	require.Nil(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// writePublicKey writes the given public key in a PEM file, and returns its path.
+func writePublicKey(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.Nil(t, err)
+	file := filepath.Join(t.TempDir(), "key.pem")
+	require.Nil(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+	return file
+}
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	secret := []byte("s3cr3t")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	// The RSA key is configured by its PEM file, and the ECDSA key by a JWKS file.
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	jwks := map[string]any{"keys": []map[string]string{{
+		"kty": "EC",
+		"kid": "ec-1",
+		"use": "sig",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(ecKey.X.Bytes()),
+		"y":   base64.RawURLEncoding.EncodeToString(ecKey.Y.Bytes()),
+	}}}
+	data, err := json.Marshal(jwks)
+	require.Nil(t, err)
+	require.Nil(t, os.WriteFile(jwksFile, data, 0600))
+
+	cfg := config.NewJWT([]string{string(secret)}, []string{writePublicKey(t, &rsaKey.PublicKey)}, jwksFile,
+		[]string{"cocktails-api"}, "roles", []string{"bar-manager:admin", "staff:reader"}, time.Minute)
+	v, err := NewJWTVerifier(cfg)
+	require.Nil(t, err)
+	v.now = func() time.Time { return testNow }
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"sub":   "jdoe",
+			"iss":   "https://idp.example.com",
+			"aud":   "cocktails-api",
+			"exp":   testNow.Add(time.Hour).Unix(),
+			"roles": "staff",
+		}
+		for k, val := range overrides {
+			if val == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = val
+		}
+		return c
+	}
+	principal := func(role ct.Role) ct.Principal {
+		return ct.Principal{Name: "jdoe", Role: role, Method: ct.JWTAuth, Issuer: "https://idp.example.com"}
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    ct.Principal
+		wantErr error
+	}{
+		{
+			name:  "HMAC",
+			token: signToken(t, map[string]any{"alg": "HS256"}, claims(nil), secret),
+			want:  principal(ct.ReaderRole),
+		},
+		{
+			name:  "RSA",
+			token: signToken(t, map[string]any{"alg": "RS256"}, claims(nil), rsaKey),
+			want:  principal(ct.ReaderRole),
+		},
+		{
+			name:  "RSA-PSS",
+			token: signToken(t, map[string]any{"alg": "PS384"}, claims(nil), rsaKey),
+			want:  principal(ct.ReaderRole),
+		},
+		{
+			name:  "ECDSA from JWKS",
+			token: signToken(t, map[string]any{"alg": "ES256", "kid": "ec-1"}, claims(nil), ecKey),
+			want:  principal(ct.ReaderRole),
+		},
+		{
+			name:  "Highest mapped role",
+			token: signToken(t, map[string]any{"alg": "HS256"}, claims(map[string]any{"roles": []string{"staff", "bar-manager"}}), secret),
+			want:  principal(ct.AdminRole),
+		},
+		{
+			name:  "Unmapped role name",
+			token: signToken(t, map[string]any{"alg": "HS256"}, claims(map[string]any{"roles": "editor"}), secret),
+			want:  principal(ct.EditorRole),
+		},
+		{
+			name:  "No role",
+			token: signToken(t, map[string]any{"alg": "HS256"}, claims(map[string]any{"roles": "guest"}), secret),
+			want:  principal(ct.InvalidRole),
+		},
+		{
+			name:  "Audience list",
+			token: signToken(t, map[string]any{"alg": "HS256"}, claims(map[string]any{"aud": []string{"other", "cocktails-api"}}), secret),
+			want:  principal(ct.ReaderRole),
+		},
+		{
+			name:  "Expired within leeway",
+			token: signToken(t, map[string]any{"alg": "HS256"}, claims(map[string]any{"exp": testNow.Add(-30 * time.Second).Unix()}), secret),
+			want:  principal(ct.ReaderRole),
+		},
+		{
+			name:    "Expired",
+			token:   signToken(t, map[string]any{"alg": "HS256"}, claims(map[string]any{"exp": testNow.Add(-time.Hour).Unix()}), secret),
+			wantErr: errTokenExpired,
+		},
+		{
+			name:    "Missing expiration",
+			token:   signToken(t, map[string]any{"alg": "HS256"}, claims(map[string]any{"exp": nil}), secret),
+			wantErr: errTokenExpired,
+		},
+		{
+			name:    "Not valid yet",
+			token:   signToken(t, map[string]any{"alg": "HS256"}, claims(map[string]any{"nbf": testNow.Add(time.Hour).Unix()}), secret),
+			wantErr: errTokenNotYetValid,
+		},
+		{
+			name:    "Invalid audience",
+			token:   signToken(t, map[string]any{"alg": "HS256"}, claims(map[string]any{"aud": "other"}), secret),
+			wantErr: errTokenAudience,
+		},
+		{
+			name:    "Missing subject",
+			token:   signToken(t, map[string]any{"alg": "HS256"}, claims(map[string]any{"sub": nil}), secret),
+			wantErr: errTokenSubject,
+		},
+		{
+			name:    "Unknown key",
+			token:   signToken(t, map[string]any{"alg": "ES256"}, claims(nil), otherKey),
+			wantErr: errTokenSignature,
+		},
+		{
+			name:    "Wrong key id",
+			token:   signToken(t, map[string]any{"alg": "ES256", "kid": "ec-2"}, claims(nil), ecKey),
+			wantErr: errTokenSignature,
+		},
+		{
+			name:    "Wrong secret",
+			token:   signToken(t, map[string]any{"alg": "HS256"}, claims(nil), []byte("foo")),
+			wantErr: errTokenSignature,
+		},
+		{
+			name:    "Unsupported algorithm",
+			token:   "eyJhbGciOiJub25lIn0.e30.",
+			wantErr: errTokenAlgorithm,
+		},
+		{
+			name:    "Malformed",
+			token:   "foo",
+			wantErr: errTokenMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Verify(tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewJWTVerifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.JWT
+		enabled bool
+		wantErr bool
+	}{
+		{
+			name: "Disabled",
+			cfg:  config.JWT{},
+		},
+		{
+			name:    "HMAC secrets",
+			cfg:     config.NewJWT([]string{"s3cr3t"}, nil, "", nil, "role", []string{"bar-manager:admin"}, 0),
+			enabled: true,
+		},
+		{
+			name:    "Invalid role mapping",
+			cfg:     config.NewJWT([]string{"s3cr3t"}, nil, "", nil, "role", []string{"bar-manager:root"}, 0),
+			wantErr: true,
+		},
+		{
+			name:    "Public key file not found",
+			cfg:     config.NewJWT(nil, []string{filepath.Join(t.TempDir(), "missing.pem")}, "", nil, "role", nil, 0),
+			wantErr: true,
+		},
+		{
+			name:    "JWKS file not found",
+			cfg:     config.NewJWT(nil, nil, filepath.Join(t.TempDir(), "missing.json"), nil, "role", nil, 0),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewJWTVerifier(tt.cfg)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.enabled, v.Enabled())
+		})
+	}
+}
+
+func TestAuth_MiddlewareBearer(t *testing.T) {
+	secret := []byte("s3cr3t")
+	auth, err := NewAuth(config.NewAuth("", nil, config.NewJWT([]string{string(secret)}, nil, "", nil, "role", nil, 0)))
+	require.Nil(t, err)
+	valid := signToken(t, map[string]any{"alg": "HS256"},
+		map[string]any{"sub": "jdoe", "exp": time.Now().Add(time.Hour).Unix(), "role": "editor"}, secret)
+
+	tests := []struct {
+		name          string
+		authorization string
+		code          int
+		principal     *ct.Principal
+	}{
+		{
+			name:          "Valid token",
+			authorization: "Bearer " + valid,
+			code:          http.StatusOK,
+			principal:     &ct.Principal{Name: "jdoe", Role: ct.EditorRole, Method: ct.JWTAuth},
+		},
+		{
+			name:          "Invalid token",
+			authorization: "Bearer foo",
+			code:          http.StatusUnauthorized,
+		},
+		{
+			name:          "Not a bearer token",
+			authorization: "Basic Zm9vOmJhcg==",
+			code:          http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *ct.Principal
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if p, ok := ct.PrincipalFrom(r.Context()); ok {
+					got = &p
+				}
+			})
+
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Authorization", tt.authorization)
+			rr := httptest.NewRecorder()
+			auth.Middleware(next).ServeHTTP(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.principal, got)
+		})
+	}
+}
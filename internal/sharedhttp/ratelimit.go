@@ -0,0 +1,247 @@
+package sharedhttp
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/config"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/controller"
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/logger"
+)
+
+// The rate limit response headers.
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+	QuotaLimitHeader         = "X-Quota-Limit"
+	QuotaRemainingHeader     = "X-Quota-Remaining"
+	QuotaResetHeader         = "X-Quota-Reset"
+
+	// sweepInterval is how often the idle clients are forgotten.
+	sweepInterval = time.Minute
+)
+
+// rateLimit is the token bucket and daily quota of a route group.
+type rateLimit struct {
+	rate  float64
+	burst int
+	quota int
+}
+
+// bucket is the state of a client on a route group: its token bucket, and the requests of the day.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	day    time.Time
+	used   int
+}
+
+// decision is the outcome of a client request on the rate limiter.
+type decision struct {
+	limit      rateLimit
+	allowed    bool
+	err        error
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+	quotaLeft  int
+	quotaReset time.Duration
+}
+
+// RateLimiter limits the requests per client with a token bucket and a daily quota per route group.
+// The clients are identified by their authenticated principal, or their IP address otherwise.
+type RateLimiter struct {
+	mu        sync.Mutex
+	defaults  rateLimit
+	groups    map[string]rateLimit
+	clients   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewRateLimiter returns a new RateLimiter implementation with the configured default and route group limits.
+// Returns an error if any route group entry is malformed.
+func NewRateLimiter(cfg config.RateLimit) (*RateLimiter, error) {
+	l := &RateLimiter{
+		defaults: newRateLimit(cfg.Rate(), cfg.Burst(), cfg.DailyQuota()),
+		groups:   make(map[string]rateLimit),
+		clients:  make(map[string]*bucket),
+		now:      time.Now,
+	}
+	for _, entry := range cfg.Groups() {
+		name, limit, err := parseRateLimit(entry)
+		if err != nil {
+			return nil, err
+		}
+		l.groups[strings.ToLower(name)] = limit
+	}
+	return l, nil
+}
+
+// parseRateLimit returns the route group name and limits of the given "name:rate:burst:quota" entry.
+func parseRateLimit(entry string) (string, rateLimit, error) {
+	fields := strings.Split(entry, ":")
+	if len(fields) != 4 {
+		return "", rateLimit{}, fmt.Errorf("invalid rate limit group %q, must be name:rate:burst:quota", entry)
+	}
+	rate, errRate := strconv.ParseFloat(fields[1], 64)
+	burst, errBurst := strconv.Atoi(fields[2])
+	quota, errQuota := strconv.Atoi(fields[3])
+	if errRate != nil || errBurst != nil || errQuota != nil || rate < 0 || burst < 0 || quota < 0 {
+		return "", rateLimit{}, fmt.Errorf("invalid rate limit group %q, must be non-negative numbers", entry)
+	}
+	return fields[0], newRateLimit(rate, burst, quota), nil
+}
+
+// newRateLimit returns the limits of the given rate, burst and quota. The burst is at least one request,
+// so a rate limited group always allows some requests.
+func newRateLimit(rate float64, burst, quota int) rateLimit {
+	if rate > 0 && burst < 1 {
+		burst = 1
+	}
+	return rateLimit{rate: rate, burst: burst, quota: quota}
+}
+
+// limit returns the limits of the given route group.
+func (l *RateLimiter) limit(group string) rateLimit {
+	if limit, ok := l.groups[strings.ToLower(group)]; ok {
+		return limit
+	}
+	return l.defaults
+}
+
+// Middleware returns the middleware limiting the requests of the given route group.
+// The allowed requests are responded along with the RateLimit-* headers, and the X-Quota-* ones if a daily
+// quota is set. The denied requests are responded with 429 Too Many Requests and the Retry-After header.
+func (l *RateLimiter) Middleware(group string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d := l.allow(group, clientKey(r))
+			if d.limit.rate > 0 {
+				w.Header().Set(RateLimitLimitHeader, strconv.Itoa(d.limit.burst))
+				w.Header().Set(RateLimitRemainingHeader, strconv.Itoa(d.remaining))
+				w.Header().Set(RateLimitResetHeader, strconv.Itoa(seconds(d.reset)))
+				w.Header().Set(RateLimitPolicyHeader, fmt.Sprintf("%d;w=%d", d.limit.burst, seconds(window(d.limit))))
+			}
+			if d.limit.quota > 0 {
+				w.Header().Set(QuotaLimitHeader, strconv.Itoa(d.limit.quota))
+				w.Header().Set(QuotaRemainingHeader, strconv.Itoa(d.quotaLeft))
+				w.Header().Set(QuotaResetHeader, strconv.Itoa(seconds(d.quotaReset)))
+			}
+			if !d.allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(seconds(d.retryAfter)))
+				logger.Log().Debug().Err(d.err).Str("group", group).Str("path", r.URL.Path).Msg("request rate limited")
+				controller.RenderError(w, r, &controller.RateLimitErr{Err: d.err})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allow takes a token of the client bucket on the given route group, and counts the request on its daily quota.
+func (l *RateLimiter) allow(group, client string) decision {
+	limit := l.limit(group)
+	d := decision{limit: limit, allowed: true}
+	if limit.rate <= 0 && limit.quota <= 0 {
+		return d
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	key := strings.ToLower(group) + "|" + client
+	b, ok := l.clients[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.burst), last: now}
+		l.clients[key] = b
+	}
+
+	// Daily quota, reset at midnight UTC
+	day := now.UTC().Truncate(24 * time.Hour)
+	if !b.day.Equal(day) {
+		b.day, b.used = day, 0
+	}
+	d.quotaReset = day.Add(24 * time.Hour).Sub(now)
+	if limit.quota > 0 && b.used >= limit.quota {
+		d.allowed, d.err, d.retryAfter = false, controller.ErrQuotaExceeded, d.quotaReset
+	}
+
+	// Token bucket
+	if limit.rate > 0 {
+		b.tokens = math.Min(float64(limit.burst), b.tokens+now.Sub(b.last).Seconds()*limit.rate)
+		b.last = now
+		if d.allowed && b.tokens < 1 {
+			d.allowed, d.err = false, controller.ErrRateLimited
+			d.retryAfter = time.Duration((1 - b.tokens) / limit.rate * float64(time.Second))
+		}
+		if d.allowed {
+			b.tokens--
+		}
+		d.remaining = int(b.tokens)
+		d.reset = time.Duration((float64(limit.burst) - b.tokens) / limit.rate * float64(time.Second))
+	}
+
+	if d.allowed {
+		b.used++
+	}
+	d.quotaLeft = limit.quota - b.used
+	if d.quotaLeft < 0 {
+		d.quotaLeft = 0
+	}
+	return d
+}
+
+// sweep forgets the clients idle long enough to have refilled their bucket, and with no requests today.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	today := now.UTC().Truncate(24 * time.Hour)
+	for key, b := range l.clients {
+		group, _, _ := strings.Cut(key, "|")
+		limit := l.limit(group)
+		refilled := limit.rate <= 0 || now.Sub(b.last) >= window(limit)
+		if refilled && (limit.quota <= 0 || !b.day.Equal(today)) {
+			delete(l.clients, key)
+		}
+	}
+}
+
+// window returns the time an empty bucket of the given limits takes to refill.
+func window(limit rateLimit) time.Duration {
+	if limit.rate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(limit.burst) / limit.rate * float64(time.Second))
+}
+
+// seconds returns the given duration in seconds, rounded up.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// clientKey returns the key identifying the client of the request: its authenticated principal,
+// or its IP address for the anonymous requests.
+func clientKey(r *http.Request) string {
+	if p, ok := ct.PrincipalFrom(r.Context()); ok && p.Method != ct.AnonymousAuth {
+		return p.Method + ":" + p.Name
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
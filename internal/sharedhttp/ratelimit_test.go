@@ -0,0 +1,162 @@
+package sharedhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marcos-wz/capstone-go-bootcamp/internal/config"
+	ct "github.com/marcos-wz/capstone-go-bootcamp/internal/customtype"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	tests := []struct {
+		name    string
+		groups  []string
+		want    map[string]rateLimit
+		wantErr bool
+	}{
+		{
+			name: "Defaults",
+			want: map[string]rateLimit{},
+		},
+		{
+			name:   "Groups",
+			groups: []string{"Webhook:0.5:5:100", "HealthCheck:0:0:0", "Events:1:0:0"},
+			want: map[string]rateLimit{
+				"webhook":     {rate: 0.5, burst: 5, quota: 100},
+				"healthcheck": {},
+				"events":      {rate: 1, burst: 1},
+			},
+		},
+		{
+			name:    "Malformed group",
+			groups:  []string{"Webhook:1:5"},
+			wantErr: true,
+		},
+		{
+			name:    "Negative rate",
+			groups:  []string{"Webhook:-1:5:0"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewRateLimiter(config.NewRateLimit(10, 20, 0, tt.groups))
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.want, l.groups)
+		})
+	}
+}
+
+func TestRateLimiter_Middleware(t *testing.T) {
+	type step struct {
+		after     time.Duration
+		client    string
+		code      int
+		remaining string
+		quota     string
+		retry     string
+	}
+	tests := []struct {
+		name  string
+		cfg   config.RateLimit
+		steps []step
+	}{
+		{
+			name: "Token bucket",
+			cfg:  config.NewRateLimit(1, 2, 0, nil),
+			steps: []step{
+				{client: "10.0.0.1:1234", code: http.StatusOK, remaining: "1"},
+				{client: "10.0.0.1:1234", code: http.StatusOK, remaining: "0"},
+				{client: "10.0.0.1:1234", code: http.StatusTooManyRequests, remaining: "0", retry: "1"},
+				{client: "10.0.0.2:1234", code: http.StatusOK, remaining: "1"},
+				{after: time.Second, client: "10.0.0.1:1234", code: http.StatusOK, remaining: "0"},
+			},
+		},
+		{
+			name: "Daily quota",
+			cfg:  config.NewRateLimit(0, 0, 2, nil),
+			steps: []step{
+				{client: "10.0.0.1:1234", code: http.StatusOK, quota: "1"},
+				{client: "10.0.0.1:1234", code: http.StatusOK, quota: "0"},
+				{client: "10.0.0.1:1234", code: http.StatusTooManyRequests, quota: "0", retry: "43200"},
+				{after: 12 * time.Hour, client: "10.0.0.1:1234", code: http.StatusOK, quota: "1"},
+			},
+		},
+		{
+			name: "Group override",
+			cfg:  config.NewRateLimit(1, 1, 0, []string{"test:0:0:0"}),
+			steps: []step{
+				{client: "10.0.0.1:1234", code: http.StatusOK},
+				{client: "10.0.0.1:1234", code: http.StatusOK},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewRateLimiter(tt.cfg)
+			require.Nil(t, err)
+			now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+			l.now = func() time.Time { return now }
+			handler := l.Middleware("Test")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			for i, s := range tt.steps {
+				now = now.Add(s.after)
+				r := httptest.NewRequest("GET", "/", nil)
+				r.RemoteAddr = s.client
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, r)
+
+				assert.Equal(t, s.code, rr.Code, "step %d", i)
+				assert.Equal(t, s.remaining, rr.Header().Get(RateLimitRemainingHeader), "step %d", i)
+				assert.Equal(t, s.quota, rr.Header().Get(QuotaRemainingHeader), "step %d", i)
+				assert.Equal(t, s.retry, rr.Header().Get("Retry-After"), "step %d", i)
+			}
+		})
+	}
+}
+
+func TestClientKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *ct.Principal
+		want      string
+	}{
+		{
+			name: "Client IP",
+			want: "ip:10.0.0.1",
+		},
+		{
+			name:      "Anonymous",
+			principal: &anonymous,
+			want:      "ip:10.0.0.1",
+		},
+		{
+			name:      "API key",
+			principal: &ct.Principal{Name: "ops", Role: ct.AdminRole, Method: ct.APIKeyAuth},
+			want:      "api_key:ops",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = "10.0.0.1:1234"
+			if tt.principal != nil {
+				r = r.WithContext(ct.WithPrincipal(r.Context(), *tt.principal))
+			}
+			assert.Equal(t, tt.want, clientKey(r))
+		})
+	}
+}
@@ -25,6 +25,7 @@ type ApiHTTP struct {
 // It uses the configuration instance to set the components.
 // It provides the controllers, repository and service dependencies.
 // Moreover, implements a http.Server instance with chi.Mux router support.
+// The returned function stops the background workers, such as the webhook notifications.
 // Returns an error if any implementation of the dependencies fails.
 func NewApiHTTP(cfg config.Config) (ApiHTTP, func(), error) {
 	// Cocktail dependencies
@@ -32,19 +33,45 @@ func NewApiHTTP(cfg config.Config) (ApiHTTP, func(), error) {
 	if err != nil {
 		return ApiHTTP{}, nil, err
 	}
-	cSvc := service.NewCocktail(cRepo)
+	events := service.NewEventBroker(0)
+	cSvc, err := service.NewCocktail(repository.NewCocktailCache(cRepo), events).WithRemovedPolicy(cfg.Database.Sync.RemovedPolicy())
+	if err != nil {
+		return ApiHTTP{}, nil, err
+	}
+
+	// Webhook dependencies
+	whRepo, err := repository.NewWebhook(cfg)
+	if err != nil {
+		return ApiHTTP{}, nil, err
+	}
+	whSvc := service.NewWebhook(whRepo)
 
 	// Router
-	router := sharedhttp.NewChi(cfg.Application)
+	auth, err := sharedhttp.NewAuth(cfg.HTTP.Auth)
+	if err != nil {
+		return ApiHTTP{}, nil, err
+	}
+	router, err := sharedhttp.NewChi(cfg.Application, cfg.HTTP.RateLimit)
+	if err != nil {
+		return ApiHTTP{}, nil, err
+	}
+	router.Use(auth.Middleware)
 	router.Add("HealthCheck", controller.NewHealthCheck())
 	router.Add("Home", controller.NewHome())
 	router.Add("Cocktail", controller.NewCocktail(cSvc))
+	router.Add("Taxonomy", controller.NewTaxonomy(cSvc))
+	router.Add("Events", controller.NewEvents(events))
+	router.Add("Webhook", controller.NewWebhook(whSvc))
+	router.Add("OpenAPI", controller.NewOpenAPI(cfg.Application, router.Router(), router.Docs))
 	router.RegisterRoutes()
 
+	ctx, stopWebhooks := context.WithCancel(context.Background())
+	go whSvc.Run(ctx, events)
+
 	return ApiHTTP{
 		cfg:    cfg,
 		server: sharedhttp.NewHTTPServer(cfg.HTTP.Server, router.Router()),
-	}, nil, nil
+	}, stopWebhooks, nil
 }
 
 // Start runs the http API and quits doing a grateful shutdown.